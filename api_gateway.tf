@@ -75,5 +75,205 @@ module "api_gateway" {
       authorization_type = "JWT"
       authorizer_key     = "cognito"
     }
+    "GET /cf/stacks" = {
+      integration = {
+        uri                    = module.get_stacks_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "GET /cf/stacks/{accountName}/{stackName}" = {
+      integration = {
+        uri                    = module.describe_stack_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "GET /cf/stacks/{accountName}/{stackName}/events" = {
+      integration = {
+        uri                    = module.stack_events_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "POST /cf/change-sets" = {
+      integration = {
+        uri                    = module.create_change_set_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "POST /cf/change-sets/{id}/execute" = {
+      integration = {
+        uri                    = module.execute_change_set_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "DELETE /cf/change-sets/{id}" = {
+      integration = {
+        uri                    = module.delete_change_set_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "DELETE /cf/stacks/{accountName}/{stackName}" = {
+      integration = {
+        uri                    = module.delete_stack_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "POST /cf/templates/convert" = {
+      integration = {
+        uri                    = module.convert_template_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "POST /cf/validate" = {
+      integration = {
+        uri                    = module.validate_template_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "POST /cf/stacks/{accountName}/{stackName}/drift" = {
+      integration = {
+        uri                    = module.stack_drift_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "POST /cf/stack-sets" = {
+      integration = {
+        uri                    = module.stack_set_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "GET /cf/stack-sets/{accountName}/{stackSetName}" = {
+      integration = {
+        uri                    = module.stack_set_status_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "GET /cf/deployments" = {
+      integration = {
+        uri                    = module.list_deployments_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "POST /cf/templates" = {
+      integration = {
+        uri                    = module.catalog_publish_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "GET /cf/templates" = {
+      integration = {
+        uri                    = module.catalog_list_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "GET /cf/templates/{templateId}/{version}" = {
+      integration = {
+        uri                    = module.catalog_get_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "POST /cf/templates/{templateId}/{version}/deprecate" = {
+      integration = {
+        uri                    = module.catalog_deprecate_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "GET /cf/templates/{templateId}" = {
+      integration = {
+        uri                    = module.catalog_list_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "GET /cf/stacks/{accountName}/{stackName}/policy" = {
+      integration = {
+        uri                    = module.get_stack_policy_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "PUT /cf/stacks/{accountName}/{stackName}/policy" = {
+      integration = {
+        uri                    = module.set_stack_policy_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "PUT /cf/stacks/{accountName}/{stackName}/termination-protection" = {
+      integration = {
+        uri                    = module.termination_protection_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "PUT /cf/guardrails" = {
+      integration = {
+        uri                    = module.guardrails_put_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "GET /cf/guardrails" = {
+      integration = {
+        uri                    = module.guardrails_get_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "POST /cf/scan" = {
+      integration = {
+        uri                    = module.security_scan_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
+    "POST /cf/preview" = {
+      integration = {
+        uri                    = module.preview_template_lambda.lambda_function_arn
+        payload_format_version = "2.0"
+      }
+      authorization_type = "JWT"
+      authorizer_key     = "cognito"
+    }
   }
 }
\ No newline at end of file
@@ -6,6 +6,85 @@ module "create_stack_lambda" {
   path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/lambda"
   api_execution_arn  = module.api_gateway.api_execution_arn
   attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID     = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID            = aws_cognito_user_pool.user_pool.id
+    REGION                  = var.region
+    TEMPLATE_STAGING_BUCKET = module.template_staging_bucket.s3_bucket_id
+    TRACKER_QUEUE_URL       = module.deployment_tracker_queue.queue_url
+    DEPLOYMENTS_TABLE_NAME  = module.deployments_dynamodb.dynamodb_table_id
+    TEMPLATE_CATALOG_TABLE  = module.template_catalog_dynamodb.dynamodb_table_id
+    IDEMPOTENCY_TABLE_NAME  = module.idempotency_dynamodb.dynamodb_table_id
+    TAG_POLICY              = jsonencode(var.tag_policy)
+    GUARDRAILS_TABLE_NAME   = module.guardrails_dynamodb.dynamodb_table_id
+    SECURITY_SCAN           = var.security_scan
+    TEMPLATE_LINT           = var.template_lint
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["cloudformation:*"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject", "s3:PutObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/templates/*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:ListBucket"]
+        Resource = module.template_staging_bucket.s3_bucket_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["sqs:SendMessage"]
+        Resource = module.deployment_tracker_queue.queue_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:PutItem"]
+        Resource = module.deployments_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem", "dynamodb:Query"]
+        Resource = module.template_catalog_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/catalog/*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:PutItem", "dynamodb:DeleteItem"]
+        Resource = module.idempotency_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:Query"]
+        Resource = module.guardrails_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "get_stacks_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-get-stacks-ms"
+  description        = "List CloudFormation Stacks in target account"
+  handler            = "${path.module}/cmd/cloudformation-ms/get-stacks/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/get-stacks/cmd"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
   variables = {
     USER_POOL_CLIENT_ID = aws_cognito_user_pool_client.client.id
     USER_POOL_ID        = aws_cognito_user_pool.user_pool.id
@@ -16,14 +95,1135 @@ module "create_stack_lambda" {
     Statement = [
       {
         Effect   = "Allow"
-        Action   = ["cloudformation:*"]
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      }
+    ]
+  })
+}
+
+module "describe_stack_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-describe-stack-ms"
+  description        = "Describe CloudFormation Stack in target account"
+  handler            = "${path.module}/cmd/cloudformation-ms/get-stacks/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/get-stacks/cmd/describe"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID        = aws_cognito_user_pool.user_pool.id
+    REGION              = var.region
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      }
+    ]
+  })
+}
+
+module "stack_events_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-stack-events-ms"
+  description        = "Poll CloudFormation Stack events in target account"
+  handler            = "${path.module}/cmd/cloudformation-ms/get-stacks/cmd/events/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/get-stacks/cmd/events"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID        = aws_cognito_user_pool.user_pool.id
+    REGION              = var.region
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      }
+    ]
+  })
+}
+
+module "create_change_set_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-create-change-set-ms"
+  description        = "Create and preview CloudFormation Change Sets in target account"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/change-set/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/change-set"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID     = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID            = aws_cognito_user_pool.user_pool.id
+    REGION                  = var.region
+    TEMPLATE_STAGING_BUCKET = module.template_staging_bucket.s3_bucket_id
+    TEMPLATE_CATALOG_TABLE  = module.template_catalog_dynamodb.dynamodb_table_id
+    TAG_POLICY              = jsonencode(var.tag_policy)
+    GUARDRAILS_TABLE_NAME   = module.guardrails_dynamodb.dynamodb_table_id
+    SECURITY_SCAN           = var.security_scan
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject", "s3:PutObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/templates/*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:ListBucket"]
+        Resource = module.template_staging_bucket.s3_bucket_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem", "dynamodb:Query"]
+        Resource = module.template_catalog_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/catalog/*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:Query"]
+        Resource = module.guardrails_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "execute_change_set_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-execute-change-set-ms"
+  description        = "Execute CloudFormation Change Sets in target account"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/execute-change-set/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/execute-change-set"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID    = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID           = aws_cognito_user_pool.user_pool.id
+    REGION                 = var.region
+    TRACKER_QUEUE_URL      = module.deployment_tracker_queue.queue_url
+    DEPLOYMENTS_TABLE_NAME = module.deployments_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["sqs:SendMessage"]
+        Resource = module.deployment_tracker_queue.queue_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:PutItem", "dynamodb:GetItem"]
+        Resource = module.deployments_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "delete_change_set_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-delete-change-set-ms"
+  description        = "Discard CloudFormation Change Sets in target account"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/delete-change-set/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/delete-change-set"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID    = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID           = aws_cognito_user_pool.user_pool.id
+    REGION                 = var.region
+    DEPLOYMENTS_TABLE_NAME = module.deployments_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem"]
+        Resource = module.deployments_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "delete_stack_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-delete-stack-ms"
+  description        = "Delete CloudFormation Stack in target account"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/delete-stack/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/delete-stack"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID    = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID           = aws_cognito_user_pool.user_pool.id
+    REGION                 = var.region
+    TRACKER_QUEUE_URL      = module.deployment_tracker_queue.queue_url
+    DEPLOYMENTS_TABLE_NAME = module.deployments_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["sqs:SendMessage"]
+        Resource = module.deployment_tracker_queue.queue_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:PutItem", "dynamodb:GetItem"]
+        Resource = module.deployments_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "convert_template_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-convert-template-ms"
+  description        = "Lambda to convert CloudFormation templates between YAML and JSON"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/convert-template/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/convert-template"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID        = aws_cognito_user_pool.user_pool.id
+    REGION              = var.region
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      }
+    ]
+  })
+}
+
+# Templates acima do limite inline (51.200 bytes) são publicados aqui e lidos
+# pelo CloudFormation da conta alvo por URL pré-assinada.
+module "template_staging_bucket" {
+  source  = "terraform-aws-modules/s3-bucket/aws"
+  version = "~> 5.3"
+
+  bucket = "${var.project}-template-staging"
+  acl    = "private"
+
+  control_object_ownership = true
+  object_ownership         = "BucketOwnerEnforced"
+
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+
+  versioning = {
+    enabled = false
+  }
+
+  lifecycle_rule = [
+    {
+      id      = "expire-staged-templates"
+      enabled = true
+      filter = {
+        prefix = "templates/"
+      }
+      expiration = {
+        days = 1
+      }
+    }
+  ]
+}
+
+module "validate_template_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-validate-template-ms"
+  description        = "Validate CloudFormation templates (local checks + ValidateTemplate) in target account"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/validate/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/validate"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID     = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID            = aws_cognito_user_pool.user_pool.id
+    REGION                  = var.region
+    TEMPLATE_STAGING_BUCKET = module.template_staging_bucket.s3_bucket_id
+    TEMPLATE_CATALOG_TABLE  = module.template_catalog_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
         Resource = "*"
       },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject", "s3:PutObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/templates/*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:ListBucket"]
+        Resource = module.template_staging_bucket.s3_bucket_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem", "dynamodb:Query"]
+        Resource = module.template_catalog_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/catalog/*"
+      }
+    ]
+  })
+}
+
+module "stack_drift_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-stack-drift-ms"
+  description        = "Detect CloudFormation Stack drift in target account"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/drift/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/drift"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID        = aws_cognito_user_pool.user_pool.id
+    REGION              = var.region
+    DRIFT_TABLE_NAME    = module.stack_drift_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
       {
         Effect   = "Allow"
         Action   = ["secretsmanager:GetSecretValue"]
         Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:PutItem"]
+        Resource = module.stack_drift_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+# Enfileira uma mensagem por conta registrada; a detecção roda no worker
+module "stack_drift_sweep_lambda" {
+  source              = "./modules/lambda"
+  name                = "${var.project}-stack-drift-sweep-ms"
+  description         = "Schedule drift detection for every registered account"
+  handler             = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/drift-sweep/main.handler"
+  path                = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/drift-sweep"
+  api_execution_arn   = module.api_gateway.api_execution_arn
+  attach_policy_json  = true
+  schedule_expression = "rate(1 day)"
+  variables = {
+    USER_POOL_CLIENT_ID   = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID          = aws_cognito_user_pool.user_pool.id
+    REGION                = var.region
+    DRIFT_SWEEP_QUEUE_URL = module.stack_drift_sweep_queue.queue_url
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:ListSecrets"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["sqs:SendMessage"]
+        Resource = module.stack_drift_sweep_queue.queue_arn
+      }
+    ]
+  })
+}
+
+module "stack_drift_sweep_queue" {
+  source  = "terraform-aws-modules/sqs/aws"
+  version = "~> 4.0"
+
+  name = "${var.project}-stack-drift-sweep"
+  # Maior que o timeout da Lambda do worker
+  visibility_timeout_seconds = 960
+
+  create_dlq = true
+  redrive_policy = {
+    maxReceiveCount = 3
+  }
+}
+
+module "stack_drift_sweep_account_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-stack-drift-sweep-account-ms"
+  description        = "Drift detection on the owner's stacks of one registered account"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/drift-sweep-account/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/drift-sweep-account"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  timeout            = 900
+  queue_arn          = module.stack_drift_sweep_queue.queue_arn
+  queue_batch_size   = 1
+  variables = {
+    USER_POOL_CLIENT_ID    = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID           = aws_cognito_user_pool.user_pool.id
+    REGION                 = var.region
+    DRIFT_TABLE_NAME       = module.stack_drift_dynamodb.dynamodb_table_id
+    DEPLOYMENTS_TABLE_NAME = module.deployments_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:GetQueueAttributes"]
+        Resource = module.stack_drift_sweep_queue.queue_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:PutItem"]
+        Resource = module.stack_drift_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:Query"]
+        Resource = "${module.deployments_dynamodb.dynamodb_table_arn}/index/gsi1"
+      }
+    ]
+  })
+}
+
+# Último resultado de drift por stack: pk=DRIFT#<owner>, sk=<account>#<region>#<stackName>
+module "stack_drift_dynamodb" {
+  source  = "terraform-aws-modules/dynamodb-table/aws"
+  version = "~> 5.0"
+
+  name      = "${var.project}-stack-drift"
+  hash_key  = "pk"
+  range_key = "sk"
+
+  attributes = [
+    {
+      name = "pk"
+      type = "S"
+    },
+    {
+      name = "sk"
+      type = "S"
+    }
+  ]
+}
+
+# Cada "[WARN] Drift detected" do sweep vira um ponto da métrica StacksDrifted,
+# base para alarmes.
+resource "aws_cloudwatch_log_metric_filter" "stack_drifted" {
+  name           = "${var.project}-stack-drifted"
+  log_group_name = "/aws/lambda/${var.project}-stack-drift-sweep-account-ms"
+  pattern        = "\"Drift detected\""
+
+  metric_transformation {
+    name      = "StacksDrifted"
+    namespace = var.project
+    value     = "1"
+  }
+
+  depends_on = [module.stack_drift_sweep_account_lambda]
+}
+
+module "stack_set_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-stack-set-ms"
+  description        = "Create CloudFormation StackSets and roll out instances across registered accounts"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/stack-set/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/stack-set"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID     = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID            = aws_cognito_user_pool.user_pool.id
+    REGION                  = var.region
+    TEMPLATE_STAGING_BUCKET = module.template_staging_bucket.s3_bucket_id
+    TEMPLATE_CATALOG_TABLE  = module.template_catalog_dynamodb.dynamodb_table_id
+    TAG_POLICY              = jsonencode(var.tag_policy)
+    GUARDRAILS_TABLE_NAME   = module.guardrails_dynamodb.dynamodb_table_id
+    SECURITY_SCAN           = var.security_scan
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject", "s3:PutObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/templates/*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:ListBucket"]
+        Resource = module.template_staging_bucket.s3_bucket_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem", "dynamodb:Query"]
+        Resource = module.template_catalog_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/catalog/*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:Query"]
+        Resource = module.guardrails_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "stack_set_status_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-stack-set-status-ms"
+  description        = "Report CloudFormation StackSet operation and instance status"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/stack-set-status/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/stack-set-status"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID        = aws_cognito_user_pool.user_pool.id
+    REGION              = var.region
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:ListSecrets"]
+        Resource = "*"
+      }
+    ]
+  })
+}
+
+module "deployment_tracker_queue" {
+  source  = "terraform-aws-modules/sqs/aws"
+  version = "~> 4.0"
+
+  name = "${var.project}-deployment-tracker"
+  # Maior que o timeout da Lambda do tracker
+  visibility_timeout_seconds = 360
+
+  create_dlq = true
+  redrive_policy = {
+    maxReceiveCount = 5
+  }
+}
+
+module "deployment_tracker_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-deployment-tracker-ms"
+  description        = "Follow started stack operations until a terminal status"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/tracker/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/tracker"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  timeout            = 300
+  queue_arn          = module.deployment_tracker_queue.queue_arn
+  queue_batch_size   = 5
+  variables = {
+    USER_POOL_CLIENT_ID    = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID           = aws_cognito_user_pool.user_pool.id
+    REGION                 = var.region
+    TRACKER_QUEUE_URL      = module.deployment_tracker_queue.queue_url
+    DEPLOYMENTS_TABLE_NAME = module.deployments_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["sqs:SendMessage", "sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:GetQueueAttributes"]
+        Resource = module.deployment_tracker_queue.queue_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:UpdateItem"]
+        Resource = module.deployments_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["events:PutEvents"]
+        Resource = "arn:aws:events:${var.region}:${data.aws_caller_identity.this.account_id}:event-bus/default"
+      }
+    ]
+  })
+}
+
+module "deployments_dynamodb" {
+  source  = "terraform-aws-modules/dynamodb-table/aws"
+  version = "~> 5.0"
+
+  name      = "${var.project}-deployments"
+  hash_key  = "pk"
+  range_key = "sk"
+
+  attributes = [
+    {
+      name = "pk"
+      type = "S"
+    },
+    {
+      name = "sk"
+      type = "S"
+    },
+    {
+      name = "gsi1pk"
+      type = "S"
+    },
+    {
+      name = "gsi1sk"
+      type = "S"
+    }
+  ]
+
+  # Só o item LATEST de cada stack tem gsi1pk (OWNER#<owner>) e gsi1sk (<account>#<region>#<stackName>)
+  global_secondary_indexes = [
+    {
+      name            = "gsi1"
+      hash_key        = "gsi1pk"
+      range_key       = "gsi1sk"
+      projection_type = "ALL"
+    }
+  ]
+}
+
+module "list_deployments_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-list-deployments-ms"
+  description        = "List deployments recorded in the inventory"
+  handler            = "${path.module}/cmd/cloudformation-ms/get-stacks/cmd/deployments/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/get-stacks/cmd/deployments"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID    = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID           = aws_cognito_user_pool.user_pool.id
+    REGION                 = var.region
+    DEPLOYMENTS_TABLE_NAME = module.deployments_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:Query", "dynamodb:GetItem"]
+        Resource = [module.deployments_dynamodb.dynamodb_table_arn, "${module.deployments_dynamodb.dynamodb_table_arn}/index/*"]
+      }
+    ]
+  })
+}
+
+module "catalog_publish_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-catalog-publish-ms"
+  description        = "Publish versioned templates to the catalog"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/catalog-publish/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/catalog-publish"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID     = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID            = aws_cognito_user_pool.user_pool.id
+    REGION                  = var.region
+    TEMPLATE_STAGING_BUCKET = module.template_staging_bucket.s3_bucket_id
+    TEMPLATE_CATALOG_TABLE  = module.template_catalog_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:PutItem"]
+        Resource = module.template_catalog_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject", "s3:PutObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/catalog/*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:ListBucket"]
+        Resource = module.template_staging_bucket.s3_bucket_arn
+      }
+    ]
+  })
+}
+
+module "catalog_list_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-catalog-list-ms"
+  description        = "List templates and versions in the catalog"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/catalog-list/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/catalog-list"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID     = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID            = aws_cognito_user_pool.user_pool.id
+    REGION                  = var.region
+    TEMPLATE_STAGING_BUCKET = module.template_staging_bucket.s3_bucket_id
+    TEMPLATE_CATALOG_TABLE  = module.template_catalog_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:Query"]
+        Resource = module.template_catalog_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "catalog_get_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-catalog-get-ms"
+  description        = "Get a template version from the catalog"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/catalog-get/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/catalog-get"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID     = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID            = aws_cognito_user_pool.user_pool.id
+    REGION                  = var.region
+    TEMPLATE_STAGING_BUCKET = module.template_staging_bucket.s3_bucket_id
+    TEMPLATE_CATALOG_TABLE  = module.template_catalog_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem"]
+        Resource = module.template_catalog_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/catalog/*"
+      }
+    ]
+  })
+}
+
+module "catalog_deprecate_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-catalog-deprecate-ms"
+  description        = "Deprecate a template version in the catalog"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/catalog-deprecate/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/catalog-deprecate"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID     = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID            = aws_cognito_user_pool.user_pool.id
+    REGION                  = var.region
+    TEMPLATE_STAGING_BUCKET = module.template_staging_bucket.s3_bucket_id
+    TEMPLATE_CATALOG_TABLE  = module.template_catalog_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:UpdateItem"]
+        Resource = module.template_catalog_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "template_catalog_dynamodb" {
+  source  = "terraform-aws-modules/dynamodb-table/aws"
+  version = "~> 5.0"
+
+  name      = "${var.project}-template-catalog"
+  hash_key  = "pk"
+  range_key = "sk"
+
+  attributes = [
+    {
+      name = "pk"
+      type = "S"
+    },
+    {
+      name = "sk"
+      type = "S"
+    }
+  ]
+}
+
+module "idempotency_dynamodb" {
+  source  = "terraform-aws-modules/dynamodb-table/aws"
+  version = "~> 5.0"
+
+  name      = "${var.project}-idempotency"
+  hash_key  = "pk"
+  range_key = "sk"
+
+  # Chaves de Idempotency-Key valem 24h (expiresAt em epoch)
+  ttl_enabled        = true
+  ttl_attribute_name = "expiresAt"
+
+  attributes = [
+    {
+      name = "pk"
+      type = "S"
+    },
+    {
+      name = "sk"
+      type = "S"
+    }
+  ]
+}
+
+module "get_stack_policy_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-get-stack-policy-ms"
+  description        = "Get CloudFormation Stack policy and termination protection"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/stack-policy-get/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/stack-policy-get"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID    = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID           = aws_cognito_user_pool.user_pool.id
+    REGION                 = var.region
+    DEPLOYMENTS_TABLE_NAME = module.deployments_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem"]
+        Resource = module.deployments_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "set_stack_policy_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-set-stack-policy-ms"
+  description        = "Set CloudFormation Stack policy in target account"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/stack-policy-set/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/stack-policy-set"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID    = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID           = aws_cognito_user_pool.user_pool.id
+    REGION                 = var.region
+    DEPLOYMENTS_TABLE_NAME = module.deployments_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem"]
+        Resource = module.deployments_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "termination_protection_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-termination-protection-ms"
+  description        = "Toggle CloudFormation Stack termination protection"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/termination-protection/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/termination-protection"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID    = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID           = aws_cognito_user_pool.user_pool.id
+    REGION                 = var.region
+    DEPLOYMENTS_TABLE_NAME = module.deployments_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem"]
+        Resource = module.deployments_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "guardrails_put_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-guardrails-put-ms"
+  description        = "Publish versioned guardrail policies"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/guardrails-put/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/guardrails-put"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID   = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID          = aws_cognito_user_pool.user_pool.id
+    REGION                = var.region
+    GUARDRAILS_TABLE_NAME = module.guardrails_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:PutItem", "dynamodb:Query"]
+        Resource = module.guardrails_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "guardrails_get_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-guardrails-get-ms"
+  description        = "Get guardrail policy versions"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/guardrails-get/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/guardrails-get"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID   = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID          = aws_cognito_user_pool.user_pool.id
+    REGION                = var.region
+    GUARDRAILS_TABLE_NAME = module.guardrails_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem", "dynamodb:Query"]
+        Resource = module.guardrails_dynamodb.dynamodb_table_arn
+      }
+    ]
+  })
+}
+
+module "guardrails_dynamodb" {
+  source  = "terraform-aws-modules/dynamodb-table/aws"
+  version = "~> 5.0"
+
+  name      = "${var.project}-guardrails"
+  hash_key  = "pk"
+  range_key = "sk"
+
+  attributes = [
+    {
+      name = "pk"
+      type = "S"
+    },
+    {
+      name = "sk"
+      type = "S"
+    }
+  ]
+}
+
+module "security_scan_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-security-scan-ms"
+  description        = "Scan CloudFormation templates for security issues (JSON or SARIF)"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/scan/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/scan"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID     = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID            = aws_cognito_user_pool.user_pool.id
+    REGION                  = var.region
+    TEMPLATE_STAGING_BUCKET = module.template_staging_bucket.s3_bucket_id
+    TEMPLATE_CATALOG_TABLE  = module.template_catalog_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem", "dynamodb:Query"]
+        Resource = module.template_catalog_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/catalog/*"
+      }
+    ]
+  })
+}
+
+module "preview_template_lambda" {
+  source             = "./modules/lambda"
+  name               = "${var.project}-preview-template-ms"
+  description        = "Preview the effective CloudFormation template (parameters, conditions and intrinsic functions) for a target account"
+  handler            = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/preview/main.handler"
+  path               = "${path.module}/cmd/cloudformation-ms/create-stack/cmd/preview"
+  api_execution_arn  = module.api_gateway.api_execution_arn
+  attach_policy_json = true
+  variables = {
+    USER_POOL_CLIENT_ID     = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID            = aws_cognito_user_pool.user_pool.id
+    REGION                  = var.region
+    TEMPLATE_STAGING_BUCKET = module.template_staging_bucket.s3_bucket_id
+    TEMPLATE_CATALOG_TABLE  = module.template_catalog_dynamodb.dynamodb_table_id
+  }
+  policy_json = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["secretsmanager:GetSecretValue"]
+        Resource = "*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:GetItem", "dynamodb:Query"]
+        Resource = module.template_catalog_dynamodb.dynamodb_table_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject"]
+        Resource = "${module.template_staging_bucket.s3_bucket_arn}/catalog/*"
       }
     ]
   })
-}
\ No newline at end of file
+}
@@ -54,3 +54,12 @@ func OwnerFromRequest(ctx context.Context, req events.APIGatewayV2HTTPRequest, c
 	}
 	return "", errors.New("unauthorized: no username/sub in claims")
 }
+
+// Claim devolve um claim do JWT já validado pelo authorizer do API Gateway.
+// Atributos custom do Cognito chegam no ID token como "custom:<nome>".
+func Claim(req events.APIGatewayV2HTTPRequest, name string) string {
+	if req.RequestContext.Authorizer == nil || req.RequestContext.Authorizer.JWT == nil {
+		return ""
+	}
+	return req.RequestContext.Authorizer.JWT.Claims[name]
+}
@@ -0,0 +1,145 @@
+package capability
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// Tipos IAM que exigem CAPABILITY_IAM. Outros AWS::IAM:: (OIDCProvider,
+// SAMLProvider, ServerCertificate, VirtualMFADevice, ServiceLinkedRole...)
+// não exigem capability.
+var iamTypes = map[string]bool{
+	"AWS::IAM::AccessKey":           true,
+	"AWS::IAM::Group":               true,
+	"AWS::IAM::GroupPolicy":         true,
+	"AWS::IAM::InstanceProfile":     true,
+	"AWS::IAM::ManagedPolicy":       true,
+	"AWS::IAM::Policy":              true,
+	"AWS::IAM::Role":                true,
+	"AWS::IAM::RolePolicy":          true,
+	"AWS::IAM::User":                true,
+	"AWS::IAM::UserPolicy":          true,
+	"AWS::IAM::UserToGroupAddition": true,
+}
+
+// Propriedades que dão nome fixo a recursos IAM e exigem CAPABILITY_NAMED_IAM.
+var namedIAM = map[string]string{
+	"AWS::IAM::Role":            "RoleName",
+	"AWS::IAM::User":            "UserName",
+	"AWS::IAM::Group":           "GroupName",
+	"AWS::IAM::InstanceProfile": "InstanceProfileName",
+	"AWS::IAM::ManagedPolicy":   "ManagedPolicyName",
+}
+
+// Requirement explica por que o template exige uma capability.
+type Requirement struct {
+	Capability cft.Capability
+	Resource   string // logical id; vazio para o template como um todo
+	Reason     string
+}
+
+func (r Requirement) String() string {
+	if r.Resource == "" {
+		return fmt.Sprintf("%s (%s)", r.Capability, r.Reason)
+	}
+	return fmt.Sprintf("%s (%s: %s)", r.Capability, r.Resource, r.Reason)
+}
+
+// Required calcula as capabilities exigidas pelo template. Macros só podem ser
+// expandidas pelo CloudFormation, então pedem CAPABILITY_AUTO_EXPAND. Nested
+// stacks não exigem capability própria; recursos IAM do template filho pedem
+// CAPABILITY_IAM na stack raiz, mas o filho não é inspecionado aqui.
+func Required(tpl map[string]any) []Requirement {
+	var out []Requirement
+	if t, ok := tpl["Transform"]; ok && t != nil {
+		out = append(out, Requirement{
+			Capability: cft.CapabilityCapabilityAutoExpand,
+			Reason:     fmt.Sprintf("template declares Transform %s", transformNames(t)),
+		})
+	}
+
+	resources, _ := tpl["Resources"].(map[string]any)
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		res, _ := resources[name].(map[string]any)
+		typ, _ := res["Type"].(string)
+		props, _ := res["Properties"].(map[string]any)
+		switch {
+		case iamTypes[typ]:
+			if prop, ok := namedIAM[typ]; ok && props[prop] != nil {
+				out = append(out, Requirement{
+					Capability: cft.CapabilityCapabilityNamedIam,
+					Resource:   name,
+					Reason:     fmt.Sprintf("%s with custom %s", typ, prop),
+				})
+				continue
+			}
+			out = append(out, Requirement{Capability: cft.CapabilityCapabilityIam, Resource: name, Reason: typ})
+		case strings.HasPrefix(typ, "AWS::Serverless::"):
+			// Sem Transform o CloudFormation rejeita o template de qualquer forma
+			out = append(out, Requirement{Capability: cft.CapabilityCapabilityAutoExpand, Resource: name, Reason: typ})
+		}
+	}
+	return out
+}
+
+// Missing devolve os requisitos não atendidos por given.
+// CAPABILITY_NAMED_IAM também cobre recursos que pedem CAPABILITY_IAM.
+func Missing(reqs []Requirement, given []cft.Capability) []Requirement {
+	has := map[cft.Capability]bool{}
+	for _, c := range given {
+		has[c] = true
+	}
+	if has[cft.CapabilityCapabilityNamedIam] {
+		has[cft.CapabilityCapabilityIam] = true
+	}
+	var out []Requirement
+	for _, r := range reqs {
+		if !has[r.Capability] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Add acrescenta a given as capabilities que faltam, sem duplicar.
+func Add(given []cft.Capability, missing []Requirement) []cft.Capability {
+	out := append([]cft.Capability(nil), given...)
+	seen := map[cft.Capability]bool{}
+	for _, c := range given {
+		seen[c] = true
+	}
+	for _, r := range missing {
+		if !seen[r.Capability] {
+			seen[r.Capability] = true
+			out = append(out, r.Capability)
+		}
+	}
+	return out
+}
+
+func transformNames(t any) string {
+	switch v := t.(type) {
+	case string:
+		return v
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, it := range v {
+			if s, ok := it.(string); ok {
+				parts = append(parts, s)
+			} else {
+				parts = append(parts, "<macro>")
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	return "<macro>"
+}
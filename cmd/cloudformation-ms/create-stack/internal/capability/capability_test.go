@@ -0,0 +1,77 @@
+package capability
+
+import (
+	"reflect"
+	"testing"
+
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func TestRequiredIAMTypes(t *testing.T) {
+	iam := []cft.Capability{cft.CapabilityCapabilityIam}
+	named := []cft.Capability{cft.CapabilityCapabilityNamedIam}
+	cases := []struct {
+		typ   string
+		props map[string]any
+		want  []cft.Capability
+	}{
+		{"AWS::IAM::AccessKey", nil, iam},
+		{"AWS::IAM::Group", nil, iam},
+		{"AWS::IAM::GroupPolicy", nil, iam},
+		{"AWS::IAM::InstanceProfile", nil, iam},
+		{"AWS::IAM::ManagedPolicy", nil, iam},
+		{"AWS::IAM::Policy", nil, iam},
+		{"AWS::IAM::Role", nil, iam},
+		{"AWS::IAM::RolePolicy", nil, iam},
+		{"AWS::IAM::User", nil, iam},
+		{"AWS::IAM::UserPolicy", nil, iam},
+		{"AWS::IAM::UserToGroupAddition", nil, iam},
+		{"AWS::IAM::Role", map[string]any{"RoleName": "app"}, named},
+		{"AWS::IAM::ManagedPolicy", map[string]any{"ManagedPolicyName": "app"}, named},
+		{"AWS::IAM::OIDCProvider", nil, nil},
+		{"AWS::IAM::SAMLProvider", nil, nil},
+		{"AWS::IAM::ServerCertificate", nil, nil},
+		{"AWS::IAM::VirtualMFADevice", nil, nil},
+		{"AWS::IAM::ServiceLinkedRole", nil, nil},
+		{"AWS::S3::Bucket", nil, nil},
+		{"AWS::Serverless::Function", nil, []cft.Capability{cft.CapabilityCapabilityAutoExpand}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.typ, func(t *testing.T) {
+			tpl := map[string]any{"Resources": map[string]any{
+				"Res": map[string]any{"Type": tc.typ, "Properties": tc.props},
+			}}
+			var got []cft.Capability
+			for _, r := range Required(tpl) {
+				got = append(got, r.Capability)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRequiredTransform(t *testing.T) {
+	tpl := map[string]any{
+		"Transform": []any{"AWS::Serverless-2016-10-31", map[string]any{"Name": "AWS::Include"}},
+		"Resources": map[string]any{},
+	}
+	reqs := Required(tpl)
+	if len(reqs) != 1 || reqs[0].Capability != cft.CapabilityCapabilityAutoExpand {
+		t.Fatalf("unexpected requirements: %v", reqs)
+	}
+	if want := "CAPABILITY_AUTO_EXPAND (template declares Transform AWS::Serverless-2016-10-31, <macro>)"; reqs[0].String() != want {
+		t.Fatalf("got %q, want %q", reqs[0].String(), want)
+	}
+}
+
+func TestMissingNamedIAMCoversIAM(t *testing.T) {
+	reqs := []Requirement{{Capability: cft.CapabilityCapabilityIam, Resource: "Role", Reason: "AWS::IAM::Role"}}
+	if missing := Missing(reqs, []cft.Capability{cft.CapabilityCapabilityNamedIam}); len(missing) != 0 {
+		t.Fatalf("CAPABILITY_NAMED_IAM must cover CAPABILITY_IAM, missing %v", missing)
+	}
+	if missing := Missing(reqs, nil); len(missing) != 1 {
+		t.Fatalf("expected CAPABILITY_IAM to be missing, got %v", missing)
+	}
+}
@@ -0,0 +1,203 @@
+package catalog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"create-stack-ms/internal/staging"
+)
+
+var (
+	ErrNotFound   = errors.New("template version not found")
+	ErrExists     = errors.New("template version already exists")
+	ErrDeprecated = errors.New("template version is deprecated")
+)
+
+// Tags gravadas nas stacks criadas a partir do catálogo, para achar tudo que
+// roda uma versão antiga (GET /cf/stacks?tag=cloudbuilder:template-version=1.0.0).
+const (
+	TagTemplateID      = "cloudbuilder:template-id"
+	TagTemplateVersion = "cloudbuilder:template-version"
+)
+
+var idRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Version é uma versão publicada de um template do catálogo do owner. O
+// conteúdo é imutável: corrigir um template significa publicar outra versão.
+type Version struct {
+	Owner              string
+	TemplateID         string
+	Version            string
+	Description        string
+	ContentHash        string // sha256 do JSON normalizado
+	ContentKey         string
+	Size               int
+	CreatedAt          time.Time
+	RequestID          string
+	Deprecated         bool
+	DeprecationMessage string
+	DeprecatedAt       time.Time
+}
+
+type Store interface {
+	// Put falha com ErrExists quando a versão já foi publicada.
+	Put(ctx context.Context, v Version) error
+	Get(ctx context.Context, owner, templateID, version string) (Version, error)
+	// List devolve as versões de um template, ou de todos com templateID vazio.
+	List(ctx context.Context, owner, templateID string) ([]Version, error)
+	Deprecate(ctx context.Context, owner, templateID, version, message string, at time.Time) (Version, error)
+}
+
+// Catalog junta os metadados (Store) e o conteúdo, gravado por hash no
+// bucket de templates: versões com o mesmo conteúdo compartilham o objeto.
+type Catalog struct {
+	store   Store
+	content *staging.Stager
+}
+
+func New(store Store, content *staging.Stager) *Catalog {
+	return &Catalog{store: store, content: content}
+}
+
+func ValidateID(id string) error {
+	if !idRe.MatchString(id) {
+		return fmt.Errorf("invalid templateId '%s' (letters, digits, '.', '_' and '-', up to 64 chars)", id)
+	}
+	return nil
+}
+
+// Publish grava o conteúdo (JSON já normalizado) e registra a versão.
+func (c *Catalog) Publish(ctx context.Context, v Version, body []byte) (Version, error) {
+	if err := ValidateID(v.TemplateID); err != nil {
+		return Version{}, err
+	}
+	if _, err := ParseSemver(v.Version); err != nil {
+		return Version{}, err
+	}
+	key, err := c.content.Save(ctx, v.Owner, body)
+	if err != nil {
+		return Version{}, err
+	}
+	v.ContentKey = key
+	sum := sha256.Sum256(body)
+	v.ContentHash = hex.EncodeToString(sum[:])
+	v.Size = len(body)
+	if err := c.store.Put(ctx, v); err != nil {
+		return Version{}, err
+	}
+	return v, nil
+}
+
+// Resolve encontra a versão pedida; sem versão, usa Latest. Versões
+// depreciadas não servem para novas stacks.
+func (c *Catalog) Resolve(ctx context.Context, owner, templateID, version string) (Version, error) {
+	if version == "" {
+		versions, err := c.Versions(ctx, owner, templateID)
+		if err != nil {
+			return Version{}, err
+		}
+		if len(versions) == 0 {
+			return Version{}, ErrNotFound
+		}
+		v, ok := Latest(versions)
+		if !ok {
+			return Version{}, fmt.Errorf("%w: every version of '%s' is deprecated", ErrDeprecated, templateID)
+		}
+		return v, nil
+	}
+
+	v, err := c.store.Get(ctx, owner, templateID, version)
+	if err != nil {
+		return Version{}, err
+	}
+	if v.Deprecated {
+		msg := fmt.Errorf("%w: %s@%s", ErrDeprecated, templateID, version)
+		if v.DeprecationMessage != "" {
+			msg = fmt.Errorf("%w (%s)", msg, v.DeprecationMessage)
+		}
+		return Version{}, msg
+	}
+	return v, nil
+}
+
+func (c *Catalog) Get(ctx context.Context, owner, templateID, version string) (Version, error) {
+	return c.store.Get(ctx, owner, templateID, version)
+}
+
+// Content lê o template (JSON) de uma versão.
+func (c *Catalog) Content(ctx context.Context, v Version) ([]byte, error) {
+	return c.content.Load(ctx, v.ContentKey)
+}
+
+// Versions lista as versões de um template, da mais nova para a mais antiga.
+func (c *Catalog) Versions(ctx context.Context, owner, templateID string) ([]Version, error) {
+	if err := ValidateID(templateID); err != nil {
+		return nil, err
+	}
+	versions, err := c.store.List(ctx, owner, templateID)
+	if err != nil {
+		return nil, err
+	}
+	SortNewestFirst(versions)
+	return versions, nil
+}
+
+// Templates devolve todas as versões do owner agrupadas por template, cada
+// grupo da mais nova para a mais antiga.
+func (c *Catalog) Templates(ctx context.Context, owner string) (map[string][]Version, error) {
+	all, err := c.store.List(ctx, owner, "")
+	if err != nil {
+		return nil, err
+	}
+	out := map[string][]Version{}
+	for _, v := range all {
+		out[v.TemplateID] = append(out[v.TemplateID], v)
+	}
+	for _, vs := range out {
+		SortNewestFirst(vs)
+	}
+	return out, nil
+}
+
+func (c *Catalog) Deprecate(ctx context.Context, owner, templateID, version, message string, at time.Time) (Version, error) {
+	return c.store.Deprecate(ctx, owner, templateID, version, message, at)
+}
+
+// Latest escolhe, numa lista da mais nova para a mais antiga, a primeira
+// versão final não depreciada; pré-releases só quando não há versão final.
+func Latest(versions []Version) (Version, bool) {
+	var pre *Version
+	for i, v := range versions {
+		if v.Deprecated {
+			continue
+		}
+		sv, err := ParseSemver(v.Version)
+		if err == nil && sv.Pre == "" {
+			return v, true
+		}
+		if pre == nil {
+			pre = &versions[i]
+		}
+	}
+	if pre != nil {
+		return *pre, true
+	}
+	return Version{}, false
+}
+
+func SortNewestFirst(vs []Version) {
+	sort.SliceStable(vs, func(i, j int) bool {
+		a, errA := ParseSemver(vs[i].Version)
+		b, errB := ParseSemver(vs[j].Version)
+		if errA != nil || errB != nil {
+			return vs[i].Version > vs[j].Version
+		}
+		return a.Compare(b) > 0
+	})
+}
@@ -0,0 +1,159 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type API interface {
+	dynamodb.QueryAPIClient
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	UpdateItem(ctx context.Context, in *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+}
+
+// DynamoStore grava um item por versão: pk=CATALOG#<owner>,
+// sk=<templateId>#<version>. A ordenação por semver é feita em memória.
+type DynamoStore struct {
+	api   API
+	table string
+}
+
+func NewDynamoStore(api API, table string) *DynamoStore {
+	return &DynamoStore{api: api, table: table}
+}
+
+func key(owner, templateID, version string) map[string]ddbtypes.AttributeValue {
+	return map[string]ddbtypes.AttributeValue{
+		"pk": &ddbtypes.AttributeValueMemberS{Value: "CATALOG#" + owner},
+		"sk": &ddbtypes.AttributeValueMemberS{Value: templateID + "#" + version},
+	}
+}
+
+func (s *DynamoStore) Put(ctx context.Context, v Version) error {
+	item := key(v.Owner, v.TemplateID, v.Version)
+	item["owner"] = &ddbtypes.AttributeValueMemberS{Value: v.Owner}
+	item["templateId"] = &ddbtypes.AttributeValueMemberS{Value: v.TemplateID}
+	item["version"] = &ddbtypes.AttributeValueMemberS{Value: v.Version}
+	item["contentHash"] = &ddbtypes.AttributeValueMemberS{Value: v.ContentHash}
+	item["contentKey"] = &ddbtypes.AttributeValueMemberS{Value: v.ContentKey}
+	item["size"] = &ddbtypes.AttributeValueMemberN{Value: strconv.Itoa(v.Size)}
+	item["createdAt"] = &ddbtypes.AttributeValueMemberS{Value: v.CreatedAt.UTC().Format(time.RFC3339)}
+	item["deprecated"] = &ddbtypes.AttributeValueMemberBOOL{Value: false}
+	if v.Description != "" {
+		item["description"] = &ddbtypes.AttributeValueMemberS{Value: v.Description}
+	}
+	if v.RequestID != "" {
+		item["requestId"] = &ddbtypes.AttributeValueMemberS{Value: v.RequestID}
+	}
+	_, err := s.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	var ccf *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrExists
+	}
+	return err
+}
+
+func (s *DynamoStore) Get(ctx context.Context, owner, templateID, version string) (Version, error) {
+	out, err := s.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            key(owner, templateID, version),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return Version{}, err
+	}
+	if len(out.Item) == 0 {
+		return Version{}, ErrNotFound
+	}
+	return fromItem(out.Item), nil
+}
+
+func (s *DynamoStore) List(ctx context.Context, owner, templateID string) ([]Version, error) {
+	cond := "pk = :pk"
+	values := map[string]ddbtypes.AttributeValue{
+		":pk": &ddbtypes.AttributeValueMemberS{Value: "CATALOG#" + owner},
+	}
+	if templateID != "" {
+		cond += " AND begins_with(sk, :id)"
+		values[":id"] = &ddbtypes.AttributeValueMemberS{Value: templateID + "#"}
+	}
+	var out []Version
+	p := dynamodb.NewQueryPaginator(s.api, &dynamodb.QueryInput{
+		TableName:                 aws.String(s.table),
+		KeyConditionExpression:    aws.String(cond),
+		ExpressionAttributeValues: values,
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range page.Items {
+			out = append(out, fromItem(it))
+		}
+	}
+	return out, nil
+}
+
+func (s *DynamoStore) Deprecate(ctx context.Context, owner, templateID, version, message string, at time.Time) (Version, error) {
+	out, err := s.api.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.table),
+		Key:                 key(owner, templateID, version),
+		UpdateExpression:    aws.String("SET deprecated = :true, deprecationMessage = :msg, deprecatedAt = :at"),
+		ConditionExpression: aws.String("attribute_exists(pk)"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":true": &ddbtypes.AttributeValueMemberBOOL{Value: true},
+			":msg":  &ddbtypes.AttributeValueMemberS{Value: message},
+			":at":   &ddbtypes.AttributeValueMemberS{Value: at.UTC().Format(time.RFC3339)},
+		},
+		ReturnValues: ddbtypes.ReturnValueAllNew,
+	})
+	var ccf *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return Version{}, ErrNotFound
+	}
+	if err != nil {
+		return Version{}, err
+	}
+	return fromItem(out.Attributes), nil
+}
+
+func fromItem(it map[string]ddbtypes.AttributeValue) Version {
+	v := Version{
+		Owner:              str(it, "owner"),
+		TemplateID:         str(it, "templateId"),
+		Version:            str(it, "version"),
+		Description:        str(it, "description"),
+		ContentHash:        str(it, "contentHash"),
+		ContentKey:         str(it, "contentKey"),
+		RequestID:          str(it, "requestId"),
+		DeprecationMessage: str(it, "deprecationMessage"),
+	}
+	if n, ok := it["size"].(*ddbtypes.AttributeValueMemberN); ok {
+		v.Size, _ = strconv.Atoi(n.Value)
+	}
+	if b, ok := it["deprecated"].(*ddbtypes.AttributeValueMemberBOOL); ok {
+		v.Deprecated = b.Value
+	}
+	v.CreatedAt, _ = time.Parse(time.RFC3339, str(it, "createdAt"))
+	v.DeprecatedAt, _ = time.Parse(time.RFC3339, str(it, "deprecatedAt"))
+	return v
+}
+
+func str(it map[string]ddbtypes.AttributeValue, key string) string {
+	if v, ok := it[key].(*ddbtypes.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
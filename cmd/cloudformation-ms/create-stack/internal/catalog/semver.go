@@ -0,0 +1,79 @@
+package catalog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var semverRe = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// Semver é uma versão MAJOR.MINOR.PATCH[-pre]. Metadados de build (+...) não
+// são aceitos: duas versões que diferem só neles seriam a mesma.
+type Semver struct {
+	Major, Minor, Patch int
+	Pre                 string
+}
+
+func ParseSemver(s string) (Semver, error) {
+	m := semverRe.FindStringSubmatch(s)
+	if m == nil {
+		return Semver{}, fmt.Errorf("invalid version '%s' (expected MAJOR.MINOR.PATCH, ex. 1.4.0)", s)
+	}
+	var v Semver
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	v.Pre = m[4]
+	return v, nil
+}
+
+// Compare segue a precedência do semver.org: pré-releases vêm antes da
+// versão final e são comparadas campo a campo.
+func (v Semver) Compare(o Semver) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	a, b := strings.Split(v.Pre, "."), strings.Split(o.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePre(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+func comparePre(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return sign(na - nb)
+	case errA == nil:
+		return -1 // identificadores numéricos têm precedência menor
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -1,13 +1,17 @@
 package cfn
 
 import (
+	"fmt"
 	"strings"
 
 	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 )
 
-func Capabilities(vals []string) []cft.Capability {
+// Capabilities converte as capabilities da requisição. Valores desconhecidos
+// são erro: ignorá-los só adiaria a falha para o CloudFormation.
+func Capabilities(vals []string) ([]cft.Capability, error) {
 	var out []cft.Capability
+	var unknown []string
 	for _, v := range vals {
 		switch strings.ToUpper(strings.TrimSpace(v)) {
 		case "CAPABILITY_IAM":
@@ -16,8 +20,22 @@ func Capabilities(vals []string) []cft.Capability {
 			out = append(out, cft.CapabilityCapabilityNamedIam)
 		case "CAPABILITY_AUTO_EXPAND":
 			out = append(out, cft.CapabilityCapabilityAutoExpand)
+		default:
+			unknown = append(unknown, "'"+v+"'")
 		}
 	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("invalid capabilities: %s (allowed: CAPABILITY_IAM, CAPABILITY_NAMED_IAM, CAPABILITY_AUTO_EXPAND)", strings.Join(unknown, ", "))
+	}
+	return out, nil
+}
+
+// CapabilityNames converte as capabilities para a resposta HTTP.
+func CapabilityNames(caps []cft.Capability) []string {
+	out := make([]string, 0, len(caps))
+	for _, c := range caps {
+		out = append(out, string(c))
+	}
 	return out
 }
 
@@ -32,3 +50,15 @@ func Tags(m map[string]string) []cft.Tag {
 	}
 	return out
 }
+
+func OnFailure(v string) (cft.OnFailure, error) {
+	switch strings.ToUpper(v) {
+	case "ROLLBACK":
+		return cft.OnFailureRollback, nil
+	case "DELETE":
+		return cft.OnFailureDelete, nil
+	case "DO_NOTHING", "":
+		return cft.OnFailureDoNothing, nil
+	}
+	return "", fmt.Errorf("invalid onFailure: %s", v)
+}
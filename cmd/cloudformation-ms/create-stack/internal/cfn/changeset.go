@@ -0,0 +1,158 @@
+package cfn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"create-stack-ms/internal/types"
+)
+
+var (
+	ErrChangeSetTimeout     = errors.New("timed out waiting for change set to be computed")
+	ErrInvalidChangeSetType = errors.New("invalid changeSetType")
+)
+
+const changeSetPollInterval = 2 * time.Second
+
+// ChangeSetType resolve o tipo do change set. Sem valor explícito, usa UPDATE
+// quando a stack já existe e CREATE caso contrário. Stacks em
+// REVIEW_IN_PROGRESS (criadas por um change set CREATE nunca executado)
+// continuam aceitando apenas CREATE.
+func ChangeSetType(ctx context.Context, api cf.DescribeStacksAPIClient, stackName, requested string) (cft.ChangeSetType, error) {
+	switch requested {
+	case "UPDATE":
+		return cft.ChangeSetTypeUpdate, nil
+	case "CREATE":
+		return cft.ChangeSetTypeCreate, nil
+	case "":
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidChangeSetType, requested)
+	}
+
+	stack, err := LookupStack(ctx, api, stackName)
+	if errors.Is(err, ErrStackNotFound) {
+		return cft.ChangeSetTypeCreate, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if stack.StackStatus == cft.StackStatusReviewInProgress {
+		return cft.ChangeSetTypeCreate, nil
+	}
+	return cft.ChangeSetTypeUpdate, nil
+}
+
+// WaitChangeSet aguarda o change set sair de CREATE_PENDING/CREATE_IN_PROGRESS.
+// Um change set FAILED não é erro aqui: o motivo vem em StatusReason (ex.
+// "The submitted information didn't contain changes").
+func WaitChangeSet(ctx context.Context, api cf.DescribeChangeSetAPIClient, changeSetID string, maxWait time.Duration) (types.ChangeSetResponse, error) {
+	deadline := time.Now().Add(maxWait)
+	if d, ok := ctx.Deadline(); ok && d.Add(-5*time.Second).Before(deadline) {
+		deadline = d.Add(-5 * time.Second)
+	}
+
+	for {
+		cs, err := DescribeChangeSet(ctx, api, changeSetID)
+		if err != nil {
+			return types.ChangeSetResponse{}, err
+		}
+		switch cft.ChangeSetStatus(cs.Status) {
+		case cft.ChangeSetStatusCreateComplete, cft.ChangeSetStatusFailed,
+			cft.ChangeSetStatusDeleteComplete, cft.ChangeSetStatusDeleteFailed:
+			return cs, nil
+		}
+		if time.Now().Add(changeSetPollInterval).After(deadline) {
+			return cs, ErrChangeSetTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return cs, ctx.Err()
+		case <-time.After(changeSetPollInterval):
+		}
+	}
+}
+
+// DescribeChangeSet lê o change set com todas as páginas de mudanças.
+func DescribeChangeSet(ctx context.Context, api cf.DescribeChangeSetAPIClient, changeSetID string) (types.ChangeSetResponse, error) {
+	var (
+		resp  types.ChangeSetResponse
+		token *string
+	)
+	resp.Changes = []types.ResourceChange{}
+	for {
+		out, err := api.DescribeChangeSet(ctx, &cf.DescribeChangeSetInput{
+			ChangeSetName: aws.String(changeSetID),
+			NextToken:     token,
+		})
+		if err != nil {
+			return types.ChangeSetResponse{}, err
+		}
+		resp.ChangeSetID = aws.ToString(out.ChangeSetId)
+		resp.ChangeSetName = aws.ToString(out.ChangeSetName)
+		resp.StackID = aws.ToString(out.StackId)
+		resp.StackName = aws.ToString(out.StackName)
+		resp.Status = string(out.Status)
+		resp.ExecutionStatus = string(out.ExecutionStatus)
+		resp.StatusReason = aws.ToString(out.StatusReason)
+		resp.CreatedAt = out.CreationTime
+		if resp.Parameters == nil {
+			// O CloudFormation já devolve os NoEcho como "****"
+			resp.Parameters = changeSetParameters(out.Parameters)
+		}
+		for _, c := range out.Changes {
+			if c.ResourceChange != nil {
+				resp.Changes = append(resp.Changes, resourceChange(c.ResourceChange))
+			}
+		}
+		if out.NextToken == nil {
+			return resp, nil
+		}
+		token = out.NextToken
+	}
+}
+
+func changeSetParameters(in []cft.Parameter) []types.Parameter {
+	out := make([]types.Parameter, 0, len(in))
+	for _, p := range in {
+		out = append(out, types.Parameter{
+			Key:              aws.ToString(p.ParameterKey),
+			Value:            aws.ToString(p.ParameterValue),
+			UsePreviousValue: aws.ToBool(p.UsePreviousValue),
+			ResolvedValue:    aws.ToString(p.ResolvedValue),
+		})
+	}
+	return out
+}
+
+func resourceChange(rc *cft.ResourceChange) types.ResourceChange {
+	out := types.ResourceChange{
+		Action:       string(rc.Action),
+		LogicalID:    aws.ToString(rc.LogicalResourceId),
+		PhysicalID:   aws.ToString(rc.PhysicalResourceId),
+		ResourceType: aws.ToString(rc.ResourceType),
+		Replacement:  string(rc.Replacement),
+	}
+	for _, sc := range rc.Scope {
+		out.Scope = append(out.Scope, string(sc))
+	}
+	for _, d := range rc.Details {
+		cd := types.ChangeDetail{
+			Evaluation:    string(d.Evaluation),
+			ChangeSource:  string(d.ChangeSource),
+			CausingEntity: aws.ToString(d.CausingEntity),
+		}
+		if d.Target != nil {
+			cd.Attribute = string(d.Target.Attribute)
+			cd.Name = aws.ToString(d.Target.Name)
+			cd.RequiresRecreation = string(d.Target.RequiresRecreation)
+		}
+		out.Details = append(out.Details, cd)
+	}
+	return out
+}
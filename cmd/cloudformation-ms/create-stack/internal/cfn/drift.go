@@ -0,0 +1,105 @@
+package cfn
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"create-stack-ms/internal/types"
+)
+
+var ErrDriftTimeout = errors.New("timed out waiting for drift detection")
+
+const driftPollInterval = 3 * time.Second
+
+type DriftAPI interface {
+	DetectStackDrift(ctx context.Context, in *cf.DetectStackDriftInput, optFns ...func(*cf.Options)) (*cf.DetectStackDriftOutput, error)
+	DescribeStackDriftDetectionStatus(ctx context.Context, in *cf.DescribeStackDriftDetectionStatusInput, optFns ...func(*cf.Options)) (*cf.DescribeStackDriftDetectionStatusOutput, error)
+	DescribeStackResourceDrifts(ctx context.Context, in *cf.DescribeStackResourceDriftsInput, optFns ...func(*cf.Options)) (*cf.DescribeStackResourceDriftsOutput, error)
+}
+
+// DetectDrift inicia a detecção de drift e devolve o id para acompanhamento.
+func DetectDrift(ctx context.Context, api DriftAPI, stackName string) (string, error) {
+	out, err := api.DetectStackDrift(ctx, &cf.DetectStackDriftInput{StackName: aws.String(stackName)})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.StackDriftDetectionId), nil
+}
+
+// WaitDrift aguarda a detecção sair de DETECTION_IN_PROGRESS. Detecções
+// FAILED (ex. recurso sem suporte a drift) não são erro: o motivo vem em
+// DetectionStatusReason e o resultado parcial continua válido.
+func WaitDrift(ctx context.Context, api DriftAPI, detectionID string, maxWait time.Duration) (*cf.DescribeStackDriftDetectionStatusOutput, error) {
+	deadline := time.Now().Add(maxWait)
+	if d, ok := ctx.Deadline(); ok && d.Add(-5*time.Second).Before(deadline) {
+		deadline = d.Add(-5 * time.Second)
+	}
+
+	for {
+		out, err := api.DescribeStackDriftDetectionStatus(ctx, &cf.DescribeStackDriftDetectionStatusInput{
+			StackDriftDetectionId: aws.String(detectionID),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if out.DetectionStatus != cft.StackDriftDetectionStatusDetectionInProgress {
+			return out, nil
+		}
+		if time.Now().Add(driftPollInterval).After(deadline) {
+			return out, ErrDriftTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return out, ctx.Err()
+		case <-time.After(driftPollInterval):
+		}
+	}
+}
+
+// ResourceDrifts lê o drift por recurso, com as diferenças de propriedades.
+// Por padrão só traz recursos com drift (MODIFIED/DELETED); all=true inclui
+// os IN_SYNC e NOT_CHECKED.
+func ResourceDrifts(ctx context.Context, api DriftAPI, stackName string, all bool) ([]types.ResourceDrift, error) {
+	in := &cf.DescribeStackResourceDriftsInput{StackName: aws.String(stackName)}
+	if !all {
+		in.StackResourceDriftStatusFilters = []cft.StackResourceDriftStatus{
+			cft.StackResourceDriftStatusModified,
+			cft.StackResourceDriftStatusDeleted,
+		}
+	}
+
+	out := []types.ResourceDrift{}
+	for {
+		page, err := api.DescribeStackResourceDrifts(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range page.StackResourceDrifts {
+			rd := types.ResourceDrift{
+				LogicalID:    aws.ToString(d.LogicalResourceId),
+				PhysicalID:   aws.ToString(d.PhysicalResourceId),
+				ResourceType: aws.ToString(d.ResourceType),
+				Status:       string(d.StackResourceDriftStatus),
+				CheckedAt:    d.Timestamp,
+			}
+			for _, p := range d.PropertyDifferences {
+				rd.Differences = append(rd.Differences, types.PropertyDifference{
+					Path:     aws.ToString(p.PropertyPath),
+					Type:     string(p.DifferenceType),
+					Expected: aws.ToString(p.ExpectedValue),
+					Actual:   aws.ToString(p.ActualValue),
+				})
+			}
+			out = append(out, rd)
+		}
+		if page.NextToken == nil {
+			return out, nil
+		}
+		in.NextToken = page.NextToken
+	}
+}
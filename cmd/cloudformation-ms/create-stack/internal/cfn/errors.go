@@ -0,0 +1,112 @@
+package cfn
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/aws/smithy-go"
+)
+
+// HTTPStatus traduz erros do CloudFormation para o status devolvido ao cliente.
+// Erros de cliente sem mapeamento específico viram 400; falhas da AWS, 502.
+func HTTPStatus(err error) int {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return 500
+	}
+	switch apiErr.ErrorCode() {
+	case "AlreadyExistsException", "TokenAlreadyExistsException", "NameAlreadyExistsException",
+		"InvalidChangeSetStatus", "InvalidOperationException", "OperationInProgressException":
+		return 409
+	case "ChangeSetNotFound", "ChangeSetNotFoundException", "StackNotFoundException":
+		return 404
+	case "LimitExceededException":
+		return 429
+	case "Throttling", "ThrottlingException":
+		return 429
+	case "ValidationError":
+		if strings.Contains(apiErr.ErrorMessage(), "does not exist") {
+			return 404
+		}
+		return 400
+	}
+	if apiErr.ErrorFault() == smithy.FaultServer {
+		return 502
+	}
+	return 400
+}
+
+// ErrorCode traduz erros da AWS para um código estável devolvido ao cliente
+// (ex. AlreadyExistsException -> ALREADY_EXISTS). Erros que não vieram da
+// AWS usam o código do status sugerido.
+func ErrorCode(err error, status int) string {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return StatusCode(status)
+	}
+	switch apiErr.ErrorCode() {
+	case "AlreadyExistsException", "NameAlreadyExistsException":
+		return "ALREADY_EXISTS"
+	case "TokenAlreadyExistsException":
+		return "TOKEN_ALREADY_EXISTS"
+	case "InsufficientCapabilitiesException", "InsufficientCapabilities":
+		return "INSUFFICIENT_CAPABILITIES"
+	case "LimitExceededException":
+		return "LIMIT_EXCEEDED"
+	case "Throttling", "ThrottlingException":
+		return "THROTTLED"
+	case "AccessDenied", "AccessDeniedException":
+		return "ACCESS_DENIED"
+	case "ExpiredToken", "ExpiredTokenException", "InvalidClientTokenId", "UnrecognizedClientException", "SignatureDoesNotMatch":
+		return "INVALID_CREDENTIALS"
+	}
+	return StatusCode(HTTPStatus(err))
+}
+
+// StatusCode é o código genérico de um status HTTP de erro.
+func StatusCode(status int) string {
+	switch status {
+	case 400:
+		return "VALIDATION_ERROR"
+	case 401:
+		return "INVALID_CREDENTIALS"
+	case 403:
+		return "FORBIDDEN"
+	case 404:
+		return "NOT_FOUND"
+	case 409:
+		return "CONFLICT"
+	case 429:
+		return "THROTTLED"
+	case 502:
+		return "AWS_ERROR"
+	}
+	return "INTERNAL_ERROR"
+}
+
+// ErrorMessage devolve só a mensagem da AWS, sem o prefixo do SDK
+// ("operation error CloudFormation: ..., RequestID: ...").
+func ErrorMessage(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorMessage() != "" {
+		return apiErr.ErrorMessage()
+	}
+	return err.Error()
+}
+
+// IsNotFound reconhece o ValidationError que o CloudFormation devolve para
+// stacks inexistentes (não existe um tipo de erro específico para isso).
+func IsNotFound(err error) bool {
+	return HTTPStatus(err) == 404
+}
+
+// ValidationMessage devolve a mensagem de um ValidationError do
+// CloudFormation, que para ValidateTemplate é um problema no template e não
+// uma falha da chamada.
+func ValidationMessage(err error) (string, bool) {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ValidationError" {
+		return "", false
+	}
+	return apiErr.ErrorMessage(), true
+}
@@ -0,0 +1,48 @@
+package cfn
+
+import cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+// Lifecycle é a visão simplificada do status da stack para a UI e scripts.
+type Lifecycle string
+
+const (
+	LifecyclePending    Lifecycle = "pending"
+	LifecycleSucceeded  Lifecycle = "succeeded"
+	LifecycleFailed     Lifecycle = "failed"
+	LifecycleRolledBack Lifecycle = "rolled_back"
+	LifecycleDeleting   Lifecycle = "deleting"
+	LifecycleDeleted    Lifecycle = "deleted"
+)
+
+// LifecycleOf mapeia o status bruto do CloudFormation. Rollbacks ainda em
+// andamento contam como pending: a operação não terminou.
+func LifecycleOf(s cft.StackStatus) Lifecycle {
+	switch s {
+	case cft.StackStatusCreateComplete,
+		cft.StackStatusUpdateComplete,
+		cft.StackStatusImportComplete:
+		return LifecycleSucceeded
+	case cft.StackStatusCreateFailed,
+		cft.StackStatusRollbackFailed,
+		cft.StackStatusDeleteFailed,
+		cft.StackStatusUpdateFailed,
+		cft.StackStatusUpdateRollbackFailed,
+		cft.StackStatusImportRollbackFailed:
+		return LifecycleFailed
+	case cft.StackStatusRollbackComplete,
+		cft.StackStatusUpdateRollbackComplete,
+		cft.StackStatusImportRollbackComplete:
+		return LifecycleRolledBack
+	case cft.StackStatusDeleteInProgress:
+		return LifecycleDeleting
+	case cft.StackStatusDeleteComplete:
+		return LifecycleDeleted
+	default:
+		return LifecyclePending
+	}
+}
+
+// Terminal indica se não há operação em andamento na stack.
+func (l Lifecycle) Terminal() bool {
+	return l != LifecyclePending && l != LifecycleDeleting
+}
@@ -0,0 +1,100 @@
+package cfn
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"create-stack-ms/internal/types"
+)
+
+// Limites do CloudFormation.
+const (
+	maxRollbackTriggers  = 5
+	maxMonitoringMinutes = 180
+	maxNotificationARNs  = 5
+)
+
+const alarmTriggerType = "AWS::CloudWatch::Alarm"
+
+// RollbackConfiguration converte os alarmes da requisição em rollback
+// triggers. Devolve nil quando não há nada a configurar.
+func RollbackConfiguration(rc *types.RollbackConfiguration) (*cft.RollbackConfiguration, error) {
+	if rc == nil || (len(rc.AlarmARNs) == 0 && rc.MonitoringTimeInMinutes == nil) {
+		return nil, nil
+	}
+	if len(rc.AlarmARNs) > maxRollbackTriggers {
+		return nil, fmt.Errorf("rollbackConfiguration accepts at most %d alarms (got %d)", maxRollbackTriggers, len(rc.AlarmARNs))
+	}
+	if m := rc.MonitoringTimeInMinutes; m != nil && (*m < 0 || *m > maxMonitoringMinutes) {
+		return nil, fmt.Errorf("rollbackConfiguration.monitoringTimeInMinutes must be between 0 and %d", maxMonitoringMinutes)
+	}
+
+	out := &cft.RollbackConfiguration{MonitoringTimeInMinutes: rc.MonitoringTimeInMinutes}
+	for _, a := range rc.AlarmARNs {
+		if _, err := parseARN(a, "cloudwatch", "alarm:", "a CloudWatch alarm"); err != nil {
+			return nil, fmt.Errorf("rollbackConfiguration.alarmArns: %w", err)
+		}
+		out.RollbackTriggers = append(out.RollbackTriggers, cft.RollbackTrigger{
+			Arn:  aws.String(a),
+			Type: aws.String(alarmTriggerType),
+		})
+	}
+	return out, nil
+}
+
+// NotificationARNs valida os tópicos SNS que recebem os eventos da stack.
+func NotificationARNs(arns []string) error {
+	if len(arns) > maxNotificationARNs {
+		return fmt.Errorf("notificationArns accepts at most %d topics (got %d)", maxNotificationARNs, len(arns))
+	}
+	for _, a := range arns {
+		if _, err := parseARN(a, "sns", "", "an SNS topic"); err != nil {
+			return fmt.Errorf("notificationArns: %w", err)
+		}
+	}
+	return nil
+}
+
+// CheckTargetARNs confere que alarmes e tópicos são da conta e região onde a
+// stack será criada: o CloudFormation só os enxerga ali, e a falha dele
+// apareceria apenas no meio do deploy.
+func CheckTargetARNs(accountID, region string, rc *cft.RollbackConfiguration, topics []string) error {
+	var all []string
+	if rc != nil {
+		for _, t := range rc.RollbackTriggers {
+			all = append(all, aws.ToString(t.Arn))
+		}
+	}
+	all = append(all, topics...)
+
+	for _, a := range all {
+		parsed, err := arn.Parse(a)
+		if err != nil {
+			return fmt.Errorf("invalid ARN '%s': %w", a, err)
+		}
+		if parsed.AccountID != accountID {
+			return fmt.Errorf("'%s' belongs to account %s, but the stack is created in account %s", a, parsed.AccountID, accountID)
+		}
+		if parsed.Region != region {
+			return fmt.Errorf("'%s' is in region %s, but the stack is created in region %s", a, parsed.Region, region)
+		}
+	}
+	return nil
+}
+
+// parseARN exige um ARN regional do serviço informado e, opcionalmente, com
+// o prefixo de recurso (ex. "alarm:"). kind só entra na mensagem de erro.
+func parseARN(s, service, resourcePrefix, kind string) (arn.ARN, error) {
+	parsed, err := arn.Parse(s)
+	if err != nil {
+		return arn.ARN{}, fmt.Errorf("invalid ARN '%s'", s)
+	}
+	if parsed.Service != service || !strings.HasPrefix(parsed.Resource, resourcePrefix) || parsed.Region == "" || parsed.AccountID == "" {
+		return arn.ARN{}, fmt.Errorf("'%s' is not %s ARN", s, kind)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,27 @@
+package cfn
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+var ErrStackNotFound = errors.New("stack not found")
+
+// LookupStack busca uma stack por nome ou StackId.
+func LookupStack(ctx context.Context, api cf.DescribeStacksAPIClient, stackName string) (cft.Stack, error) {
+	out, err := api.DescribeStacks(ctx, &cf.DescribeStacksInput{StackName: aws.String(stackName)})
+	if err != nil {
+		if IsNotFound(err) {
+			return cft.Stack{}, ErrStackNotFound
+		}
+		return cft.Stack{}, err
+	}
+	if len(out.Stacks) == 0 {
+		return cft.Stack{}, ErrStackNotFound
+	}
+	return out.Stacks[0], nil
+}
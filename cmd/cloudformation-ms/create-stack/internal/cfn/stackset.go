@@ -0,0 +1,111 @@
+package cfn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"create-stack-ms/internal/types"
+)
+
+var ErrStackSetNotFound = errors.New("stack set not found")
+
+type StackSetAPI interface {
+	DescribeStackSet(ctx context.Context, in *cf.DescribeStackSetInput, optFns ...func(*cf.Options)) (*cf.DescribeStackSetOutput, error)
+	ListStackInstances(ctx context.Context, in *cf.ListStackInstancesInput, optFns ...func(*cf.Options)) (*cf.ListStackInstancesOutput, error)
+}
+
+// LookupStackSet devolve o stack set ou ErrStackSetNotFound.
+func LookupStackSet(ctx context.Context, api StackSetAPI, name string) (*cft.StackSet, error) {
+	out, err := api.DescribeStackSet(ctx, &cf.DescribeStackSetInput{StackSetName: aws.String(name)})
+	if err != nil {
+		var nf *cft.StackSetNotFoundException
+		if errors.As(err, &nf) {
+			return nil, ErrStackSetNotFound
+		}
+		return nil, err
+	}
+	if out.StackSet == nil || out.StackSet.Status == cft.StackSetStatusDeleted {
+		return nil, ErrStackSetNotFound
+	}
+	return out.StackSet, nil
+}
+
+// OperationPreferences valida e converte as preferências de rollout.
+// Contagem e porcentagem são mutuamente exclusivas, como no CloudFormation.
+func OperationPreferences(p types.StackSetPreferences, regions []string) (*cft.StackSetOperationPreferences, error) {
+	if p.MaxConcurrentCount != nil && p.MaxConcurrentPercentage != nil {
+		return nil, errors.New("use either maxConcurrentCount or maxConcurrentPercentage, not both")
+	}
+	if p.FailureToleranceCount != nil && p.FailureTolerancePercentage != nil {
+		return nil, errors.New("use either failureToleranceCount or failureTolerancePercentage, not both")
+	}
+	for name, v := range map[string]*int32{
+		"maxConcurrentPercentage":    p.MaxConcurrentPercentage,
+		"failureTolerancePercentage": p.FailureTolerancePercentage,
+	} {
+		if v != nil && (*v < 0 || *v > 100) {
+			return nil, fmt.Errorf("%s must be between 0 and 100", name)
+		}
+	}
+	if p.MaxConcurrentCount != nil && *p.MaxConcurrentCount < 1 {
+		return nil, errors.New("maxConcurrentCount must be at least 1")
+	}
+	if p.FailureToleranceCount != nil && *p.FailureToleranceCount < 0 {
+		return nil, errors.New("failureToleranceCount must not be negative")
+	}
+
+	out := &cft.StackSetOperationPreferences{
+		MaxConcurrentCount:         p.MaxConcurrentCount,
+		MaxConcurrentPercentage:    p.MaxConcurrentPercentage,
+		FailureToleranceCount:      p.FailureToleranceCount,
+		FailureTolerancePercentage: p.FailureTolerancePercentage,
+		RegionOrder:                regions,
+	}
+	switch strings.ToUpper(p.RegionConcurrencyType) {
+	case "", "SEQUENTIAL":
+		out.RegionConcurrencyType = cft.RegionConcurrencyTypeSequential
+	case "PARALLEL":
+		out.RegionConcurrencyType = cft.RegionConcurrencyTypeParallel
+	default:
+		return nil, fmt.Errorf("invalid regionConcurrencyType: %s", p.RegionConcurrencyType)
+	}
+	return out, nil
+}
+
+// StackInstances lista as instâncias do stack set. accountNames traduz ids
+// de conta para os nomes cadastrados (quando conhecidos).
+func StackInstances(ctx context.Context, api StackSetAPI, name string, accountNames map[string]string) ([]types.StackInstance, error) {
+	in := &cf.ListStackInstancesInput{StackSetName: aws.String(name)}
+	out := []types.StackInstance{}
+	for {
+		page, err := api.ListStackInstances(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		for _, si := range page.Summaries {
+			inst := types.StackInstance{
+				AccountID:    aws.ToString(si.Account),
+				AccountName:  accountNames[aws.ToString(si.Account)],
+				Region:       aws.ToString(si.Region),
+				Status:       string(si.Status),
+				StatusReason: aws.ToString(si.StatusReason),
+				StackID:      aws.ToString(si.StackId),
+				DriftStatus:  string(si.DriftStatus),
+			}
+			if si.StackInstanceStatus != nil {
+				inst.DetailedStatus = string(si.StackInstanceStatus.DetailedStatus)
+			}
+			out = append(out, inst)
+		}
+		if page.NextToken == nil {
+			return out, nil
+		}
+		in.NextToken = page.NextToken
+	}
+}
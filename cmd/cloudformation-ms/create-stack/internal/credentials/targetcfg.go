@@ -14,8 +14,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
-func BuildTargetConfig(ctx context.Context, base aws.Config, keys types.SecretKeys) (aws.Config, error) {
-	target := base
+// BuildTargetConfig monta a config da conta alvo na região informada. O STS
+// (AssumeRole e a validação) usa o endpoint regional dessa mesma região.
+// Devolve também o id da conta informado pelo GetCallerIdentity.
+func BuildTargetConfig(ctx context.Context, base aws.Config, keys types.SecretKeys, region string) (aws.Config, string, error) {
+	target := base.Copy()
+	if region != "" {
+		target.Region = region
+	}
 
 	if keys.AccessKeyID != "" && keys.SecretAccessKey != "" {
 		target.Credentials = aws.NewCredentialsCache(
@@ -42,10 +48,10 @@ func BuildTargetConfig(ctx context.Context, base aws.Config, keys types.SecretKe
 	// Validação STS
 	idOut, err := sts.NewFromConfig(target).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
-		return target, fmt.Errorf("STS GetCallerIdentity failed (creds inválidas/expiradas?): %w", err)
+		return target, "", fmt.Errorf("STS GetCallerIdentity failed (creds inválidas/expiradas?): %w", err)
 	}
-	log.Printf("[INFO] Caller identity: Account=%s ARN=%s UserId=%s",
-		aws.ToString(idOut.Account), aws.ToString(idOut.Arn), aws.ToString(idOut.UserId))
+	log.Printf("[INFO] Caller identity: Account=%s ARN=%s UserId=%s Region=%s",
+		aws.ToString(idOut.Account), aws.ToString(idOut.Arn), aws.ToString(idOut.UserId), target.Region)
 
-	return target, nil
+	return target, aws.ToString(idOut.Account), nil
 }
@@ -0,0 +1,66 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Record é o último resultado de drift conhecido de uma stack.
+type Record struct {
+	Owner            string
+	Account          string
+	Region           string
+	StackName        string
+	StackID          string
+	DetectionID      string
+	DetectionStatus  string
+	DriftStatus      string
+	DriftedResources int32
+	CheckedAt        time.Time
+}
+
+type Store interface {
+	Put(ctx context.Context, r Record) error
+}
+
+type PutItemAPI interface {
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+// DynamoStore grava um item por stack: pk=DRIFT#<owner>, sk=<account>#<region>#<stackName>.
+// Cada detecção sobrescreve a anterior.
+type DynamoStore struct {
+	api   PutItemAPI
+	table string
+}
+
+func NewDynamoStore(api PutItemAPI, table string) *DynamoStore {
+	return &DynamoStore{api: api, table: table}
+}
+
+func (s *DynamoStore) Put(ctx context.Context, r Record) error {
+	_, err := s.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item: map[string]ddbtypes.AttributeValue{
+			"pk":               &ddbtypes.AttributeValueMemberS{Value: "DRIFT#" + r.Owner},
+			"sk":               &ddbtypes.AttributeValueMemberS{Value: fmt.Sprintf("%s#%s#%s", r.Account, r.Region, r.StackName)},
+			"owner":            &ddbtypes.AttributeValueMemberS{Value: r.Owner},
+			"account":          &ddbtypes.AttributeValueMemberS{Value: r.Account},
+			"region":           &ddbtypes.AttributeValueMemberS{Value: r.Region},
+			"stackName":        &ddbtypes.AttributeValueMemberS{Value: r.StackName},
+			"stackId":          &ddbtypes.AttributeValueMemberS{Value: r.StackID},
+			"detectionId":      &ddbtypes.AttributeValueMemberS{Value: r.DetectionID},
+			"detectionStatus":  &ddbtypes.AttributeValueMemberS{Value: r.DetectionStatus},
+			"driftStatus":      &ddbtypes.AttributeValueMemberS{Value: r.DriftStatus},
+			"driftedResources": &ddbtypes.AttributeValueMemberN{Value: strconv.Itoa(int(r.DriftedResources))},
+			"checkedAt":        &ddbtypes.AttributeValueMemberS{Value: r.CheckedAt.UTC().Format(time.RFC3339)},
+		},
+	})
+	return err
+}
@@ -0,0 +1,163 @@
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type API interface {
+	dynamodb.QueryAPIClient
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+}
+
+// DynamoStore grava um item por versão: pk=GUARDRAILS#<owner>,
+// sk=OWNER#<version> ou ACCOUNT#<accountName>#<version>. A versão tem zeros à
+// esquerda para que a ordem do sort key seja a numérica.
+type DynamoStore struct {
+	api   API
+	table string
+}
+
+func NewDynamoStore(api API, table string) *DynamoStore {
+	return &DynamoStore{api: api, table: table}
+}
+
+func pk(owner string) *ddbtypes.AttributeValueMemberS {
+	return &ddbtypes.AttributeValueMemberS{Value: "GUARDRAILS#" + owner}
+}
+
+func scope(account string) string {
+	if account == "" {
+		return "OWNER#"
+	}
+	return "ACCOUNT#" + account + "#"
+}
+
+func key(owner, account string, version int) map[string]ddbtypes.AttributeValue {
+	return map[string]ddbtypes.AttributeValue{
+		"pk": pk(owner),
+		"sk": &ddbtypes.AttributeValueMemberS{Value: fmt.Sprintf("%s%06d", scope(account), version)},
+	}
+}
+
+func (s *DynamoStore) Put(ctx context.Context, v Version) error {
+	doc, err := json.Marshal(v.Document)
+	if err != nil {
+		return err
+	}
+	item := key(v.Owner, v.Account, v.Version)
+	item["owner"] = &ddbtypes.AttributeValueMemberS{Value: v.Owner}
+	item["version"] = &ddbtypes.AttributeValueMemberN{Value: strconv.Itoa(v.Version)}
+	item["mode"] = &ddbtypes.AttributeValueMemberS{Value: v.Document.Mode}
+	item["document"] = &ddbtypes.AttributeValueMemberS{Value: string(doc)}
+	item["createdAt"] = &ddbtypes.AttributeValueMemberS{Value: v.CreatedAt.UTC().Format(time.RFC3339)}
+	if v.Account != "" {
+		item["accountName"] = &ddbtypes.AttributeValueMemberS{Value: v.Account}
+	}
+	if v.RequestID != "" {
+		item["requestId"] = &ddbtypes.AttributeValueMemberS{Value: v.RequestID}
+	}
+	_, err = s.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	var ccf *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *DynamoStore) Get(ctx context.Context, owner, account string, version int) (Version, error) {
+	out, err := s.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            key(owner, account, version),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return Version{}, err
+	}
+	if len(out.Item) == 0 {
+		return Version{}, ErrNotFound
+	}
+	return fromItem(out.Item)
+}
+
+func (s *DynamoStore) Latest(ctx context.Context, owner, account string) (Version, error) {
+	out, err := s.api.Query(ctx, s.query(owner, account, aws.Int32(1)))
+	if err != nil {
+		return Version{}, err
+	}
+	if len(out.Items) == 0 {
+		return Version{}, ErrNotFound
+	}
+	return fromItem(out.Items[0])
+}
+
+func (s *DynamoStore) Versions(ctx context.Context, owner, account string) ([]int, error) {
+	in := s.query(owner, account, nil)
+	in.ProjectionExpression = aws.String("version")
+	var out []int
+	p := dynamodb.NewQueryPaginator(s.api, in)
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range page.Items {
+			if n, ok := it["version"].(*ddbtypes.AttributeValueMemberN); ok {
+				v, _ := strconv.Atoi(n.Value)
+				out = append(out, v)
+			}
+		}
+	}
+	return out, nil
+}
+
+// query lista as versões de um escopo, da mais nova para a mais antiga.
+func (s *DynamoStore) query(owner, account string, limit *int32) *dynamodb.QueryInput {
+	return &dynamodb.QueryInput{
+		TableName:              aws.String(s.table),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :scope)"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":pk":    pk(owner),
+			":scope": &ddbtypes.AttributeValueMemberS{Value: scope(account)},
+		},
+		ScanIndexForward: aws.Bool(false),
+		ConsistentRead:   aws.Bool(true),
+		Limit:            limit,
+	}
+}
+
+func fromItem(it map[string]ddbtypes.AttributeValue) (Version, error) {
+	v := Version{
+		Owner:     str(it, "owner"),
+		Account:   str(it, "accountName"),
+		RequestID: str(it, "requestId"),
+	}
+	if n, ok := it["version"].(*ddbtypes.AttributeValueMemberN); ok {
+		v.Version, _ = strconv.Atoi(n.Value)
+	}
+	v.CreatedAt, _ = time.Parse(time.RFC3339, str(it, "createdAt"))
+	if err := json.Unmarshal([]byte(str(it, "document")), &v.Document); err != nil {
+		return Version{}, fmt.Errorf("corrupted guardrail policy %s: %w", v.Label(), err)
+	}
+	return v, nil
+}
+
+func str(it map[string]ddbtypes.AttributeValue, key string) string {
+	if v, ok := it[key].(*ddbtypes.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
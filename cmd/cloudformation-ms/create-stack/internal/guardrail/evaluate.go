@@ -0,0 +1,331 @@
+package guardrail
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"create-stack-ms/internal/validate"
+)
+
+// Violation é uma regra descumprida. LogicalID e Path (JSON pointer no
+// template) ficam vazios para regras que não dizem respeito a um recurso.
+type Violation struct {
+	RuleID       string
+	Severity     string
+	Policy       string // versão que trouxe a regra, ex. "owner@3"
+	LogicalID    string
+	ResourceType string
+	Path         string
+	Message      string
+}
+
+// ViolationError é a falha de uma policy em modo enforce.
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Message)
+	}
+	return "guardrails violated: " + strings.Join(msgs, "; ")
+}
+
+// Input é o que a avaliação sabe sobre o deploy. Template nil significa que
+// o template só existe numa URL: as regras sobre recursos não podem ser
+// avaliadas e viram violação.
+type Input struct {
+	Template   map[string]any
+	Region     string
+	Parameters map[string]string // valores enviados; os ausentes caem no Default do template
+}
+
+// Evaluate avalia todas as regras da policy. Valores que só o CloudFormation
+// resolve (Fn::If, GetAtt, parâmetros sem valor) não geram violação.
+func Evaluate(p Policy, in Input) []Violation {
+	e := &evaluator{in: in, resources: map[string]map[string]any{}}
+	if res, ok := in.Template["Resources"].(map[string]any); ok {
+		for name, r := range res {
+			if m, ok := r.(map[string]any); ok {
+				e.names = append(e.names, name)
+				e.resources[name] = m
+			}
+		}
+		sort.Strings(e.names)
+	}
+
+	for _, src := range p.Sources {
+		for _, r := range src.Document.Rules {
+			e.rule, e.policy = r, src.Label()
+			switch {
+			case r.Type == RuleAllowedRegions:
+				e.allowedRegions()
+			case in.Template == nil:
+				e.add("", "", "", "rule '%s' needs the template, but it was sent by URL (use 'template' or 'templateId')", r.ID)
+			case r.Type == RuleDeniedResourceTypes:
+				e.deniedResourceTypes()
+			case r.Type == RuleAllowedInstanceFamilies:
+				e.allowedInstanceFamilies()
+			case r.Type == RuleRequiredEncryption:
+				e.requiredEncryption()
+			case r.Type == RuleNoPublicIngress:
+				e.noPublicIngress()
+			}
+		}
+	}
+	return e.violations
+}
+
+// Enforce devolve um *ViolationError quando a policy está em modo enforce e
+// há violações; em modo warn as violações são só avisos.
+func Enforce(p Policy, violations []Violation) error {
+	if p.Mode == ModeEnforce && len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+	return nil
+}
+
+type evaluator struct {
+	in         Input
+	names      []string
+	resources  map[string]map[string]any
+	rule       Rule
+	policy     string
+	violations []Violation
+}
+
+func (e *evaluator) add(logicalID, resourceType, ptr, format string, args ...any) {
+	e.violations = append(e.violations, Violation{
+		RuleID:       e.rule.ID,
+		Severity:     e.rule.Severity,
+		Policy:       e.policy,
+		LogicalID:    logicalID,
+		ResourceType: resourceType,
+		Path:         ptr,
+		Message:      fmt.Sprintf(format, args...),
+	})
+}
+
+// each chama fn para os recursos dos tipos informados, em ordem de nome.
+func (e *evaluator) each(fn func(name, typ string, props map[string]any), types ...string) {
+	for _, name := range e.names {
+		typ, _ := e.resources[name]["Type"].(string)
+		for _, t := range types {
+			if typ == t {
+				props, _ := e.resources[name]["Properties"].(map[string]any)
+				fn(name, typ, props)
+				break
+			}
+		}
+	}
+}
+
+func (e *evaluator) allowedRegions() {
+	for _, r := range e.rule.Regions {
+		if r == e.in.Region {
+			return
+		}
+	}
+	e.add("", "", "", "region '%s' is not allowed (allowed: %s)", e.in.Region, strings.Join(e.rule.Regions, ", "))
+}
+
+func (e *evaluator) deniedResourceTypes() {
+	for _, name := range e.names {
+		typ, _ := e.resources[name]["Type"].(string)
+		for _, pattern := range e.rule.ResourceTypes {
+			if ok, _ := path.Match(pattern, typ); ok {
+				e.add(name, typ, validate.Pointer("Resources", name, "Type"), "resource '%s': type %s is denied", name, typ)
+				break
+			}
+		}
+	}
+}
+
+func (e *evaluator) allowedInstanceFamilies() {
+	check := func(name, typ, ptr string, v any, prefix string) {
+		size, ok := e.str(v)
+		if !ok {
+			return
+		}
+		family, _, _ := strings.Cut(strings.TrimPrefix(size, prefix), ".")
+		for _, pattern := range e.rule.Families {
+			if ok, _ := path.Match(pattern, family); ok {
+				return
+			}
+		}
+		e.add(name, typ, ptr, "resource '%s': instance type %s is not in an allowed family (%s)", name, size, strings.Join(e.rule.Families, ", "))
+	}
+
+	e.each(func(name, typ string, props map[string]any) {
+		check(name, typ, validate.Pointer("Resources", name, "Properties", "InstanceType"), props["InstanceType"], "")
+	}, "AWS::EC2::Instance", "AWS::AutoScaling::LaunchConfiguration")
+	e.each(func(name, typ string, props map[string]any) {
+		data, _ := props["LaunchTemplateData"].(map[string]any)
+		check(name, typ, validate.Pointer("Resources", name, "Properties", "LaunchTemplateData", "InstanceType"), data["InstanceType"], "")
+	}, "AWS::EC2::LaunchTemplate")
+	e.each(func(name, typ string, props map[string]any) {
+		check(name, typ, validate.Pointer("Resources", name, "Properties", "DBInstanceClass"), props["DBInstanceClass"], "db.")
+	}, "AWS::RDS::DBInstance")
+}
+
+func (e *evaluator) requiredEncryption() {
+	services := e.rule.Services
+	if len(services) == 0 {
+		services = []string{"s3", "rds", "ebs"}
+	}
+	for _, svc := range services {
+		switch svc {
+		case "s3":
+			e.each(func(name, typ string, props map[string]any) {
+				enc, _ := props["BucketEncryption"].(map[string]any)
+				if rules, ok := enc["ServerSideEncryptionConfiguration"].([]any); !ok || len(rules) == 0 {
+					e.add(name, typ, validate.Pointer("Resources", name, "Properties", "BucketEncryption"), "resource '%s': S3 bucket must set BucketEncryption", name)
+				}
+			}, "AWS::S3::Bucket")
+		case "rds":
+			e.each(func(name, typ string, props map[string]any) {
+				// Instâncias de um cluster Aurora herdam a criptografia do cluster
+				if _, ok := props["DBClusterIdentifier"]; ok && typ == "AWS::RDS::DBInstance" {
+					return
+				}
+				e.requireTrue(name, typ, props["StorageEncrypted"], "StorageEncrypted", validate.Pointer("Resources", name, "Properties", "StorageEncrypted"))
+			}, "AWS::RDS::DBInstance", "AWS::RDS::DBCluster")
+		case "ebs":
+			e.each(func(name, typ string, props map[string]any) {
+				e.requireTrue(name, typ, props["Encrypted"], "Encrypted", validate.Pointer("Resources", name, "Properties", "Encrypted"))
+			}, "AWS::EC2::Volume")
+			e.each(func(name, typ string, props map[string]any) {
+				e.blockDevices(name, typ, props["BlockDeviceMappings"], "Properties", "BlockDeviceMappings")
+			}, "AWS::EC2::Instance")
+			e.each(func(name, typ string, props map[string]any) {
+				data, _ := props["LaunchTemplateData"].(map[string]any)
+				e.blockDevices(name, typ, data["BlockDeviceMappings"], "Properties", "LaunchTemplateData", "BlockDeviceMappings")
+			}, "AWS::EC2::LaunchTemplate")
+		}
+	}
+}
+
+// blockDevices exige Ebs.Encrypted em cada volume EBS declarado.
+func (e *evaluator) blockDevices(name, typ string, v any, segments ...string) {
+	mappings, _ := v.([]any)
+	for i, m := range mappings {
+		mapping, _ := m.(map[string]any)
+		ebs, ok := mapping["Ebs"].(map[string]any)
+		if !ok {
+			continue
+		}
+		ptr := validate.Pointer(append(append([]string{"Resources", name}, segments...), strconv.Itoa(i), "Ebs", "Encrypted")...)
+		e.requireTrue(name, typ, ebs["Encrypted"], "Ebs.Encrypted", ptr)
+	}
+}
+
+func (e *evaluator) requireTrue(name, typ string, v any, property, ptr string) {
+	if v == nil {
+		e.add(name, typ, ptr, "resource '%s': %s must be true (not set)", name, property)
+		return
+	}
+	if b, ok := e.bool(v); ok && !b {
+		e.add(name, typ, ptr, "resource '%s': %s must be true", name, property)
+	}
+}
+
+func (e *evaluator) noPublicIngress() {
+	e.each(func(name, typ string, props map[string]any) {
+		rules, _ := props["SecurityGroupIngress"].([]any)
+		for i, r := range rules {
+			rule, _ := r.(map[string]any)
+			e.ingress(name, typ, rule, validate.Pointer("Resources", name, "Properties", "SecurityGroupIngress", strconv.Itoa(i)))
+		}
+	}, "AWS::EC2::SecurityGroup")
+	e.each(func(name, typ string, props map[string]any) {
+		e.ingress(name, typ, props, validate.Pointer("Resources", name, "Properties"))
+	}, "AWS::EC2::SecurityGroupIngress")
+}
+
+func (e *evaluator) ingress(name, typ string, rule map[string]any, ptr string) {
+	cidr, _ := e.str(rule["CidrIp"])
+	cidr6, _ := e.str(rule["CidrIpv6"])
+	source := cidr
+	if cidr6 == "::/0" {
+		source = cidr6
+	}
+	if cidr != "0.0.0.0/0" && cidr6 != "::/0" {
+		return
+	}
+	if len(e.rule.Ports) == 0 {
+		e.add(name, typ, ptr, "resource '%s': ingress open to %s", name, source)
+		return
+	}
+
+	from, to := 0, 65535
+	proto, _ := e.str(rule["IpProtocol"])
+	if proto != "-1" && proto != "all" {
+		// Portas que não resolvem contam como o intervalo inteiro
+		if n, ok := e.int(rule["FromPort"]); ok && n >= 0 {
+			from = n
+		}
+		if n, ok := e.int(rule["ToPort"]); ok && n >= 0 {
+			to = n
+		}
+	}
+	for _, p := range e.rule.Ports {
+		if p >= from && p <= to {
+			e.add(name, typ, ptr, "resource '%s': port %d open to %s", name, p, source)
+		}
+	}
+}
+
+// str resolve literais e Ref a parâmetros; ok=false para o resto.
+func (e *evaluator) str(v any) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(x), true
+	case map[string]any:
+		name, ok := x["Ref"].(string)
+		if !ok || len(x) != 1 {
+			return "", false
+		}
+		return e.param(name)
+	}
+	return "", false
+}
+
+func (e *evaluator) bool(v any) (bool, bool) {
+	s, ok := e.str(v)
+	if !ok {
+		return false, false
+	}
+	b, err := strconv.ParseBool(strings.ToLower(s))
+	return b, err == nil
+}
+
+func (e *evaluator) int(v any) (int, bool) {
+	s, ok := e.str(v)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+func (e *evaluator) param(name string) (string, bool) {
+	if v, ok := e.in.Parameters[name]; ok {
+		return v, true
+	}
+	params, _ := e.in.Template["Parameters"].(map[string]any)
+	decl, _ := params[name].(map[string]any)
+	if def, ok := decl["Default"]; ok {
+		if _, isMap := def.(map[string]any); !isMap {
+			return e.str(def)
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,209 @@
+package guardrail
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ModeEnforce = "enforce"
+	ModeWarn    = "warn"
+)
+
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// Tipos de regra.
+const (
+	RuleDeniedResourceTypes     = "deniedResourceTypes"
+	RuleAllowedInstanceFamilies = "allowedInstanceFamilies"
+	RuleRequiredEncryption      = "requiredEncryption"
+	RuleNoPublicIngress         = "noPublicIngress"
+	RuleAllowedRegions          = "allowedRegions"
+)
+
+// Serviços aceitos em requiredEncryption.
+var encryptionServices = map[string]bool{"s3": true, "rds": true, "ebs": true}
+
+var idRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Rule é uma regra declarativa. Só os campos do tipo da regra são usados.
+type Rule struct {
+	ID          string `json:"id" yaml:"id"`
+	Type        string `json:"type" yaml:"type"`
+	Severity    string `json:"severity,omitempty" yaml:"severity,omitempty"` // Padrão: high
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	ResourceTypes []string `json:"resourceTypes,omitempty" yaml:"resourceTypes,omitempty"` // deniedResourceTypes, aceita * (ex. AWS::IAM::*)
+	Families      []string `json:"families,omitempty" yaml:"families,omitempty"`           // allowedInstanceFamilies, aceita * (ex. m6*)
+	Services      []string `json:"services,omitempty" yaml:"services,omitempty"`           // requiredEncryption: s3, rds, ebs (padrão: todos)
+	Ports         []int    `json:"ports,omitempty" yaml:"ports,omitempty"`                 // noPublicIngress (padrão: qualquer porta)
+	Regions       []string `json:"regions,omitempty" yaml:"regions,omitempty"`             // allowedRegions
+}
+
+// Document é uma policy como o owner a publica, em JSON ou YAML.
+type Document struct {
+	Mode  string `json:"mode" yaml:"mode"` // "enforce" (padrão) | "warn"
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Version é uma versão publicada de uma policy. Account vazio é a policy do
+// owner, que vale para todas as contas dele.
+type Version struct {
+	Owner     string
+	Account   string
+	Version   int
+	Document  Document
+	CreatedAt time.Time
+	RequestID string
+}
+
+// Label identifica a versão nas violações, ex. "owner@3" ou "account:prod@1".
+func (v Version) Label() string {
+	if v.Account == "" {
+		return fmt.Sprintf("owner@%d", v.Version)
+	}
+	return fmt.Sprintf("account:%s@%d", v.Account, v.Version)
+}
+
+// ParseDocument lê a policy enviada como objeto JSON ou como string YAML/JSON.
+// Campos desconhecidos são erro, para que um erro de digitação não desligue
+// uma regra calado.
+func ParseDocument(raw json.RawMessage) (Document, error) {
+	src := bytes.TrimSpace(raw)
+	if len(src) == 0 || string(src) == "null" {
+		return Document{}, errors.New("field 'document' is required")
+	}
+	if src[0] == '"' {
+		var text string
+		if err := json.Unmarshal(src, &text); err != nil {
+			return Document{}, fmt.Errorf("invalid document string: %w", err)
+		}
+		src = []byte(text)
+	}
+
+	var doc Document
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil {
+		return Document{}, fmt.Errorf("document must be valid YAML or JSON: %v", err)
+	}
+	if err := doc.normalize(); err != nil {
+		return Document{}, err
+	}
+	return doc, nil
+}
+
+// normalize valida a policy e preenche os padrões.
+func (d *Document) normalize() error {
+	switch strings.ToLower(d.Mode) {
+	case "", ModeEnforce:
+		d.Mode = ModeEnforce
+	case ModeWarn:
+		d.Mode = ModeWarn
+	default:
+		return fmt.Errorf("invalid mode '%s' (use enforce or warn)", d.Mode)
+	}
+	if d.Rules == nil {
+		d.Rules = []Rule{}
+	}
+
+	seen := map[string]bool{}
+	for i := range d.Rules {
+		r := &d.Rules[i]
+		if !idRe.MatchString(r.ID) {
+			return fmt.Errorf("rules[%d]: invalid id '%s' (letters, digits, '.', '_' and '-', up to 64 chars)", i, r.ID)
+		}
+		if seen[r.ID] {
+			return fmt.Errorf("rules[%d]: duplicated id '%s'", i, r.ID)
+		}
+		seen[r.ID] = true
+		if err := r.normalize(); err != nil {
+			return fmt.Errorf("rule '%s': %w", r.ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) normalize() error {
+	switch strings.ToLower(r.Severity) {
+	case "":
+		r.Severity = SeverityHigh
+	case SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+		r.Severity = strings.ToLower(r.Severity)
+	default:
+		return fmt.Errorf("invalid severity '%s' (use low, medium, high or critical)", r.Severity)
+	}
+
+	switch r.Type {
+	case RuleDeniedResourceTypes:
+		if len(r.ResourceTypes) == 0 {
+			return errors.New("'resourceTypes' must not be empty")
+		}
+		return validPatterns(r.ResourceTypes)
+	case RuleAllowedInstanceFamilies:
+		if len(r.Families) == 0 {
+			return errors.New("'families' must not be empty")
+		}
+		return validPatterns(r.Families)
+	case RuleRequiredEncryption:
+		for i, s := range r.Services {
+			r.Services[i] = strings.ToLower(s)
+			if !encryptionServices[r.Services[i]] {
+				return fmt.Errorf("unsupported service '%s' (use s3, rds or ebs)", s)
+			}
+		}
+	case RuleNoPublicIngress:
+		for _, p := range r.Ports {
+			if p < 0 || p > 65535 {
+				return fmt.Errorf("invalid port %d", p)
+			}
+		}
+	case RuleAllowedRegions:
+		if len(r.Regions) == 0 {
+			return errors.New("'regions' must not be empty")
+		}
+	default:
+		return fmt.Errorf("unknown type '%s' (use %s, %s, %s, %s or %s)", r.Type,
+			RuleDeniedResourceTypes, RuleAllowedInstanceFamilies, RuleRequiredEncryption, RuleNoPublicIngress, RuleAllowedRegions)
+	}
+	return nil
+}
+
+func validPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s'", p)
+		}
+	}
+	return nil
+}
+
+// Policy é o que vale para uma conta: as regras do owner e as da conta. O
+// modo é o da policy da conta ou, sem ela, o da policy do owner.
+type Policy struct {
+	Mode    string
+	Sources []Version // owner e/ou conta, nessa ordem
+}
+
+// Empty indica que não há regras a avaliar.
+func (p Policy) Empty() bool {
+	for _, v := range p.Sources {
+		if len(v.Document.Rules) > 0 {
+			return false
+		}
+	}
+	return true
+}
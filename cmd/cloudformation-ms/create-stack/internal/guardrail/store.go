@@ -0,0 +1,89 @@
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("guardrail policy not found")
+	ErrConflict = errors.New("guardrail policy version already exists")
+)
+
+type Store interface {
+	// Put falha com ErrConflict quando a versão já foi gravada.
+	Put(ctx context.Context, v Version) error
+	Get(ctx context.Context, owner, account string, version int) (Version, error)
+	// Latest devolve a versão mais nova, ou ErrNotFound.
+	Latest(ctx context.Context, owner, account string) (Version, error)
+	// Versions lista os números de versão, do mais novo para o mais antigo.
+	Versions(ctx context.Context, owner, account string) ([]int, error)
+}
+
+// Registry versiona as policies: cada publicação grava uma versão nova e
+// as anteriores ficam para consulta.
+type Registry struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewRegistry(store Store) *Registry {
+	return &Registry{store: store, now: time.Now}
+}
+
+// Publish grava doc (já validado por ParseDocument) como a próxima versão.
+// Duas publicações simultâneas no mesmo escopo: a segunda falha com
+// ErrConflict.
+func (r *Registry) Publish(ctx context.Context, owner, account string, doc Document, requestID string) (Version, error) {
+	next := 1
+	latest, err := r.store.Latest(ctx, owner, account)
+	switch {
+	case err == nil:
+		next = latest.Version + 1
+	case !errors.Is(err, ErrNotFound):
+		return Version{}, err
+	}
+
+	v := Version{
+		Owner:     owner,
+		Account:   account,
+		Version:   next,
+		Document:  doc,
+		CreatedAt: r.now().UTC(),
+		RequestID: requestID,
+	}
+	if err := r.store.Put(ctx, v); err != nil {
+		return Version{}, err
+	}
+	return v, nil
+}
+
+// Get devolve uma versão; version 0 é a mais nova.
+func (r *Registry) Get(ctx context.Context, owner, account string, version int) (Version, error) {
+	if version == 0 {
+		return r.store.Latest(ctx, owner, account)
+	}
+	return r.store.Get(ctx, owner, account, version)
+}
+
+func (r *Registry) Versions(ctx context.Context, owner, account string) ([]int, error) {
+	return r.store.Versions(ctx, owner, account)
+}
+
+// Effective junta a versão mais nova da policy do owner e a da conta.
+func (r *Registry) Effective(ctx context.Context, owner, account string) (Policy, error) {
+	p := Policy{Mode: ModeEnforce}
+	for _, scope := range []string{"", account} {
+		v, err := r.store.Latest(ctx, owner, scope)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return Policy{}, err
+		}
+		p.Mode = v.Document.Mode
+		p.Sources = append(p.Sources, v)
+	}
+	return p, nil
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"create-stack-ms/internal/catalog"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/staging"
+	"create-stack-ms/internal/types"
+	"create-stack-ms/internal/validate"
+)
+
+// catalogSession abre a sessão e garante que o catálogo está configurado.
+func catalogSession(ctx context.Context, req events.APIGatewayV2HTTPRequest) (*session, *events.APIGatewayV2HTTPResponse) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return nil, errResp
+	}
+	if s.catalog == nil {
+		resp := httpresp.Error(500, errors.New("template catalog is not configured"))
+		return nil, &resp
+	}
+	return s, nil
+}
+
+// CatalogPublishHandler atende POST /cf/templates: publica uma nova versão
+// de um template. Versões são imutáveis (409 se já existir) e o template
+// precisa passar nas verificações locais de /cf/validate.
+func CatalogPublishHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := catalogSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	var body types.CatalogPublishRequest
+	if err := decodeBody(req, &body); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	body.TemplateID = strings.TrimSpace(body.TemplateID)
+	body.Version = strings.TrimSpace(body.Version)
+	log.Printf("[INFO] Catalog publish: templateId=%s version=%s", body.TemplateID, body.Version)
+
+	if err := catalog.ValidateID(body.TemplateID); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	if _, err := catalog.ParseSemver(body.Version); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+
+	if len(body.Template) == 0 && body.TemplateYAML == "" {
+		return httpresp.Error(400, errors.New("either 'template' or 'templateYaml' is required")), nil
+	}
+	raw, err := inlineTemplate(types.RequestBody{Template: body.Template, TemplateYAML: body.TemplateYAML})
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	var tpl map[string]any
+	if err := json.Unmarshal(raw, &tpl); err != nil {
+		return httpresp.Error(400, fmt.Errorf("template must be valid JSON: %v", err)), nil
+	}
+	for _, f := range validate.Template(tpl) {
+		if f.Severity == validate.SeverityError {
+			return httpresp.Error(400, fmt.Errorf("template has errors: %s (%s)", f.Message, f.Path)), nil
+		}
+	}
+
+	v, err := s.catalog.Publish(ctx, catalog.Version{
+		Owner:       s.owner,
+		TemplateID:  body.TemplateID,
+		Version:     body.Version,
+		Description: body.Description,
+		CreatedAt:   time.Now(),
+		RequestID:   s.requestID,
+	}, raw)
+	if err != nil {
+		switch {
+		case errors.Is(err, catalog.ErrExists):
+			return httpresp.Error(409, fmt.Errorf("version '%s' of template '%s' already exists", body.Version, body.TemplateID)), nil
+		case errors.Is(err, staging.ErrTooLarge):
+			return httpresp.Error(400, err), nil
+		}
+		return httpresp.Error(500, fmt.Errorf("publish template failed: %w", err)), nil
+	}
+	log.Printf("[INFO] Template published: templateId=%s version=%s hash=%s size=%d", v.TemplateID, v.Version, v.ContentHash, v.Size)
+	return httpresp.OK(201, catalogVersion(v)), nil
+}
+
+// CatalogListHandler atende GET /cf/templates (todos os templates do owner)
+// e GET /cf/templates/{templateId} (só as versões desse template).
+func CatalogListHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := catalogSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	var groups map[string][]catalog.Version
+	templateID := strings.TrimSpace(req.PathParameters["templateId"])
+	if templateID != "" {
+		if err := catalog.ValidateID(templateID); err != nil {
+			return httpresp.Error(400, err), nil
+		}
+		versions, err := s.catalog.Versions(ctx, s.owner, templateID)
+		if err != nil {
+			return httpresp.Error(500, fmt.Errorf("list template versions failed: %w", err)), nil
+		}
+		if len(versions) == 0 {
+			return httpresp.Error(404, fmt.Errorf("template '%s' not found in catalog", templateID)), nil
+		}
+		groups = map[string][]catalog.Version{templateID: versions}
+	} else {
+		var err error
+		if groups, err = s.catalog.Templates(ctx, s.owner); err != nil {
+			return httpresp.Error(500, fmt.Errorf("list templates failed: %w", err)), nil
+		}
+	}
+
+	resp := types.CatalogResponse{Owner: s.owner, Templates: []types.CatalogTemplate{}}
+	for id, versions := range groups {
+		t := types.CatalogTemplate{TemplateID: id, Versions: make([]types.CatalogVersion, 0, len(versions))}
+		if latest, ok := catalog.Latest(versions); ok {
+			t.LatestVersion = latest.Version
+		}
+		for _, v := range versions {
+			t.Versions = append(t.Versions, catalogVersion(v))
+		}
+		resp.Templates = append(resp.Templates, t)
+	}
+	sort.Slice(resp.Templates, func(i, j int) bool { return resp.Templates[i].TemplateID < resp.Templates[j].TemplateID })
+	log.Printf("[INFO] Catalog listed: templateId=%s templates=%d", templateID, len(resp.Templates))
+	return httpresp.OK(200, resp), nil
+}
+
+// CatalogGetHandler atende GET /cf/templates/{templateId}/{version}: a versão
+// com o conteúdo (JSON).
+func CatalogGetHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := catalogSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+	templateID, version, errResp := catalogTarget(req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	v, err := s.catalog.Get(ctx, s.owner, templateID, version)
+	if err != nil {
+		if errors.Is(err, catalog.ErrNotFound) {
+			return httpresp.Error(404, fmt.Errorf("version '%s' of template '%s' not found", version, templateID)), nil
+		}
+		return httpresp.Error(500, fmt.Errorf("get template version failed: %w", err)), nil
+	}
+	content, err := s.catalog.Content(ctx, v)
+	if err != nil {
+		return httpresp.Error(500, fmt.Errorf("read catalog template failed: %w", err)), nil
+	}
+
+	resp := catalogVersion(v)
+	resp.Template = content
+	return httpresp.OK(200, resp), nil
+}
+
+// CatalogDeprecateHandler atende POST /cf/templates/{templateId}/{version}/deprecate.
+// Stacks existentes não mudam; a versão só deixa de ser aceita em novas
+// criações e de ser escolhida como a mais nova.
+func CatalogDeprecateHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := catalogSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+	templateID, version, errResp := catalogTarget(req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	var body types.DeprecateRequest
+	if strings.TrimSpace(req.Body) != "" {
+		if err := decodeBody(req, &body); err != nil {
+			return httpresp.Error(400, err), nil
+		}
+	}
+
+	v, err := s.catalog.Deprecate(ctx, s.owner, templateID, version, body.Message, time.Now())
+	if err != nil {
+		if errors.Is(err, catalog.ErrNotFound) {
+			return httpresp.Error(404, fmt.Errorf("version '%s' of template '%s' not found", version, templateID)), nil
+		}
+		return httpresp.Error(500, fmt.Errorf("deprecate template version failed: %w", err)), nil
+	}
+	log.Printf("[WARN] Template version deprecated: templateId=%s version=%s owner=%s", templateID, version, s.owner)
+	return httpresp.OK(200, catalogVersion(v)), nil
+}
+
+func catalogTarget(req events.APIGatewayV2HTTPRequest) (string, string, *events.APIGatewayV2HTTPResponse) {
+	templateID := strings.TrimSpace(req.PathParameters["templateId"])
+	version := strings.TrimSpace(req.PathParameters["version"])
+	if err := catalog.ValidateID(templateID); err != nil {
+		resp := httpresp.Error(400, err)
+		return "", "", &resp
+	}
+	if _, err := catalog.ParseSemver(version); err != nil {
+		resp := httpresp.Error(400, err)
+		return "", "", &resp
+	}
+	return templateID, version, nil
+}
+
+func catalogVersion(v catalog.Version) types.CatalogVersion {
+	out := types.CatalogVersion{
+		TemplateID:         v.TemplateID,
+		Version:            v.Version,
+		Description:        v.Description,
+		ContentHash:        v.ContentHash,
+		Size:               v.Size,
+		CreatedAt:          v.CreatedAt,
+		Deprecated:         v.Deprecated,
+		DeprecationMessage: v.DeprecationMessage,
+	}
+	if !v.DeprecatedAt.IsZero() {
+		at := v.DeprecatedAt
+		out.DeprecatedAt = &at
+	}
+	return out
+}
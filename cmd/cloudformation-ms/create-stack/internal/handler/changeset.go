@@ -0,0 +1,337 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"create-stack-ms/internal/cfn"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/inventory"
+	"create-stack-ms/internal/types"
+)
+
+const changeSetMaxWait = 90 * time.Second
+
+// ChangeSetHandler atende POST /cf/change-sets: cria o change set, aguarda o
+// cálculo e devolve a prévia das mudanças sem executar nada.
+func ChangeSetHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	var body types.ChangeSetRequest
+	if err := decodeBody(req, &body); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	log.Printf("[INFO] Payload summary: accountName=%s stackName=%s changeSetType=%s templateInline=%t templateUrlSet=%t tags=%d caps=%v",
+		body.AccountName, body.StackName, body.ChangeSetType, len(body.Template) > 0 || body.TemplateYAML != "", body.TemplateURL != "", len(body.Tags), body.Capabilities)
+
+	if body.AccountName == "" || body.StackName == "" {
+		return httpresp.Error(400, errors.New("fields 'accountName' and 'stackName' are required")), nil
+	}
+	if len(body.StackPolicy) > 0 || body.EnableTerminationProtection != nil {
+		// CreateChangeSet não aceita nenhum dos dois; ignorar calado deixaria a stack desprotegida
+		return httpresp.Error(400, errors.New("fields 'stackPolicy' and 'enableTerminationProtection' are not supported for change sets (use the stack policy and termination protection endpoints)")), nil
+	}
+
+	if errResp := s.catalogTemplate(ctx, &body.RequestBody); errResp != nil {
+		return *errResp, nil
+	}
+	templateBody, errResp := s.resolveTemplate(ctx, &body.RequestBody)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	caps, errResp := s.resolveCapabilities(body.RequestBody)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	rollback, err := cfn.RollbackConfiguration(body.RollbackConfiguration)
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	if err := cfn.NotificationARNs(body.NotificationARNs); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+
+	targetCfg, accountID, errResp := s.target(ctx, body.AccountName, body.Region)
+	if errResp != nil {
+		return *errResp, nil
+	}
+	if err := cfn.CheckTargetARNs(accountID, targetCfg.Region, rollback, body.NotificationARNs); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	cfnClient := cf.NewFromConfig(targetCfg)
+
+	csType, err := cfn.ChangeSetType(ctx, cfnClient, body.StackName, strings.ToUpper(body.ChangeSetType))
+	if err != nil {
+		if errors.Is(err, cfn.ErrInvalidChangeSetType) {
+			return httpresp.Error(400, err), nil
+		}
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe stack failed: %w", err)), nil
+	}
+
+	// Como em create-stack, o scan automático vale para stacks novas
+	var findings []types.ScanFinding
+	if csType == cft.ChangeSetTypeCreate {
+		var status int
+		if findings, status, err = securityScan(body.RequestBody); err != nil {
+			return policyError(status, err), nil
+		}
+	}
+
+	name := body.ChangeSetName
+	if name == "" {
+		name = fmt.Sprintf("cloudbuilder-%d", time.Now().Unix())
+	}
+
+	cfParams, visibleParams, errResp := resolveParameters(ctx, targetCfg, cfnClient, body.RequestBody, templateBody, csType == cft.ChangeSetTypeUpdate)
+	if errResp != nil {
+		return *errResp, nil
+	}
+	tags, status, err := s.stackTags(body.RequestBody, body.AccountName)
+	if err != nil {
+		return policyError(status, err), nil
+	}
+	warnings, status, err := s.checkGuardrails(ctx, body.RequestBody, body.AccountName, targetCfg.Region)
+	if err != nil {
+		return policyError(status, err), nil
+	}
+
+	in := &cf.CreateChangeSetInput{
+		StackName:     &body.StackName,
+		ChangeSetName: aws.String(name),
+		ChangeSetType: csType,
+		Capabilities:  caps,
+		Tags:          tags,
+		Parameters:    cfParams,
+
+		RollbackConfiguration: rollback,
+		NotificationARNs:      body.NotificationARNs,
+	}
+	if body.Description != "" {
+		in.Description = aws.String(body.Description)
+	}
+	if body.ClientRequestToken != "" {
+		in.ClientToken = aws.String(body.ClientRequestToken)
+	}
+	if templateBody != nil {
+		in.TemplateBody = templateBody
+	} else {
+		in.TemplateURL = aws.String(body.TemplateURL)
+	}
+	if body.OnFailure != "" {
+		onFailure, err := cfn.OnFailure(body.OnFailure)
+		if err != nil {
+			return httpresp.Error(400, err), nil
+		}
+		in.OnStackFailure = cft.OnStackFailure(onFailure)
+	}
+
+	log.Printf("[INFO] Calling CreateChangeSet: stackName=%s changeSetName=%s type=%s caps=%v params=%d tags=%d",
+		body.StackName, name, csType, caps, len(in.Parameters), len(in.Tags))
+
+	out, err := cfnClient.CreateChangeSet(ctx, in)
+	if err != nil {
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("create change set failed: %w", err)), nil
+	}
+	log.Printf("[INFO] CreateChangeSet started: changeSetId=%s stackId=%s", aws.ToString(out.Id), aws.ToString(out.StackId))
+
+	cs, err := cfn.WaitChangeSet(ctx, cfnClient, aws.ToString(out.Id), changeSetMaxWait)
+	if err != nil && !errors.Is(err, cfn.ErrChangeSetTimeout) {
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe change set failed: %w", err)), nil
+	}
+
+	status = 200
+	cs.Message = "change set ready for review"
+	switch {
+	case errors.Is(err, cfn.ErrChangeSetTimeout):
+		// O change set continua sendo calculado; o cliente pode consultar depois
+		status = 202
+		cs.Message = "change set still being computed"
+	case cs.Status == string(cft.ChangeSetStatusFailed):
+		cs.Message = "change set creation failed"
+	}
+	cs.ChangeSetID = aws.ToString(out.Id)
+	cs.ChangeSetType = string(csType)
+	cs.Account = body.AccountName
+	cs.Region = targetCfg.Region
+	cs.Owner = s.owner
+	cs.Parameters = visibleParams
+	cs.Capabilities = cfn.CapabilityNames(caps)
+	cs.GuardrailWarnings = warnings
+	cs.SecurityFindings = findings
+	log.Printf("[INFO] Change set result: changeSetId=%s status=%s executionStatus=%s changes=%d",
+		cs.ChangeSetID, cs.Status, cs.ExecutionStatus, len(cs.Changes))
+
+	return httpresp.OK(status, cs), nil
+}
+
+// ExecuteChangeSetHandler atende POST /cf/change-sets/{id}/execute.
+func ExecuteChangeSetHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	accountName, region, changeSetID, errResp := changeSetTarget(req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	targetCfg, errResp := s.targetConfig(ctx, accountName, region)
+	if errResp != nil {
+		return *errResp, nil
+	}
+	cfnClient := cf.NewFromConfig(targetCfg)
+
+	cs, err := cfn.DescribeChangeSet(ctx, cfnClient, changeSetID)
+	if err != nil {
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe change set failed: %w", err)), nil
+	}
+	if errResp := s.checkChangeSetOwner(ctx, cfnClient, cs, accountName); errResp != nil {
+		return *errResp, nil
+	}
+	if cs.ExecutionStatus != string(cft.ExecutionStatusAvailable) {
+		return httpresp.Error(409, fmt.Errorf("change set is not executable (status=%s executionStatus=%s): %s",
+			cs.Status, cs.ExecutionStatus, cs.StatusReason)), nil
+	}
+
+	log.Printf("[INFO] Calling ExecuteChangeSet: changeSetId=%s stackId=%s changes=%d", cs.ChangeSetID, cs.StackID, len(cs.Changes))
+	// Lido antes: executar o change set remove os change sets da stack
+	hash := templateHash(ctx, cfnClient, nil, cs.StackID, cs.ChangeSetID)
+	startedAt := time.Now()
+	if _, err := cfnClient.ExecuteChangeSet(ctx, &cf.ExecuteChangeSetInput{
+		ChangeSetName: aws.String(cs.ChangeSetID),
+	}); err != nil {
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("execute change set failed: %w", err)), nil
+	}
+
+	resp := types.ResponseBody{
+		Message:   "change set execution started",
+		StackID:   cs.StackID,
+		StackName: cs.StackName,
+		Account:   accountName,
+		Region:    targetCfg.Region,
+		Owner:     s.owner,
+	}
+	if out, err := cfnClient.DescribeStacks(ctx, &cf.DescribeStacksInput{StackName: aws.String(cs.StackID)}); err == nil && len(out.Stacks) > 0 {
+		resp.Status = string(out.Stacks[0].StackStatus)
+	} else if err != nil {
+		log.Printf("[WARN] Could not read stack status after execute: %v", err)
+	}
+	s.track(ctx, inventory.Deployment{
+		Account:      accountName,
+		Region:       targetCfg.Region,
+		StackID:      cs.StackID,
+		StackName:    cs.StackName,
+		Operation:    operationOf(resp.Status),
+		TemplateHash: hash,
+		Parameters:   cs.Parameters,
+		Status:       resp.Status,
+	}, startedAt)
+	return httpresp.OK(200, resp), nil
+}
+
+// DeleteChangeSetHandler atende DELETE /cf/change-sets/{id}: descarta o change set.
+func DeleteChangeSetHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	accountName, region, changeSetID, errResp := changeSetTarget(req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	targetCfg, errResp := s.targetConfig(ctx, accountName, region)
+	if errResp != nil {
+		return *errResp, nil
+	}
+	cfnClient := cf.NewFromConfig(targetCfg)
+
+	cs, err := cfn.DescribeChangeSet(ctx, cfnClient, changeSetID)
+	if err != nil {
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe change set failed: %w", err)), nil
+	}
+	if errResp := s.checkChangeSetOwner(ctx, cfnClient, cs, accountName); errResp != nil {
+		return *errResp, nil
+	}
+
+	log.Printf("[INFO] Calling DeleteChangeSet: changeSetId=%s stackId=%s", cs.ChangeSetID, cs.StackID)
+	if _, err := cfnClient.DeleteChangeSet(ctx, &cf.DeleteChangeSetInput{
+		ChangeSetName: aws.String(cs.ChangeSetID),
+	}); err != nil {
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("delete change set failed: %w", err)), nil
+	}
+
+	// Um change set CREATE descartado deixa a stack em REVIEW_IN_PROGRESS, sem recursos
+	return httpresp.OK(200, types.ChangeSetResponse{
+		Message:       "change set deleted",
+		ChangeSetID:   cs.ChangeSetID,
+		ChangeSetName: cs.ChangeSetName,
+		StackID:       cs.StackID,
+		StackName:     cs.StackName,
+		Account:       accountName,
+		Region:        targetCfg.Region,
+		Owner:         s.owner,
+		Status:        string(cft.ChangeSetStatusDeleteComplete),
+		Changes:       []types.ResourceChange{},
+	}), nil
+}
+
+// checkChangeSetOwner aplica à stack do change set a mesma checagem de owner
+// de delete e protection.
+func (s *session) checkChangeSetOwner(ctx context.Context, cfnClient *cf.Client, cs types.ChangeSetResponse, accountName string) *events.APIGatewayV2HTTPResponse {
+	stack, err := cfn.LookupStack(ctx, cfnClient, cs.StackID)
+	if err != nil {
+		if errors.Is(err, cfn.ErrStackNotFound) {
+			resp := httpresp.Error(404, fmt.Errorf("stack '%s' not found in account '%s'", cs.StackName, accountName))
+			return &resp
+		}
+		resp := httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe stack failed: %w", err))
+		return &resp
+	}
+	return s.checkOwner(ctx, stack, accountName)
+}
+
+// changeSetTarget lê {id} (ARN do change set, URL-encoded), ?accountName= e
+// ?region=. Sem ?region= vale a região do próprio ARN.
+func changeSetTarget(req events.APIGatewayV2HTTPRequest) (string, string, string, *events.APIGatewayV2HTTPResponse) {
+	accountName := strings.TrimSpace(req.QueryStringParameters["accountName"])
+	id, err := url.PathUnescape(req.PathParameters["id"])
+	if err != nil || strings.TrimSpace(id) == "" {
+		resp := httpresp.Error(400, errors.New("path parameter 'id' must be the URL-encoded change set ARN"))
+		return "", "", "", &resp
+	}
+	if accountName == "" {
+		resp := httpresp.Error(400, errors.New("query parameter 'accountName' is required"))
+		return "", "", "", &resp
+	}
+	// Só o ARN identifica o change set sem o nome da stack
+	if !strings.HasPrefix(id, "arn:") {
+		resp := httpresp.Error(400, errors.New("path parameter 'id' must be the change set ARN returned by POST /cf/change-sets"))
+		return "", "", "", &resp
+	}
+	region := queryRegion(req)
+	if region == "" {
+		// arn:aws:cloudformation:<region>:<account>:changeSet/...
+		if parts := strings.SplitN(id, ":", 5); len(parts) == 5 {
+			region = parts[3]
+		}
+	}
+	return accountName, region, id, nil
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/template"
+	"create-stack-ms/internal/types"
+)
+
+// ConvertHandler atende POST /cf/templates/convert: converte templates entre
+// YAML e JSON sem falar com nenhuma conta.
+func ConvertHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	if _, errResp := newSession(ctx, req); errResp != nil {
+		return *errResp, nil
+	}
+
+	var body types.ConvertRequest
+	if err := decodeBody(req, &body); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+
+	format := strings.ToLower(strings.TrimSpace(body.Format))
+	if format != "json" && format != "yaml" {
+		return httpresp.Error(400, errors.New("field 'format' must be 'json' or 'yaml'")), nil
+	}
+
+	src, isText, err := templateText(types.RequestBody{Template: body.Template, TemplateYAML: body.TemplateYAML})
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	if !isText {
+		if len(body.Template) == 0 {
+			return httpresp.Error(400, errors.New("either 'template' or 'templateYaml' is required")), nil
+		}
+		src = string(body.Template)
+	}
+
+	doc, err := template.Parse([]byte(src))
+	if err != nil {
+		return httpresp.Error(400, fmt.Errorf("template must be valid YAML or JSON: %v", err)), nil
+	}
+	compact, err := doc.JSON()
+	if err != nil {
+		return httpresp.Error(400, fmt.Errorf("template could not be converted to JSON: %v", err)), nil
+	}
+
+	var out []byte
+	if format == "json" {
+		out, err = doc.IndentedJSON()
+	} else {
+		out, err = doc.YAML(body.ShortForm)
+	}
+	if err != nil {
+		return httpresp.Error(500, fmt.Errorf("convert template failed: %w", err)), nil
+	}
+	log.Printf("[INFO] Template converted: format=%s shortForm=%t source=%d bytes json=%d bytes", format, body.ShortForm, len(src), len(compact))
+
+	return httpresp.OK(200, types.ConvertResponse{
+		Format:     format,
+		Template:   string(out),
+		JSONSize:   len(compact),
+		FitsInline: len(compact) <= maxTemplateBodyBytes,
+	}), nil
+}
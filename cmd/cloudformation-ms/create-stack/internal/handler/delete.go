@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"create-stack-ms/internal/cfn"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/inventory"
+	"create-stack-ms/internal/types"
+)
+
+// DeleteHandler atende DELETE /cf/stacks/{accountName}/{stackName}.
+//
+// Query params:
+//   - force=true: desliga a termination protection antes de deletar
+//   - retainResources=Id1,Id2: recursos mantidos (só para stacks em DELETE_FAILED)
+//   - clientRequestToken: repassado ao DeleteStack
+func DeleteHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	accountName := strings.TrimSpace(req.PathParameters["accountName"])
+	stackName := strings.TrimSpace(req.PathParameters["stackName"])
+	if accountName == "" || stackName == "" {
+		return httpresp.Error(400, errors.New("path parameters 'accountName' and 'stackName' are required")), nil
+	}
+
+	q := req.QueryStringParameters
+	force := strings.EqualFold(q["force"], "true")
+	var retain []string
+	for _, id := range strings.Split(q["retainResources"], ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			retain = append(retain, id)
+		}
+	}
+	log.Printf("[INFO] Delete request: accountName=%s stackName=%s force=%t retain=%v", accountName, stackName, force, retain)
+
+	targetCfg, errResp := s.targetConfig(ctx, accountName, queryRegion(req))
+	if errResp != nil {
+		return *errResp, nil
+	}
+	cfnClient := cf.NewFromConfig(targetCfg)
+
+	stack, err := cfn.LookupStack(ctx, cfnClient, stackName)
+	if err != nil {
+		if errors.Is(err, cfn.ErrStackNotFound) {
+			return httpresp.Error(404, fmt.Errorf("stack '%s' not found in account '%s'", stackName, accountName)), nil
+		}
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe stack failed: %w", err)), nil
+	}
+	if errResp := s.checkOwner(ctx, stack, accountName); errResp != nil {
+		return *errResp, nil
+	}
+	stackID := aws.ToString(stack.StackId)
+
+	resp := types.ResponseBody{
+		StackID:   stackID,
+		StackName: aws.ToString(stack.StackName),
+		Account:   accountName,
+		Region:    targetCfg.Region,
+		Owner:     s.owner,
+	}
+
+	switch lc := cfn.LifecycleOf(stack.StackStatus); lc {
+	case cfn.LifecycleDeleted:
+		return httpresp.Error(404, fmt.Errorf("stack '%s' not found in account '%s'", stackName, accountName)), nil
+	case cfn.LifecycleDeleting:
+		resp.Message = "stack deletion already in progress"
+		resp.Status = string(stack.StackStatus)
+		resp.Lifecycle = string(lc)
+		return httpresp.OK(200, resp), nil
+	case cfn.LifecyclePending:
+		// REVIEW_IN_PROGRESS é o que sobra de um change set CREATE descartado:
+		// não há operação rodando e a stack só sai com DeleteStack
+		if stack.StackStatus == cft.StackStatusReviewInProgress {
+			break
+		}
+		return httpresp.Error(409, fmt.Errorf("stack has an operation in progress (status=%s)", stack.StackStatus)), nil
+	}
+
+	if len(retain) > 0 && stack.StackStatus != cft.StackStatusDeleteFailed {
+		return httpresp.Error(400, fmt.Errorf("retainResources is only allowed for stacks in DELETE_FAILED (status=%s)", stack.StackStatus)), nil
+	}
+
+	reprotect := false
+	if aws.ToBool(stack.EnableTerminationProtection) {
+		if !force {
+			return httpresp.Error(409, errors.New("termination protection is enabled for this stack (use force=true to override)")), nil
+		}
+		log.Printf("[WARN] Disabling termination protection: stackId=%s owner=%s", stackID, s.owner)
+		if _, err := cfnClient.UpdateTerminationProtection(ctx, &cf.UpdateTerminationProtectionInput{
+			StackName:                   aws.String(stackID),
+			EnableTerminationProtection: aws.Bool(false),
+		}); err != nil {
+			return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("disable termination protection failed: %w", err)), nil
+		}
+		reprotect = true
+	}
+
+	in := &cf.DeleteStackInput{
+		StackName:       aws.String(stackID),
+		RetainResources: retain,
+	}
+	if t := q["clientRequestToken"]; t != "" {
+		in.ClientRequestToken = aws.String(t)
+	}
+	log.Printf("[INFO] Calling DeleteStack: stackId=%s status=%s retain=%d", stackID, stack.StackStatus, len(retain))
+	startedAt := time.Now()
+	if _, err := cfnClient.DeleteStack(ctx, in); err != nil {
+		if reprotect {
+			// A stack continua de pé: não pode ficar sem a proteção que tinha
+			if _, perr := cfnClient.UpdateTerminationProtection(ctx, &cf.UpdateTerminationProtectionInput{
+				StackName:                   aws.String(stackID),
+				EnableTerminationProtection: aws.Bool(true),
+			}); perr != nil {
+				log.Printf("[ERROR] Could not re-enable termination protection after failed delete: stackId=%s err=%v", stackID, perr)
+			} else {
+				log.Printf("[INFO] Termination protection re-enabled after failed delete: stackId=%s", stackID)
+			}
+		}
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("delete stack failed: %w", err)), nil
+	}
+
+	// DeleteStack é assíncrono: devolve o estado logo após a chamada
+	resp.Message = "stack deletion started"
+	resp.Status = string(cft.StackStatusDeleteInProgress)
+	if after, err := cfn.LookupStack(ctx, cfnClient, stackID); err == nil {
+		resp.Status = string(after.StackStatus)
+	} else {
+		log.Printf("[WARN] Could not read stack status after delete: %v", err)
+	}
+	resp.Lifecycle = string(cfn.LifecycleOf(cft.StackStatus(resp.Status)))
+	s.track(ctx, inventory.Deployment{
+		Account:   accountName,
+		Region:    targetCfg.Region,
+		StackID:   stackID,
+		StackName: resp.StackName,
+		Operation: "DELETE",
+		Status:    resp.Status,
+	}, startedAt)
+	return httpresp.OK(200, resp), nil
+}
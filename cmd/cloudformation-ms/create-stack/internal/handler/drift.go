@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+
+	"create-stack-ms/internal/cfn"
+	"create-stack-ms/internal/drift"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/types"
+)
+
+const driftMaxWait = 60 * time.Second
+
+// DriftHandler atende POST /cf/stacks/{accountName}/{stackName}/drift.
+//
+// Query params:
+//   - detectionId: acompanha uma detecção já iniciada (resposta 202 anterior)
+//   - all=true: inclui recursos IN_SYNC e NOT_CHECKED na resposta
+func DriftHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	accountName := strings.TrimSpace(req.PathParameters["accountName"])
+	stackName := strings.TrimSpace(req.PathParameters["stackName"])
+	if accountName == "" || stackName == "" {
+		return httpresp.Error(400, errors.New("path parameters 'accountName' and 'stackName' are required")), nil
+	}
+	q := req.QueryStringParameters
+	detectionID := strings.TrimSpace(q["detectionId"])
+	all := strings.EqualFold(q["all"], "true")
+	log.Printf("[INFO] Drift request: accountName=%s stackName=%s detectionId=%s all=%t", accountName, stackName, detectionID, all)
+
+	targetCfg, errResp := s.targetConfig(ctx, accountName, queryRegion(req))
+	if errResp != nil {
+		return *errResp, nil
+	}
+	cfnClient := cf.NewFromConfig(targetCfg)
+
+	stack, err := cfn.LookupStack(ctx, cfnClient, stackName)
+	if err != nil {
+		if errors.Is(err, cfn.ErrStackNotFound) {
+			return httpresp.Error(404, fmt.Errorf("stack '%s' not found in account '%s'", stackName, accountName)), nil
+		}
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe stack failed: %w", err)), nil
+	}
+	stackID := aws.ToString(stack.StackId)
+
+	if detectionID == "" {
+		switch lc := cfn.LifecycleOf(stack.StackStatus); lc {
+		case cfn.LifecyclePending, cfn.LifecycleDeleting, cfn.LifecycleDeleted:
+			return httpresp.Error(409, fmt.Errorf("drift detection is not available for stacks in status %s", stack.StackStatus)), nil
+		}
+		if detectionID, err = cfn.DetectDrift(ctx, cfnClient, stackID); err != nil {
+			return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("detect stack drift failed: %w", err)), nil
+		}
+		log.Printf("[INFO] Drift detection started: stackId=%s detectionId=%s", stackID, detectionID)
+	}
+
+	resp, status, err := driftResult(ctx, cfnClient, stackID, detectionID, all)
+	if err != nil {
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe stack drift failed: %w", err)), nil
+	}
+	resp.StackName = aws.ToString(stack.StackName)
+	resp.Account = accountName
+	resp.Region = targetCfg.Region
+	resp.Owner = s.owner
+
+	if status == 200 {
+		s.recordDrift(ctx, resp)
+	}
+	log.Printf("[INFO] Drift result: stackId=%s detectionStatus=%s driftStatus=%s drifted=%d",
+		stackID, resp.DetectionStatus, resp.DriftStatus, resp.DriftedResources)
+	return httpresp.OK(status, resp), nil
+}
+
+// driftResult aguarda a detecção e monta a resposta. Devolve 202 quando a
+// detecção ainda não terminou dentro do tempo da Lambda.
+func driftResult(ctx context.Context, api cfn.DriftAPI, stackID, detectionID string, all bool) (types.DriftResponse, int, error) {
+	resp := types.DriftResponse{
+		DetectionID: detectionID,
+		StackID:     stackID,
+		Resources:   []types.ResourceDrift{},
+	}
+
+	st, err := cfn.WaitDrift(ctx, api, detectionID, driftMaxWait)
+	if st != nil {
+		resp.DetectionStatus = string(st.DetectionStatus)
+		resp.StatusReason = aws.ToString(st.DetectionStatusReason)
+		resp.DriftStatus = string(st.StackDriftStatus)
+		resp.DriftedResources = aws.ToInt32(st.DriftedStackResourceCount)
+		resp.CheckedAt = st.Timestamp
+	}
+	if errors.Is(err, cfn.ErrDriftTimeout) {
+		resp.Message = "drift detection still in progress"
+		return resp, 202, nil
+	}
+	if err != nil {
+		return resp, 0, err
+	}
+
+	if resp.Resources, err = cfn.ResourceDrifts(ctx, api, stackID, all); err != nil {
+		return resp, 0, err
+	}
+	resp.Message = "drift detection complete"
+	return resp, 200, nil
+}
+
+// recordDrift guarda o último resultado da stack. Falhas só geram log: o
+// registro serve para alertas, não para a resposta.
+func (s *session) recordDrift(ctx context.Context, r types.DriftResponse) {
+	if s.drifts == nil {
+		return
+	}
+	checkedAt := time.Now()
+	if r.CheckedAt != nil {
+		checkedAt = *r.CheckedAt
+	}
+	err := s.drifts.Put(ctx, drift.Record{
+		Owner:            s.owner,
+		Account:          r.Account,
+		Region:           r.Region,
+		StackName:        r.StackName,
+		StackID:          r.StackID,
+		DetectionID:      r.DetectionID,
+		DetectionStatus:  r.DetectionStatus,
+		DriftStatus:      r.DriftStatus,
+		DriftedResources: r.DriftedResources,
+		CheckedAt:        checkedAt,
+	})
+	if err != nil {
+		log.Printf("[WARN] Could not record drift status: stackId=%s err=%v", r.StackID, err)
+	}
+}
@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	cip "github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	sm "github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"create-stack-ms/internal/awsconfig"
+	"create-stack-ms/internal/cfn"
+	"create-stack-ms/internal/credentials"
+	"create-stack-ms/internal/types"
+)
+
+// Status em que o CloudFormation aceita DetectStackDrift.
+var driftableStatuses = []cft.StackStatus{
+	cft.StackStatusCreateComplete,
+	cft.StackStatusUpdateComplete,
+	cft.StackStatusUpdateRollbackComplete,
+	cft.StackStatusUpdateRollbackFailed,
+	cft.StackStatusImportComplete,
+	cft.StackStatusImportRollbackComplete,
+}
+
+type ownerAccount struct {
+	owner   string
+	account string
+}
+
+// driftSweepMessage é a mensagem da fila do sweep: uma conta por mensagem.
+type driftSweepMessage struct {
+	Owner   string `json:"owner"`
+	Account string `json:"account"`
+}
+
+// DriftSweepHandler roda por agendamento (EventBridge): enfileira uma
+// mensagem por conta registrada (secret <owner>/<account>/access_keys) para
+// DriftSweepAccountHandler, que faz a detecção. Assim cada conta tem o
+// timeout inteiro da Lambda e as contas rodam em paralelo.
+func DriftSweepHandler(ctx context.Context, ev events.CloudWatchEvent) (types.DriftSweepSummary, error) {
+	log.Printf("[INFO] Drift sweep started: eventId=%s", ev.ID)
+	var summary types.DriftSweepSummary
+
+	queueURL := os.Getenv("DRIFT_SWEEP_QUEUE_URL")
+	if queueURL == "" {
+		return summary, errors.New("DRIFT_SWEEP_QUEUE_URL is not set")
+	}
+	cfg, err := awsconfig.Base(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("aws config error: %w", err)
+	}
+
+	accounts, err := registeredAccounts(ctx, sm.NewFromConfig(cfg))
+	if err != nil {
+		return summary, fmt.Errorf("list secrets failed: %w", err)
+	}
+	queue := sqs.NewFromConfig(cfg)
+	owners := map[string]bool{}
+	for _, oa := range accounts {
+		owners[oa.owner] = true
+		body, _ := json.Marshal(driftSweepMessage{Owner: oa.owner, Account: oa.account})
+		_, err := queue.SendMessage(ctx, &sqs.SendMessageInput{
+			QueueUrl:    aws.String(queueURL),
+			MessageBody: aws.String(string(body)),
+		})
+		if err != nil {
+			summary.FailedAccounts++
+			log.Printf("[ERROR] Could not enqueue drift sweep: owner=%s account=%s err=%v", oa.owner, oa.account, err)
+			continue
+		}
+		summary.Accounts++
+	}
+	summary.Owners = len(owners)
+
+	log.Printf("[INFO] Drift sweep enqueued: owners=%d accounts=%d failedAccounts=%d",
+		summary.Owners, summary.Accounts, summary.FailedAccounts)
+	return summary, nil
+}
+
+// DriftSweepAccountHandler consome a fila do sweep: detecta drift nas stacks
+// do owner em todas as regiões liberadas da conta e grava o resultado. Stacks
+// com drift geram um log "[WARN] Drift detected", usado pelo filtro de
+// métrica para alertas. Contas com falha voltam como batch item failures.
+func DriftSweepAccountHandler(ctx context.Context, ev events.SQSEvent) (events.SQSEventResponse, error) {
+	var resp events.SQSEventResponse
+
+	cfg, err := awsconfig.Base(ctx)
+	if err != nil {
+		return resp, fmt.Errorf("aws config error: %w", err)
+	}
+	d := &deps{
+		cip: cip.NewFromConfig(cfg),
+		sm:  sm.NewFromConfig(cfg),
+	}
+
+	for _, msg := range ev.Records {
+		var m driftSweepMessage
+		if err := json.Unmarshal([]byte(msg.Body), &m); err != nil || m.Owner == "" || m.Account == "" {
+			// Mensagem inválida nunca vai dar certo: descarta
+			log.Printf("[ERROR] Invalid drift sweep message: messageId=%s err=%v", msg.MessageId, err)
+			continue
+		}
+		var summary types.DriftSweepSummary
+		s := ownerSession(cfg, d, m.Owner)
+		if err := s.sweepAccount(ctx, m.Account, &summary); err != nil {
+			log.Printf("[ERROR] Drift sweep failed: owner=%s account=%s err=%v", m.Owner, m.Account, err)
+			resp.BatchItemFailures = append(resp.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: msg.MessageId})
+			continue
+		}
+		log.Printf("[INFO] Drift sweep finished: owner=%s account=%s stacks=%d drifted=%d failedStacks=%d",
+			m.Owner, m.Account, summary.Stacks, summary.Drifted, summary.FailedStacks)
+	}
+	return resp, nil
+}
+
+// registeredAccounts lista as contas cadastradas pelos secrets de credenciais.
+func registeredAccounts(ctx context.Context, api sm.ListSecretsAPIClient) ([]ownerAccount, error) {
+	var out []ownerAccount
+	p := sm.NewListSecretsPaginator(api, &sm.ListSecretsInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, sec := range page.SecretList {
+			parts := strings.Split(aws.ToString(sec.Name), "/")
+			if len(parts) != 3 || parts[2] != "access_keys" || parts[0] == "" || parts[1] == "" {
+				continue
+			}
+			out = append(out, ownerAccount{owner: parts[0], account: parts[1]})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].owner != out[j].owner {
+			return out[i].owner < out[j].owner
+		}
+		return out[i].account < out[j].account
+	})
+	return out, nil
+}
+
+// sweepAccount varre cada região liberada da conta. Uma região com falha
+// não impede as demais; a conta só falha se nenhuma região der certo.
+func (s *session) sweepAccount(ctx context.Context, accountName string, summary *types.DriftSweepSummary) error {
+	keys, err := s.accountKeys(ctx, accountName)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	swept := 0
+	for _, region := range credentials.AllowedRegions(keys, s.cfg.Region) {
+		targetCfg, _, _, err := s.buildTargetConfig(ctx, accountName, keys, region)
+		if err == nil {
+			err = s.sweepRegion(ctx, accountName, targetCfg, summary)
+		}
+		if err != nil {
+			lastErr = err
+			log.Printf("[WARN] Drift sweep failed: owner=%s account=%s region=%s err=%v", s.owner, accountName, region, err)
+			continue
+		}
+		swept++
+	}
+	if swept == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+// sweepRegion inicia a detecção nas stacks do owner de uma vez (o
+// CloudFormation processa em paralelo) e depois colhe os resultados. São do
+// owner as stacks com a tag cloudbuilder:owner ou registradas no inventário
+// de deploys; as demais stacks da conta não são tocadas.
+func (s *session) sweepRegion(ctx context.Context, accountName string, targetCfg aws.Config, summary *types.DriftSweepSummary) error {
+	cfnClient := cf.NewFromConfig(targetCfg)
+
+	known := map[string]bool{}
+	if s.inventory != nil {
+		ids, err := s.inventory.StackIDs(ctx, s.owner, accountName, targetCfg.Region)
+		if err != nil {
+			return fmt.Errorf("list deployments failed: %w", err)
+		}
+		known = ids
+	}
+
+	type started struct {
+		stackID, stackName, detectionID string
+	}
+	var pending []started
+	p := cf.NewDescribeStacksPaginator(cfnClient, &cf.DescribeStacksInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("describe stacks failed: %w", err)
+		}
+		for _, st := range page.Stacks {
+			stackID := aws.ToString(st.StackId)
+			if !driftable(st.StackStatus) || !(known[stackID] || s.ownsStack(st)) {
+				continue
+			}
+			detectionID, err := cfn.DetectDrift(ctx, cfnClient, stackID)
+			if err != nil {
+				summary.FailedStacks++
+				log.Printf("[WARN] Could not start drift detection: account=%s stackId=%s err=%v", accountName, stackID, err)
+				continue
+			}
+			pending = append(pending, started{stackID, aws.ToString(st.StackName), detectionID})
+		}
+	}
+
+	for _, st := range pending {
+		resp, status, err := driftResult(ctx, cfnClient, st.stackID, st.detectionID, false)
+		if err != nil || status != 200 {
+			summary.FailedStacks++
+			log.Printf("[WARN] Drift detection not finished: account=%s stackId=%s status=%d err=%v", accountName, st.stackID, status, err)
+			continue
+		}
+		resp.StackName = st.stackName
+		resp.Account = accountName
+		resp.Region = targetCfg.Region
+		s.recordDrift(ctx, resp)
+
+		summary.Stacks++
+		if resp.DriftStatus == string(cft.StackDriftStatusDrifted) {
+			summary.Drifted++
+			log.Printf("[WARN] Drift detected: owner=%s account=%s region=%s stackName=%s drifted=%d",
+				s.owner, accountName, targetCfg.Region, st.stackName, resp.DriftedResources)
+		}
+	}
+	return nil
+}
+
+func driftable(status cft.StackStatus) bool {
+	for _, st := range driftableStatuses {
+		if status == st {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"create-stack-ms/internal/cfn"
+	"create-stack-ms/internal/guardrail"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/types"
+)
+
+const (
+	fanOutWorkers     = 4  // contas resolvidas/criadas em paralelo
+	maxFanOutAccounts = 20 // cabe no timeout da Lambda com folga
+)
+
+// fanOutCreate cria a mesma stack em cada conta de accountNames. Cada conta
+// tem seu próprio resultado: falhas não desfazem as contas que deram certo.
+// Responde 200 quando todas começaram e 207 quando alguma falhou. findings
+// são os do scan automático, que não depende da conta.
+func (s *session) fanOutCreate(ctx context.Context, body types.RequestBody, templateBody *string, caps []cft.Capability, onFailure cft.OnFailure,
+	findings []types.ScanFinding) events.APIGatewayV2HTTPResponse {
+	accounts := uniqueNonEmpty(body.AccountNames)
+	if len(accounts) == 0 {
+		return httpresp.Error(400, fmt.Errorf("field 'accountNames' must not be empty"))
+	}
+	if len(accounts) > maxFanOutAccounts {
+		return httpresp.Error(400, fmt.Errorf("field 'accountNames' accepts at most %d accounts (got %d)", maxFanOutAccounts, len(accounts)))
+	}
+	log.Printf("[INFO] Fan-out create: stackName=%s accounts=%d workers=%d", body.StackName, len(accounts), fanOutWorkers)
+
+	results := make([]types.AccountResult, len(accounts))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < fanOutWorkers && w < len(accounts); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.createInAccount(ctx, body, accounts[i], templateBody, caps, onFailure)
+			}
+		}()
+	}
+	for i := range accounts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	resp := types.MultiAccountResponse{
+		StackName:       body.StackName,
+		Owner:           s.owner,
+		Capabilities:    cfn.CapabilityNames(caps),
+		Results:         results,
+		TemplateID:      body.TemplateID,
+		TemplateVersion: body.TemplateVersion,
+
+		SecurityFindings: findings,
+	}
+	for _, r := range results {
+		if r.Error != nil {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+	log.Printf("[INFO] Fan-out finished: stackName=%s succeeded=%d failed=%d", body.StackName, resp.Succeeded, resp.Failed)
+
+	switch {
+	case resp.Failed == 0:
+		resp.Message = "stack creation started in all accounts"
+		return httpresp.OK(200, resp)
+	case resp.Succeeded == 0:
+		resp.Message = "stack creation failed in all accounts"
+	default:
+		resp.Message = "stack creation started in some accounts"
+	}
+	return httpresp.OK(207, resp)
+}
+
+// createInAccount resolve as credenciais de uma conta e inicia a stack nela.
+func (s *session) createInAccount(ctx context.Context, body types.RequestBody, accountName string, templateBody *string, caps []cft.Capability, onFailure cft.OnFailure) types.AccountResult {
+	result := types.AccountResult{Account: accountName}
+
+	targetCfg, accountID, status, err := s.loadTarget(ctx, accountName, body.Region)
+	if err != nil {
+		log.Printf("[WARN] Fan-out account failed: account=%s status=%d err=%v", accountName, status, err)
+		result.Status = status
+		result.Error = credentialsError(accountName, status, err)
+		return result
+	}
+	result.Region = targetCfg.Region
+
+	out, status, err := s.startStack(ctx, targetCfg, accountID, body, accountName, templateBody, caps, onFailure)
+	if err != nil {
+		log.Printf("[WARN] Fan-out account failed: account=%s status=%d err=%v", accountName, status, err)
+		result.Status = status
+		result.Error = &types.AccountError{Code: cfn.ErrorCode(err, status), Message: cfn.ErrorMessage(err)}
+		var gerr *guardrail.ViolationError
+		if errors.As(err, &gerr) {
+			result.Error = &types.AccountError{Code: "GUARDRAIL_VIOLATION", Message: err.Error(), Violations: guardrailViolations(gerr.Violations)}
+		}
+		return result
+	}
+	result.Status = 200
+	result.StackID = out.StackID
+	result.StackStatus = out.Status
+	result.Parameters = out.Parameters
+	result.GuardrailWarnings = out.GuardrailWarnings
+	return result
+}
+
+// credentialsError traduz as falhas de loadTargetConfig pelo status sugerido.
+func credentialsError(accountName string, status int, err error) *types.AccountError {
+	switch status {
+	case 404:
+		return &types.AccountError{Code: "ACCOUNT_NOT_REGISTERED", Message: fmt.Sprintf("account '%s' is not registered", accountName)}
+	case 401:
+		return &types.AccountError{Code: "INVALID_CREDENTIALS", Message: fmt.Sprintf("invalid credentials for account '%s': %s", accountName, cfn.ErrorMessage(err))}
+	case 403:
+		return &types.AccountError{Code: "REGION_NOT_ALLOWED", Message: err.Error()}
+	case 400:
+		return &types.AccountError{Code: "INVALID_REGION", Message: err.Error()}
+	}
+	return &types.AccountError{Code: cfn.StatusCode(status), Message: cfn.ErrorMessage(err)}
+}
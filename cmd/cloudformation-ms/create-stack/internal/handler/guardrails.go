@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"create-stack-ms/internal/guardrail"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/types"
+)
+
+// guardrailSession abre a sessão e garante que os guardrails estão configurados.
+func guardrailSession(ctx context.Context, req events.APIGatewayV2HTTPRequest) (*session, *events.APIGatewayV2HTTPResponse) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return nil, errResp
+	}
+	if s.guardrails == nil {
+		resp := httpresp.Error(500, errors.New("guardrails are not configured"))
+		return nil, &resp
+	}
+	return s, nil
+}
+
+// GuardrailPublishHandler atende PUT /cf/guardrails: publica uma nova versão
+// da policy do owner ou, com accountName, da policy de uma conta. As versões
+// anteriores continuam disponíveis em GET /cf/guardrails?version=N.
+func GuardrailPublishHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := guardrailSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	var body types.GuardrailRequest
+	if err := decodeBody(req, &body); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	body.AccountName = strings.TrimSpace(body.AccountName)
+	doc, err := guardrail.ParseDocument(body.Document)
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	if body.AccountName != "" {
+		if _, err := s.accountKeys(ctx, body.AccountName); err != nil {
+			return httpresp.Error(404, fmt.Errorf("account '%s' is not registered", body.AccountName)), nil
+		}
+	}
+
+	v, err := s.guardrails.Publish(ctx, s.owner, body.AccountName, doc, s.requestID)
+	if err != nil {
+		if errors.Is(err, guardrail.ErrConflict) {
+			return httpresp.Error(409, errors.New("guardrail policy was published concurrently, retry")), nil
+		}
+		return httpresp.Error(500, fmt.Errorf("publish guardrail policy failed: %w", err)), nil
+	}
+	log.Printf("[INFO] Guardrail policy published: owner=%s policy=%s mode=%s rules=%d", s.owner, v.Label(), doc.Mode, len(doc.Rules))
+	return httpresp.OK(201, guardrailPolicy(v, nil)), nil
+}
+
+// GuardrailGetHandler atende GET /cf/guardrails[?accountName=X][&version=N]:
+// a versão pedida (padrão: a mais nova) da policy do owner ou da conta.
+func GuardrailGetHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := guardrailSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	accountName := strings.TrimSpace(req.QueryStringParameters["accountName"])
+	version := 0
+	if raw := strings.TrimSpace(req.QueryStringParameters["version"]); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return httpresp.Error(400, fmt.Errorf("invalid version '%s'", raw)), nil
+		}
+		version = n
+	}
+
+	v, err := s.guardrails.Get(ctx, s.owner, accountName, version)
+	if err != nil {
+		if errors.Is(err, guardrail.ErrNotFound) {
+			return httpresp.Error(404, errors.New("guardrail policy not found")), nil
+		}
+		return httpresp.Error(500, fmt.Errorf("get guardrail policy failed: %w", err)), nil
+	}
+	versions, err := s.guardrails.Versions(ctx, s.owner, accountName)
+	if err != nil {
+		return httpresp.Error(500, fmt.Errorf("list guardrail policy versions failed: %w", err)), nil
+	}
+	return httpresp.OK(200, guardrailPolicy(v, versions)), nil
+}
+
+func guardrailPolicy(v guardrail.Version, versions []int) types.GuardrailPolicy {
+	doc, _ := json.Marshal(v.Document)
+	return types.GuardrailPolicy{
+		Owner:       v.Owner,
+		AccountName: v.Account,
+		Version:     v.Version,
+		Mode:        v.Document.Mode,
+		Document:    doc,
+		CreatedAt:   v.CreatedAt,
+		Versions:    versions,
+	}
+}
+
+// checkGuardrails avalia o template contra as policies do owner e da conta
+// antes do deploy. Em modo warn as violações voltam como avisos; em modo
+// enforce viram um *guardrail.ViolationError com status 403.
+func (s *session) checkGuardrails(ctx context.Context, body types.RequestBody, accountName, region string) ([]types.GuardrailViolation, int, error) {
+	if s.guardrails == nil {
+		return nil, 0, nil
+	}
+	policy, err := s.guardrails.Effective(ctx, s.owner, accountName)
+	if err != nil {
+		return nil, 500, fmt.Errorf("load guardrail policies failed: %w", err)
+	}
+	if policy.Empty() {
+		return nil, 0, nil
+	}
+
+	raw, err := inlineTemplate(body)
+	if err != nil {
+		return nil, 400, err
+	}
+	in := guardrail.Input{Region: region, Parameters: map[string]string{}}
+	if raw != nil {
+		if err := json.Unmarshal(raw, &in.Template); err != nil {
+			return nil, 400, fmt.Errorf("template must be valid JSON: %v", err)
+		}
+	}
+	for _, p := range body.Parameters {
+		if !p.UsePreviousValue {
+			in.Parameters[p.Key] = p.Value
+		}
+	}
+
+	violations := guardrail.Evaluate(policy, in)
+	log.Printf("[INFO] Guardrails evaluated: account=%s region=%s mode=%s policies=%d violations=%d",
+		accountName, region, policy.Mode, len(policy.Sources), len(violations))
+	if err := guardrail.Enforce(policy, violations); err != nil {
+		return nil, 403, err
+	}
+	return guardrailViolations(violations), 0, nil
+}
+
+func guardrailViolations(vs []guardrail.Violation) []types.GuardrailViolation {
+	var out []types.GuardrailViolation
+	for _, v := range vs {
+		out = append(out, types.GuardrailViolation{
+			RuleID:       v.RuleID,
+			Severity:     v.Severity,
+			Policy:       v.Policy,
+			LogicalID:    v.LogicalID,
+			ResourceType: v.ResourceType,
+			Path:         v.Path,
+			Message:      v.Message,
+		})
+	}
+	return out
+}
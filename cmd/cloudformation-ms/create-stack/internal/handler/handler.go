@@ -2,119 +2,153 @@ package handler
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
-	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
-	cip "github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
-	sm "github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 
-	"create-stack-ms/internal/auth"
-	"create-stack-ms/internal/awsconfig"
 	"create-stack-ms/internal/cfn"
-	"create-stack-ms/internal/credentials"
 	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/inventory"
+	"create-stack-ms/internal/stackpolicy"
 	"create-stack-ms/internal/types"
 )
 
-type deps struct {
-	cip *cip.Client
-	sm  *sm.Client
+// Handler cria a stack. Com o header Idempotency-Key uma retentativa devolve
+// a resposta original em vez de tentar criar a stack de novo.
+func Handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return idempotent(ctx, req, createStack)
 }
 
-func (d *deps) Cognito() *cip.Client { return d.cip }
+func createStack(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
 
-func Handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
-	log.Printf("[INFO] Incoming request: reqId=%s method=%s path=%s",
-		req.RequestContext.RequestID, req.RequestContext.HTTP.Method, req.RawPath)
+	var body types.RequestBody
+	if err := decodeBody(req, &body); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	log.Printf("[INFO] Payload summary: accountName=%s accountNames=%v stackName=%s templateInline=%t templateUrlSet=%t params=%d tags=%d caps=%v",
+		body.AccountName, body.AccountNames, body.StackName, len(body.Template) > 0 || body.TemplateYAML != "", body.TemplateURL != "", len(body.Parameters), len(body.Tags), body.Capabilities)
 
-	// ---- SDK base ----
-	cfg, err := awsconfig.Base(ctx)
-	if err != nil {
-		return httpresp.Error(500, fmt.Errorf("aws config error: %w", err)), nil
+	if (body.AccountName == "" && len(body.AccountNames) == 0) || body.StackName == "" {
+		return httpresp.Error(400, errors.New("fields 'accountName' (or 'accountNames') and 'stackName' are required")), nil
+	}
+	if body.AccountName != "" && len(body.AccountNames) > 0 {
+		return httpresp.Error(400, errors.New("use either 'accountName' or 'accountNames', not both")), nil
 	}
 
-	d := &deps{
-		cip: cip.NewFromConfig(cfg),
-		sm:  sm.NewFromConfig(cfg),
+	if errResp := s.catalogTemplate(ctx, &body); errResp != nil {
+		return *errResp, nil
+	}
+	templateBody, errResp := s.resolveTemplate(ctx, &body)
+	if errResp != nil {
+		return *errResp, nil
 	}
 
-	owner, err := auth.OwnerFromRequest(ctx, req, cfg, d)
-	if err != nil || owner == "" {
-		return httpresp.Error(401, errors.New("unauthorized")), nil
+	caps, errResp := s.resolveCapabilities(body)
+	if errResp != nil {
+		return *errResp, nil
 	}
-	log.Printf("[INFO] Authenticated owner=%s", owner)
 
-	raw := req.Body
-	if req.IsBase64Encoded {
-		b, err := base64.StdEncoding.DecodeString(req.Body)
-		if err != nil {
-			return httpresp.Error(400, fmt.Errorf("invalid base64 body: %w", err)), nil
-		}
-		raw = string(b)
+	onFailure, err := cfn.OnFailure(body.OnFailure)
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	policy, err := stackpolicy.Normalize(body.StackPolicy)
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	body.StackPolicy = json.RawMessage(policy)
+	if _, err := cfn.RollbackConfiguration(body.RollbackConfiguration); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	if err := cfn.NotificationARNs(body.NotificationARNs); err != nil {
+		return httpresp.Error(400, err), nil
 	}
 
-	var body types.RequestBody
-	if err := json.Unmarshal([]byte(raw), &body); err != nil {
-		return httpresp.Error(400, fmt.Errorf("invalid JSON body: %w", err)), nil
-	}
-	log.Printf("[INFO] Payload summary: accountName=%s stackName=%s templateInline=%t templateUrlSet=%t tags=%d caps=%v",
-		body.AccountName, body.StackName, len(body.Template) > 0, body.TemplateURL != "", len(body.Tags), body.Capabilities)
-
-	if body.AccountName == "" || body.StackName == "" {
-		return httpresp.Error(400, errors.New("fields 'accountName' and 'stackName' are required")), nil
-	}
-
-	var templateBody *string
-	if len(body.Template) > 0 {
-		var tmp map[string]any
-		if err := json.Unmarshal(body.Template, &tmp); err != nil {
-			return httpresp.Error(400, fmt.Errorf("template must be valid JSON: %v", err)), nil
-		}
-		str := string(body.Template)
-		if len(str) > 51200 {
-			return httpresp.Error(400, errors.New("template exceeds 51,200 bytes (use templateUrl instead)")), nil
-		}
-		templateBody = &str
-		log.Printf("[INFO] Using inline template (size=%d bytes)", len(str))
-	} else if body.TemplateURL != "" {
-		log.Printf("[INFO] Using template URL: %s", body.TemplateURL)
-	} else {
-		return httpresp.Error(400, errors.New("either 'template' or 'templateUrl' is required")), nil
+	// A policy não depende da conta: validar com uma delas vale para todas
+	firstAccount := body.AccountName
+	if firstAccount == "" {
+		firstAccount = body.AccountNames[0]
+	}
+	if status, err := lintTemplate(body); err != nil {
+		return policyError(status, err), nil
+	}
+	if _, status, err := s.stackTags(body, firstAccount); err != nil {
+		return policyError(status, err), nil
+	}
+	findings, status, err := securityScan(body)
+	if err != nil {
+		return policyError(status, err), nil
 	}
 
-	// ---- Secrets Manager: credenciais da conta alvo ----
-	secretName := fmt.Sprintf("%s/%s/access_keys", owner, body.AccountName)
-	log.Printf("[INFO] Fetching credentials from secret: %s", secretName)
+	if len(body.AccountNames) > 0 {
+		return s.fanOutCreate(ctx, body, templateBody, caps, onFailure, findings), nil
+	}
 
-	keys, err := credentials.GetAccountCreds(ctx, d.sm, secretName)
+	targetCfg, accountID, errResp := s.target(ctx, body.AccountName, body.Region)
+	if errResp != nil {
+		return *errResp, nil
+	}
+	resp, status, err := s.startStack(ctx, targetCfg, accountID, body, body.AccountName, templateBody, caps, onFailure)
 	if err != nil {
-		return httpresp.Error(404, fmt.Errorf("failed to get credentials from secrets manager: %w", err)), nil
+		return policyError(status, err), nil
 	}
+	resp.SecurityFindings = findings
+	return httpresp.OK(200, resp), nil
+}
 
-	// ---- Config alvo (credenciais / assume role / sts check) ----
-	targetCfg, err := credentials.BuildTargetConfig(ctx, cfg, keys)
+// startStack chama CreateStack numa conta já resolvida, sem aguardar a
+// conclusão. accountID é o id AWS da conta, usado para conferir os ARNs de
+// alarmes e tópicos. Devolve o status sugerido junto com o erro.
+func (s *session) startStack(ctx context.Context, targetCfg aws.Config, accountID string, body types.RequestBody, accountName string,
+	templateBody *string, caps []cft.Capability, onFailure cft.OnFailure) (types.ResponseBody, int, error) {
+	rollback, err := cfn.RollbackConfiguration(body.RollbackConfiguration)
 	if err != nil {
-		return httpresp.Error(401, fmt.Errorf("invalid credentials for account '%s': %w", body.AccountName, err)), nil
+		return types.ResponseBody{}, 400, err
+	}
+	if err := cfn.CheckTargetARNs(accountID, targetCfg.Region, rollback, body.NotificationARNs); err != nil {
+		return types.ResponseBody{}, 400, err
+	}
+
+	tags, status, err := s.stackTags(body, accountName)
+	if err != nil {
+		return types.ResponseBody{}, status, err
+	}
+	warnings, status, err := s.checkGuardrails(ctx, body, accountName, targetCfg.Region)
+	if err != nil {
+		return types.ResponseBody{}, status, err
 	}
 
 	// ---- CloudFormation: CreateStack (não aguarda conclusão) ----
 	cfnClient := cf.NewFromConfig(targetCfg)
 
+	cfParams, visibleParams, status, err := loadParameters(ctx, targetCfg, cfnClient, body, templateBody, false)
+	if err != nil {
+		return types.ResponseBody{}, status, err
+	}
+
 	in := &cf.CreateStackInput{
 		StackName:    &body.StackName,
-		Capabilities: cfn.Capabilities(body.Capabilities),
-		Tags:         cfn.Tags(body.Tags),
+		Capabilities: caps,
+		Tags:         tags,
+		Parameters:   cfParams,
+		OnFailure:    onFailure,
+
+		RollbackConfiguration: rollback,
+		NotificationARNs:      body.NotificationARNs,
 	}
-	if body.ClientRequestToken != "" {
-		in.ClientRequestToken = aws.String(body.ClientRequestToken)
+	if token := clientRequestToken(ctx, body.ClientRequestToken); token != "" {
+		in.ClientRequestToken = aws.String(token)
 	}
 	if body.DisableRollback != nil {
 		in.DisableRollback = body.DisableRollback
@@ -122,24 +156,21 @@ func Handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.AP
 	if body.TimeoutInMinutes != nil {
 		in.TimeoutInMinutes = body.TimeoutInMinutes
 	}
+	if len(body.StackPolicy) > 0 {
+		in.StackPolicyBody = aws.String(string(body.StackPolicy))
+	}
+	if body.EnableTerminationProtection != nil {
+		in.EnableTerminationProtection = body.EnableTerminationProtection
+	}
 	if templateBody != nil {
 		in.TemplateBody = templateBody
 	} else {
 		in.TemplateURL = aws.String(body.TemplateURL)
 	}
-	switch strings.ToUpper(body.OnFailure) {
-	case "ROLLBACK":
-		in.OnFailure = cft.OnFailureRollback
-	case "DELETE":
-		in.OnFailure = cft.OnFailureDelete
-	case "DO_NOTHING", "":
-		in.OnFailure = cft.OnFailureDoNothing
-	default:
-		return httpresp.Error(400, fmt.Errorf("invalid onFailure: %s", body.OnFailure)), nil
-	}
 
-	log.Printf("[INFO] Calling CreateStack: stackName=%s onFailure=%s caps=%v params=%d tags=%d templateMode=%s",
-		body.StackName, in.OnFailure, body.Capabilities, len(in.Parameters), len(in.Tags),
+	log.Printf("[INFO] Calling CreateStack: account=%s region=%s stackName=%s onFailure=%s caps=%v params=%d tags=%d stackPolicy=%t terminationProtection=%t rollbackTriggers=%d notifications=%d templateMode=%s",
+		accountName, targetCfg.Region, body.StackName, in.OnFailure, caps, len(in.Parameters), len(in.Tags),
+		in.StackPolicyBody != nil, aws.ToBool(in.EnableTerminationProtection), rollbackTriggers(rollback), len(in.NotificationARNs),
 		func() string {
 			if templateBody != nil {
 				return "INLINE"
@@ -148,20 +179,47 @@ func Handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.AP
 		}(),
 	)
 
+	startedAt := time.Now()
 	out, err := cfnClient.CreateStack(ctx, in)
 	if err != nil {
-		return httpresp.Error(400, fmt.Errorf("create stack failed: %w", err)), nil
-	}
-	log.Printf("[INFO] CreateStack started successfully: stackId=%s", aws.ToString(out.StackId))
+		return types.ResponseBody{}, cfn.HTTPStatus(err), fmt.Errorf("create stack failed: %w", err)
+	}
+	log.Printf("[INFO] CreateStack started successfully: account=%s stackId=%s", accountName, aws.ToString(out.StackId))
+	s.track(ctx, inventory.Deployment{
+		Account:      accountName,
+		Region:       targetCfg.Region,
+		StackID:      aws.ToString(out.StackId),
+		StackName:    body.StackName,
+		Operation:    "CREATE",
+		TemplateHash: templateHash(ctx, cfnClient, templateBody, aws.ToString(out.StackId), ""),
+		Parameters:   visibleParams,
+		Status:       "CREATE_IN_PROGRESS",
+	}, startedAt)
 
 	// Retorna imediatamente, sem esperar o completion
-	resp := types.ResponseBody{
+	return types.ResponseBody{
 		Message:   "stack creation started",
 		StackID:   aws.ToString(out.StackId),
 		StackName: body.StackName,
-		Account:   body.AccountName,
-		Owner:     owner,
+		Account:   accountName,
+		Region:    targetCfg.Region,
+		Owner:     s.owner,
 		Status:    "CREATE_IN_PROGRESS",
+
+		Parameters:   visibleParams,
+		Capabilities: cfn.CapabilityNames(caps),
+
+		TemplateID:      body.TemplateID,
+		TemplateVersion: body.TemplateVersion,
+
+		GuardrailWarnings: warnings,
+	}, 0, nil
+}
+
+// rollbackTriggers conta os alarmes configurados, para o log.
+func rollbackTriggers(rc *cft.RollbackConfiguration) int {
+	if rc == nil {
+		return 0
 	}
-	return httpresp.OK(200, resp), nil
+	return len(rc.RollbackTriggers)
 }
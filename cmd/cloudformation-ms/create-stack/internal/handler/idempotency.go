@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"create-stack-ms/internal/awsconfig"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/idempotency"
+)
+
+// idempotent executa next atrás do middleware de Idempotency-Key quando
+// IDEMPOTENCY_TABLE_NAME está definido; sem a tabela o header é ignorado.
+func idempotent(ctx context.Context, req events.APIGatewayV2HTTPRequest, next idempotency.HandlerFunc) (events.APIGatewayV2HTTPResponse, error) {
+	table := os.Getenv("IDEMPOTENCY_TABLE_NAME")
+	if table == "" {
+		return next(ctx, req)
+	}
+	cfg, err := awsconfig.Base(ctx)
+	if err != nil {
+		return httpresp.Error(500, fmt.Errorf("aws config error: %w", err)), nil
+	}
+	store := idempotency.NewDynamoStore(dynamodb.NewFromConfig(cfg), table)
+	return idempotency.New(store).Wrap(next)(ctx, req)
+}
+
+// clientRequestToken prefere o token enviado no corpo; sem ele, deriva um da
+// Idempotency-Key para que o CloudFormation também reconheça a retentativa.
+func clientRequestToken(ctx context.Context, token string) string {
+	if token != "" {
+		return token
+	}
+	return idempotency.ClientRequestToken(ctx)
+}
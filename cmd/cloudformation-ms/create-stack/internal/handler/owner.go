@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/tagpolicy"
+)
+
+// ownsStack diz se a stack tem a tag cloudbuilder:owner do owner da sessão.
+func (s *session) ownsStack(st cft.Stack) bool {
+	for _, t := range st.Tags {
+		if aws.ToString(t.Key) == tagpolicy.TagOwner {
+			return aws.ToString(t.Value) == s.owner
+		}
+	}
+	return false
+}
+
+// checkOwner só deixa passar stacks do owner da sessão: pela tag
+// cloudbuilder:owner ou pelo inventário de deploys. Stacks de outros owners
+// respondem 404, como se não existissem na conta.
+func (s *session) checkOwner(ctx context.Context, st cft.Stack, accountName string) *events.APIGatewayV2HTTPResponse {
+	if s.ownsStack(st) {
+		return nil
+	}
+	if s.inventory != nil {
+		owns, err := s.inventory.Owns(ctx, s.owner, aws.ToString(st.StackId))
+		if err != nil {
+			log.Printf("[ERROR] Inventory lookup failed for %s: %v", aws.ToString(st.StackId), err)
+			resp := httpresp.Error(500, fmt.Errorf("check stack owner failed: %w", err))
+			return &resp
+		}
+		if owns {
+			return nil
+		}
+	}
+	resp := httpresp.Error(404, fmt.Errorf("stack '%s' not found in account '%s'", aws.ToString(st.StackName), accountName))
+	return &resp
+}
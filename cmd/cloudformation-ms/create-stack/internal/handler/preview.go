@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/intrinsic"
+	"create-stack-ms/internal/types"
+	"create-stack-ms/internal/validate"
+)
+
+// PreviewHandler atende POST /cf/preview: resolve parâmetros, condições e
+// funções intrínsecas do template para a conta e região alvo e devolve o
+// template efetivo, sem os recursos de condição falsa. O id da conta vem do
+// STS; nada é criado. Aceita o mesmo corpo de /cf/create-stack.
+func PreviewHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	var body types.RequestBody
+	if err := decodeBody(req, &body); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	log.Printf("[INFO] Preview request: accountName=%s stackName=%s templateInline=%t templateUrlSet=%t params=%d",
+		body.AccountName, body.StackName, len(body.Template) > 0 || body.TemplateYAML != "", body.TemplateURL != "", len(body.Parameters))
+
+	if body.AccountName == "" {
+		return httpresp.Error(400, errors.New("field 'accountName' is required")), nil
+	}
+	if errResp := s.catalogTemplate(ctx, &body); errResp != nil {
+		return *errResp, nil
+	}
+
+	raw, err := inlineTemplate(body)
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	if raw == nil {
+		return httpresp.Error(400, errors.New("preview needs the template: use 'template', 'templateYaml' or 'templateId' instead of 'templateUrl'")), nil
+	}
+	var tpl map[string]any
+	if err := json.Unmarshal(raw, &tpl); err != nil {
+		return httpresp.Error(400, fmt.Errorf("template must be valid JSON: %v", err)), nil
+	}
+
+	params := map[string]string{}
+	for _, p := range body.Parameters {
+		if p.UsePreviousValue {
+			return httpresp.Error(400, fmt.Errorf("parameter '%s': usePreviousValue is not supported in preview", p.Key)), nil
+		}
+		params[p.Key] = p.Value
+	}
+
+	targetCfg, accountID, errResp := s.target(ctx, body.AccountName, body.Region)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	res, err := intrinsic.Evaluate(intrinsic.Input{
+		Template:         tpl,
+		Parameters:       params,
+		AccountID:        accountID,
+		Region:           targetCfg.Region,
+		StackName:        body.StackName,
+		NotificationARNs: body.NotificationARNs,
+	})
+	if err != nil {
+		var ierr *intrinsic.Error
+		if errors.As(err, &ierr) {
+			return httpresp.Error(400, fmt.Errorf("template cannot be resolved: %w", err)), nil
+		}
+		return httpresp.Error(500, err), nil
+	}
+
+	resp := types.PreviewResponse{
+		Account:         body.AccountName,
+		AccountID:       accountID,
+		Region:          targetCfg.Region,
+		Owner:           s.owner,
+		StackName:       body.StackName,
+		TemplateID:      body.TemplateID,
+		TemplateVersion: body.TemplateVersion,
+		Parameters:      []types.PreviewParameter{},
+		Conditions:      res.Conditions,
+		Resources:       []types.PreviewResource{},
+		Removed:         []types.PreviewRemoved{},
+		Warnings:        []types.Finding{},
+		Template:        res.Template,
+	}
+	for _, p := range res.Parameters {
+		resp.Parameters = append(resp.Parameters, types.PreviewParameter{Key: p.Key, Value: p.Value, Source: p.Source})
+	}
+	resources, _ := res.Template["Resources"].(map[string]any)
+	for name, v := range resources {
+		r, _ := v.(map[string]any)
+		typ, _ := r["Type"].(string)
+		resp.Resources = append(resp.Resources, types.PreviewResource{LogicalID: name, Type: typ})
+	}
+	sort.Slice(resp.Resources, func(i, j int) bool { return resp.Resources[i].LogicalID < resp.Resources[j].LogicalID })
+	for _, r := range res.Removed {
+		resp.Removed = append(resp.Removed, types.PreviewRemoved{Section: r.Section, LogicalID: r.LogicalID, Type: r.Type, Condition: r.Condition})
+	}
+	for _, w := range res.Warnings {
+		resp.Warnings = append(resp.Warnings, types.Finding{
+			Severity: validate.SeverityWarning,
+			Rule:     "removed-reference",
+			Path:     w.Path,
+			Message:  w.Message,
+		})
+	}
+
+	log.Printf("[INFO] Preview finished: account=%s region=%s resources=%d removed=%d warnings=%d",
+		body.AccountName, targetCfg.Region, len(resp.Resources), len(resp.Removed), len(resp.Warnings))
+	return httpresp.OK(200, resp), nil
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"create-stack-ms/internal/cfn"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/stackpolicy"
+	"create-stack-ms/internal/types"
+)
+
+// GetStackPolicyHandler atende GET /cf/stacks/{accountName}/{stackName}/policy.
+func GetStackPolicyHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+	cfnClient, stack, resp, errResp := s.protectedStack(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+	if errResp := readStackPolicy(ctx, cfnClient, &resp); errResp != nil {
+		return *errResp, nil
+	}
+	resp.TerminationProtection = aws.ToBool(stack.EnableTerminationProtection)
+	resp.Message = "stack protection"
+	return httpresp.OK(200, resp), nil
+}
+
+// SetStackPolicyHandler atende PUT /cf/stacks/{accountName}/{stackName}/policy.
+// O CloudFormation não permite remover uma stack policy: para liberar os
+// updates, grave uma policy com Allow Update:* em "*".
+func SetStackPolicyHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	var body types.StackPolicyRequest
+	if err := decodeBody(req, &body); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	policy, err := stackpolicy.Normalize(body.StackPolicy)
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	if policy == "" {
+		return httpresp.Error(400, errors.New("field 'stackPolicy' is required (a stack policy cannot be removed; allow Update:* on \"*\" instead)")), nil
+	}
+
+	cfnClient, stack, resp, errResp := s.protectedStack(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	log.Printf("[INFO] Calling SetStackPolicy: stackId=%s owner=%s bytes=%d", resp.StackID, s.owner, len(policy))
+	if _, err := cfnClient.SetStackPolicy(ctx, &cf.SetStackPolicyInput{
+		StackName:       aws.String(resp.StackID),
+		StackPolicyBody: aws.String(policy),
+	}); err != nil {
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("set stack policy failed: %w", err)), nil
+	}
+
+	resp.StackPolicy = json.RawMessage(policy)
+	resp.TerminationProtection = aws.ToBool(stack.EnableTerminationProtection)
+	resp.Message = "stack policy updated"
+	return httpresp.OK(200, resp), nil
+}
+
+// TerminationProtectionHandler atende
+// PUT /cf/stacks/{accountName}/{stackName}/termination-protection.
+func TerminationProtectionHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	var body types.TerminationProtectionRequest
+	if err := decodeBody(req, &body); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	if body.Enabled == nil {
+		return httpresp.Error(400, errors.New("field 'enabled' is required")), nil
+	}
+
+	cfnClient, stack, resp, errResp := s.protectedStack(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+	if stack.ParentId != nil {
+		// Nested stacks herdam a proteção da stack raiz
+		return httpresp.Error(409, fmt.Errorf("termination protection of nested stacks is set on the root stack (%s)", aws.ToString(stack.RootId))), nil
+	}
+
+	if aws.ToBool(stack.EnableTerminationProtection) != *body.Enabled {
+		log.Printf("[INFO] Calling UpdateTerminationProtection: stackId=%s enabled=%t owner=%s", resp.StackID, *body.Enabled, s.owner)
+		if _, err := cfnClient.UpdateTerminationProtection(ctx, &cf.UpdateTerminationProtectionInput{
+			StackName:                   aws.String(resp.StackID),
+			EnableTerminationProtection: body.Enabled,
+		}); err != nil {
+			return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("update termination protection failed: %w", err)), nil
+		}
+	}
+
+	if errResp := readStackPolicy(ctx, cfnClient, &resp); errResp != nil {
+		return *errResp, nil
+	}
+	resp.TerminationProtection = *body.Enabled
+	if resp.TerminationProtection {
+		resp.Message = "termination protection enabled"
+	} else {
+		resp.Message = "termination protection disabled"
+	}
+	return httpresp.OK(200, resp), nil
+}
+
+// protectedStack resolve a conta do dono e a stack do path. Stacks deletadas
+// ou de outro owner contam como inexistentes.
+func (s *session) protectedStack(ctx context.Context, req events.APIGatewayV2HTTPRequest) (*cf.Client, cft.Stack, types.StackProtectionResponse, *events.APIGatewayV2HTTPResponse) {
+	accountName := strings.TrimSpace(req.PathParameters["accountName"])
+	stackName := strings.TrimSpace(req.PathParameters["stackName"])
+	if accountName == "" || stackName == "" {
+		errResp := httpresp.Error(400, errors.New("path parameters 'accountName' and 'stackName' are required"))
+		return nil, cft.Stack{}, types.StackProtectionResponse{}, &errResp
+	}
+	log.Printf("[INFO] Stack protection request: method=%s accountName=%s stackName=%s", req.RequestContext.HTTP.Method, accountName, stackName)
+
+	targetCfg, errResp := s.targetConfig(ctx, accountName, queryRegion(req))
+	if errResp != nil {
+		return nil, cft.Stack{}, types.StackProtectionResponse{}, errResp
+	}
+	cfnClient := cf.NewFromConfig(targetCfg)
+
+	stack, err := cfn.LookupStack(ctx, cfnClient, stackName)
+	if err == nil && cfn.LifecycleOf(stack.StackStatus) == cfn.LifecycleDeleted {
+		err = cfn.ErrStackNotFound
+	}
+	if err != nil {
+		errResp := httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe stack failed: %w", err))
+		if errors.Is(err, cfn.ErrStackNotFound) {
+			errResp = httpresp.Error(404, fmt.Errorf("stack '%s' not found in account '%s'", stackName, accountName))
+		}
+		return nil, cft.Stack{}, types.StackProtectionResponse{}, &errResp
+	}
+	if errResp := s.checkOwner(ctx, stack, accountName); errResp != nil {
+		return nil, cft.Stack{}, types.StackProtectionResponse{}, errResp
+	}
+
+	return cfnClient, stack, types.StackProtectionResponse{
+		StackID:     aws.ToString(stack.StackId),
+		StackName:   aws.ToString(stack.StackName),
+		Account:     accountName,
+		Region:      targetCfg.Region,
+		Owner:       s.owner,
+		Status:      string(stack.StackStatus),
+		StackPolicy: json.RawMessage("null"),
+	}, nil
+}
+
+// readStackPolicy preenche resp.StackPolicy com a policy atual da stack.
+func readStackPolicy(ctx context.Context, api *cf.Client, resp *types.StackProtectionResponse) *events.APIGatewayV2HTTPResponse {
+	out, err := api.GetStackPolicy(ctx, &cf.GetStackPolicyInput{StackName: aws.String(resp.StackID)})
+	if err != nil {
+		errResp := httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("get stack policy failed: %w", err))
+		return &errResp
+	}
+	if body := aws.ToString(out.StackPolicyBody); json.Valid([]byte(body)) {
+		resp.StackPolicy = json.RawMessage(body)
+	}
+	return nil
+}
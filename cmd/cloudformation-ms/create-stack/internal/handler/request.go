@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	"create-stack-ms/internal/capability"
+	"create-stack-ms/internal/catalog"
+	"create-stack-ms/internal/cfn"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/params"
+	"create-stack-ms/internal/staging"
+	"create-stack-ms/internal/template"
+	"create-stack-ms/internal/types"
+)
+
+const maxTemplateBodyBytes = 51200
+
+// readBody devolve o corpo da requisição, decodificando base64 quando necessário.
+func readBody(req events.APIGatewayV2HTTPRequest) (string, error) {
+	if !req.IsBase64Encoded {
+		return req.Body, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(req.Body)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64 body: %w", err)
+	}
+	return string(b), nil
+}
+
+// queryRegion lê ?region= dos endpoints sem corpo; vazio usa a região
+// padrão da conta.
+func queryRegion(req events.APIGatewayV2HTTPRequest) string {
+	return strings.TrimSpace(req.QueryStringParameters["region"])
+}
+
+// decodeBody lê o corpo JSON da requisição em v.
+func decodeBody(req events.APIGatewayV2HTTPRequest, v any) error {
+	raw, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+// inlineTemplate devolve o template inline pronto para o CloudFormation, ou
+// nil quando a requisição usa templateUrl. Templates YAML (templateYaml, ou
+// template enviado como string) são convertidos para JSON.
+func inlineTemplate(body types.RequestBody) ([]byte, error) {
+	src, isText, err := templateText(body)
+	if err != nil {
+		return nil, err
+	}
+	if isText {
+		doc, err := template.Parse([]byte(src))
+		if err != nil {
+			return nil, fmt.Errorf("template must be valid YAML or JSON: %v", err)
+		}
+		b, err := doc.JSON()
+		if err != nil {
+			return nil, fmt.Errorf("template could not be converted to JSON: %v", err)
+		}
+		log.Printf("[INFO] Converted YAML template to JSON (source=%d bytes json=%d bytes)", len(src), len(b))
+		return b, nil
+	}
+	if len(body.Template) > 0 {
+		var tmp map[string]any
+		if err := json.Unmarshal(body.Template, &tmp); err != nil {
+			return nil, fmt.Errorf("template must be valid JSON: %v", err)
+		}
+		return body.Template, nil
+	}
+	if body.TemplateURL != "" {
+		return nil, nil
+	}
+	return nil, errors.New("either 'template', 'templateYaml', 'templateUrl' or 'templateId' is required")
+}
+
+// catalogTemplate troca templateId/version pelo conteúdo publicado no catálogo
+// e marca a stack com as tags do template. Sem templateId não faz nada.
+func (s *session) catalogTemplate(ctx context.Context, body *types.RequestBody) *events.APIGatewayV2HTTPResponse {
+	if body.TemplateID == "" {
+		if body.TemplateVersion != "" {
+			resp := httpresp.Error(400, errors.New("field 'version' requires 'templateId'"))
+			return &resp
+		}
+		return nil
+	}
+	if len(body.Template) > 0 || body.TemplateYAML != "" || body.TemplateURL != "" {
+		resp := httpresp.Error(400, errors.New("use either 'templateId' or 'template', 'templateYaml' or 'templateUrl', not both"))
+		return &resp
+	}
+	if err := catalog.ValidateID(body.TemplateID); err != nil {
+		resp := httpresp.Error(400, err)
+		return &resp
+	}
+	if body.TemplateVersion != "" {
+		if _, err := catalog.ParseSemver(body.TemplateVersion); err != nil {
+			resp := httpresp.Error(400, err)
+			return &resp
+		}
+	}
+	if s.catalog == nil {
+		resp := httpresp.Error(500, errors.New("template catalog is not configured"))
+		return &resp
+	}
+
+	v, err := s.catalog.Resolve(ctx, s.owner, body.TemplateID, body.TemplateVersion)
+	if err != nil {
+		var resp events.APIGatewayV2HTTPResponse
+		switch {
+		case errors.Is(err, catalog.ErrNotFound):
+			resp = httpresp.Error(404, fmt.Errorf("template '%s' not found in catalog (version=%q)", body.TemplateID, body.TemplateVersion))
+		case errors.Is(err, catalog.ErrDeprecated):
+			resp = httpresp.Error(409, err)
+		default:
+			resp = httpresp.Error(500, fmt.Errorf("catalog lookup failed: %w", err))
+		}
+		return &resp
+	}
+	content, err := s.catalog.Content(ctx, v)
+	if err != nil {
+		resp := httpresp.Error(500, fmt.Errorf("read catalog template failed: %w", err))
+		return &resp
+	}
+
+	body.Template = content
+	body.TemplateID = v.TemplateID
+	body.TemplateVersion = v.Version
+	log.Printf("[INFO] Using catalog template: templateId=%s version=%s hash=%s size=%d", v.TemplateID, v.Version, v.ContentHash, v.Size)
+	return nil
+}
+
+// resolveTemplate define a origem do template. Retorna o TemplateBody para
+// templates inline ou nil quando o CloudFormation deve ler body.TemplateURL.
+// Templates acima do limite inline são publicados no bucket de staging
+// (quando configurado) e seguem por URL, de forma transparente.
+func (s *session) resolveTemplate(ctx context.Context, body *types.RequestBody) (*string, *events.APIGatewayV2HTTPResponse) {
+	b, err := inlineTemplate(*body)
+	if err != nil {
+		resp := httpresp.Error(400, err)
+		return nil, &resp
+	}
+	if b == nil {
+		log.Printf("[INFO] Using template URL: %s", body.TemplateURL)
+		return nil, nil
+	}
+	if len(b) <= maxTemplateBodyBytes {
+		str := string(b)
+		log.Printf("[INFO] Using inline template (size=%d bytes)", len(str))
+		return &str, nil
+	}
+
+	if s.stager == nil {
+		resp := httpresp.Error(400, fmt.Errorf("template exceeds 51,200 bytes (size=%d, use templateUrl instead)", len(b)))
+		return nil, &resp
+	}
+	url, err := s.stager.Stage(ctx, s.owner, b)
+	if err != nil {
+		if errors.Is(err, staging.ErrTooLarge) {
+			resp := httpresp.Error(400, err)
+			return nil, &resp
+		}
+		resp := httpresp.Error(500, fmt.Errorf("stage template failed: %w", err))
+		return nil, &resp
+	}
+	body.TemplateURL = url
+	log.Printf("[INFO] Using staged template (size=%d bytes)", len(b))
+	return nil, nil
+}
+
+// resolveParameters valida os parâmetros da requisição contra os declarados no
+// template e resolve os do tipo SSM na conta alvo. Devolve a lista para o
+// CloudFormation e a versão mascarada (NoEcho) para log e resposta.
+func resolveParameters(ctx context.Context, targetCfg aws.Config, cfnClient *cf.Client, body types.RequestBody, templateBody *string, allowPrevious bool) ([]cft.Parameter, []types.Parameter, *events.APIGatewayV2HTTPResponse) {
+	cfParams, visible, status, err := loadParameters(ctx, targetCfg, cfnClient, body, templateBody, allowPrevious)
+	if err != nil {
+		resp := httpresp.Error(status, err)
+		return nil, nil, &resp
+	}
+	return cfParams, visible, nil
+}
+
+// loadParameters é o resolveParameters para quem trata o erro sem responder
+// HTTP (ex. fan-out por conta): devolve o status sugerido junto com o erro.
+func loadParameters(ctx context.Context, targetCfg aws.Config, cfnClient *cf.Client, body types.RequestBody, templateBody *string, allowPrevious bool) ([]cft.Parameter, []types.Parameter, int, error) {
+	specs, err := params.Declared(ctx, cfnClient, templateBody, body.TemplateURL)
+	if err != nil {
+		return nil, nil, cfn.HTTPStatus(err), fmt.Errorf("get template summary failed: %w", err)
+	}
+
+	cfParams, err := params.Build(body.Parameters, specs, allowPrevious)
+	if err != nil {
+		return nil, nil, 400, err
+	}
+
+	visible := params.Masked(body.Parameters, specs)
+	if err := params.ResolveSSM(ctx, ssm.NewFromConfig(targetCfg), visible, specs); err != nil {
+		return nil, nil, 400, err
+	}
+	log.Printf("[INFO] Parameters: declared=%d given=%d values=[%s]", len(specs), len(cfParams), params.Summary(body.Parameters, specs))
+	return cfParams, visible, 0, nil
+}
+
+// templateText devolve o texto do template quando ele veio como texto
+// (templateYaml ou template string) em vez de objeto JSON.
+func templateText(body types.RequestBody) (string, bool, error) {
+	isString := len(body.Template) > 0 && body.Template[0] == '"'
+	if body.TemplateYAML != "" {
+		if len(body.Template) > 0 {
+			return "", false, errors.New("use either 'template' or 'templateYaml', not both")
+		}
+		return body.TemplateYAML, true, nil
+	}
+	if !isString {
+		return "", false, nil
+	}
+	var src string
+	if err := json.Unmarshal(body.Template, &src); err != nil {
+		return "", false, fmt.Errorf("invalid template string: %v", err)
+	}
+	return src, true, nil
+}
+
+// resolveCapabilities valida as capabilities pedidas e as compara com as
+// exigidas pelo template inline (templates por URL ficam a cargo do
+// CloudFormation). As que faltam são acrescentadas quando o owner permite;
+// senão a requisição falha com a lista de recursos que as exigem.
+func (s *session) resolveCapabilities(body types.RequestBody) ([]cft.Capability, *events.APIGatewayV2HTTPResponse) {
+	caps, err := cfn.Capabilities(body.Capabilities)
+	if err != nil {
+		resp := httpresp.Error(400, err)
+		return nil, &resp
+	}
+
+	raw, err := inlineTemplate(body)
+	if err != nil || raw == nil {
+		return caps, nil
+	}
+	var tpl map[string]any
+	if err := json.Unmarshal(raw, &tpl); err != nil {
+		return caps, nil
+	}
+
+	missing := capability.Missing(capability.Required(tpl), caps)
+	if len(missing) == 0 {
+		return caps, nil
+	}
+	reasons := make([]string, 0, len(missing))
+	for _, r := range missing {
+		reasons = append(reasons, r.String())
+	}
+	if !s.autoCapabilities {
+		resp := httpresp.Error(400, fmt.Errorf("template requires capabilities that were not granted: %s", strings.Join(reasons, "; ")))
+		return nil, &resp
+	}
+	caps = capability.Add(caps, missing)
+	log.Printf("[INFO] Capabilities added automatically: caps=%v reasons=[%s]", caps, strings.Join(reasons, "; "))
+	return caps, nil
+}
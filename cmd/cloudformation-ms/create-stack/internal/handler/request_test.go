@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"create-stack-ms/internal/staging"
+	"create-stack-ms/internal/types"
+)
+
+// templateOfSize monta um template JSON válido com exatamente n bytes.
+func templateOfSize(t *testing.T, n int) json.RawMessage {
+	t.Helper()
+	head, tail := `{"Description":"`, `","Resources":{}}`
+	pad := n - len(head) - len(tail)
+	if pad < 0 {
+		t.Fatalf("size %d is too small", n)
+	}
+	return json.RawMessage(head + strings.Repeat("a", pad) + tail)
+}
+
+func TestResolveTemplateThreshold(t *testing.T) {
+	ctx := context.Background()
+	store := staging.NewMemoryStore()
+	s := &session{owner: "alice", stager: staging.New(store, "templates")}
+
+	body := types.RequestBody{Template: templateOfSize(t, maxTemplateBodyBytes)}
+	inline, errResp := s.resolveTemplate(ctx, &body)
+	if errResp != nil {
+		t.Fatalf("template at the limit: %s", errResp.Body)
+	}
+	if inline == nil || len(*inline) != maxTemplateBodyBytes || store.Puts != 0 {
+		t.Fatalf("template at the limit must go inline (puts=%d)", store.Puts)
+	}
+
+	body = types.RequestBody{Template: templateOfSize(t, maxTemplateBodyBytes+1)}
+	inline, errResp = s.resolveTemplate(ctx, &body)
+	if errResp != nil {
+		t.Fatalf("template above the limit: %s", errResp.Body)
+	}
+	if inline != nil || store.Puts != 1 {
+		t.Fatalf("template above the limit must be staged (puts=%d)", store.Puts)
+	}
+	if !strings.HasPrefix(body.TemplateURL, "https://staging.local/templates/alice/") {
+		t.Fatalf("unexpected template url %q", body.TemplateURL)
+	}
+}
+
+func TestResolveTemplateWithoutStager(t *testing.T) {
+	s := &session{owner: "alice"}
+	body := types.RequestBody{Template: templateOfSize(t, maxTemplateBodyBytes+1)}
+	_, errResp := s.resolveTemplate(context.Background(), &body)
+	if errResp == nil || errResp.StatusCode != 400 {
+		t.Fatalf("expected 400 without a staging bucket, got %+v", errResp)
+	}
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/scan"
+	"create-stack-ms/internal/template"
+	"create-stack-ms/internal/types"
+)
+
+// ScanHandler atende POST /cf/scan: roda o scanner de segurança sobre o
+// template, sem falar com nenhuma conta. Findings voltam com 200, em JSON ou
+// em SARIF (format=sarif) para ferramentas de revisão de código.
+func ScanHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	var body types.ScanRequest
+	if err := decodeBody(req, &body); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	format := strings.ToLower(strings.TrimSpace(body.Format))
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "sarif" {
+		return httpresp.Error(400, errors.New("field 'format' must be 'json' or 'sarif'")), nil
+	}
+	if len(body.Template) == 0 && body.TemplateYAML == "" && body.TemplateID == "" {
+		return httpresp.Error(400, errors.New("either 'template', 'templateYaml' or 'templateId' is required")), nil
+	}
+
+	tplBody := types.RequestBody{
+		Template:        body.Template,
+		TemplateYAML:    body.TemplateYAML,
+		TemplateID:      body.TemplateID,
+		TemplateVersion: body.TemplateVersion,
+	}
+	if errResp := s.catalogTemplate(ctx, &tplBody); errResp != nil {
+		return *errResp, nil
+	}
+
+	// Linhas só fazem sentido para templates enviados como texto
+	var line func(string) int
+	src, isText, err := templateText(tplBody)
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	if isText {
+		if doc, err := template.Parse([]byte(src)); err == nil {
+			line = doc.Line
+		}
+	}
+
+	raw, err := inlineTemplate(tplBody)
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	var tpl map[string]any
+	if err := json.Unmarshal(raw, &tpl); err != nil {
+		return httpresp.Error(400, fmt.Errorf("template must be valid JSON: %v", err)), nil
+	}
+	findings := scan.Template(tpl)
+	log.Printf("[INFO] Security scan finished: format=%s findings=%d high=%t", format, len(findings), scan.HasHigh(findings))
+
+	if format == "sarif" {
+		uri := body.ArtifactURI
+		if uri == "" {
+			uri = "template.json"
+			if isText {
+				uri = "template.yaml"
+			}
+		}
+		resp := httpresp.OK(200, scan.SARIF(findings, uri, line))
+		resp.Headers["Content-Type"] = "application/sarif+json"
+		return resp, nil
+	}
+
+	resp := types.ScanResponse{
+		Passed:          !scan.HasHigh(findings),
+		Findings:        scanFindings(findings, line),
+		TemplateID:      tplBody.TemplateID,
+		TemplateVersion: tplBody.TemplateVersion,
+	}
+	for _, f := range findings {
+		switch f.Severity {
+		case scan.SeverityHigh:
+			resp.Summary.High++
+		case scan.SeverityMedium:
+			resp.Summary.Medium++
+		default:
+			resp.Summary.Low++
+		}
+	}
+	return httpresp.OK(200, resp), nil
+}
+
+// securityScan é o scan automático (SECURITY_SCAN) do create-stack, dos
+// change sets de criação e dos stack sets novos. Em modo warn os findings
+// voltam para a resposta; em modo enforce findings high viram um
+// *scan.BlockedError com status 400.
+func securityScan(body types.RequestBody) ([]types.ScanFinding, int, error) {
+	mode, err := scan.ModeFromEnv()
+	if err != nil {
+		return nil, 500, err
+	}
+	if mode == scan.ModeOff {
+		return nil, 0, nil
+	}
+
+	raw, err := inlineTemplate(body)
+	if err != nil {
+		return nil, 400, err
+	}
+	if raw == nil {
+		if mode == scan.ModeEnforce {
+			return nil, 400, errors.New("security scan needs the template: use 'template', 'templateYaml' or 'templateId' instead of 'templateUrl'")
+		}
+		log.Printf("[WARN] Security scan skipped: template sent by URL")
+		return nil, 0, nil
+	}
+	var tpl map[string]any
+	if err := json.Unmarshal(raw, &tpl); err != nil {
+		return nil, 400, fmt.Errorf("template must be valid JSON: %v", err)
+	}
+
+	findings := scan.Template(tpl)
+	log.Printf("[INFO] Security scan: mode=%s findings=%d high=%t", mode, len(findings), scan.HasHigh(findings))
+	if mode == scan.ModeEnforce && scan.HasHigh(findings) {
+		return nil, 400, &scan.BlockedError{Findings: findings}
+	}
+	return scanFindings(findings, nil), 0, nil
+}
+
+func scanFindings(fs []scan.Finding, line func(string) int) []types.ScanFinding {
+	out := make([]types.ScanFinding, 0, len(fs))
+	for _, f := range fs {
+		sf := types.ScanFinding{
+			RuleID:       f.RuleID,
+			Severity:     f.Severity,
+			LogicalID:    f.LogicalID,
+			ResourceType: f.ResourceType,
+			Path:         f.Path,
+			Message:      f.Message,
+		}
+		if line != nil {
+			sf.Line = line(f.Path)
+		}
+		out = append(out, sf)
+	}
+	return out
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cip "github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	sm "github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"create-stack-ms/internal/auth"
+	"create-stack-ms/internal/awsconfig"
+	"create-stack-ms/internal/catalog"
+	"create-stack-ms/internal/credentials"
+	"create-stack-ms/internal/drift"
+	"create-stack-ms/internal/guardrail"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/inventory"
+	"create-stack-ms/internal/staging"
+	"create-stack-ms/internal/tracker"
+	"create-stack-ms/internal/types"
+)
+
+type deps struct {
+	cip *cip.Client
+	sm  *sm.Client
+}
+
+func (d *deps) Cognito() *cip.Client { return d.cip }
+
+// session guarda o que todo endpoint precisa antes de falar com a conta alvo:
+// config base da Lambda, clientes compartilhados e o owner autenticado.
+type session struct {
+	cfg    aws.Config
+	deps   *deps
+	owner  string
+	stager *staging.Stager // nil quando TEMPLATE_STAGING_BUCKET não está definido
+	drifts drift.Store     // nil quando DRIFT_TABLE_NAME não está definido
+	queue  tracker.Queue   // nil quando TRACKER_QUEUE_URL não está definido
+
+	inventory inventory.Store  // nil quando DEPLOYMENTS_TABLE_NAME não está definido
+	catalog   *catalog.Catalog // nil sem TEMPLATE_CATALOG_TABLE ou TEMPLATE_STAGING_BUCKET
+	requestID string
+
+	guardrails *guardrail.Registry // nil quando GUARDRAILS_TABLE_NAME não está definido
+
+	// autoCapabilities vem do atributo custom:auto_capabilities do owner e
+	// permite acrescentar as capabilities detectadas no template.
+	autoCapabilities bool
+}
+
+func newSession(ctx context.Context, req events.APIGatewayV2HTTPRequest) (*session, *events.APIGatewayV2HTTPResponse) {
+	log.Printf("[INFO] Incoming request: reqId=%s method=%s path=%s",
+		req.RequestContext.RequestID, req.RequestContext.HTTP.Method, req.RawPath)
+
+	// ---- SDK base ----
+	cfg, err := awsconfig.Base(ctx)
+	if err != nil {
+		resp := httpresp.Error(500, fmt.Errorf("aws config error: %w", err))
+		return nil, &resp
+	}
+
+	d := &deps{
+		cip: cip.NewFromConfig(cfg),
+		sm:  sm.NewFromConfig(cfg),
+	}
+
+	owner, err := auth.OwnerFromRequest(ctx, req, cfg, d)
+	if err != nil || owner == "" {
+		resp := httpresp.Error(401, errors.New("unauthorized"))
+		return nil, &resp
+	}
+	log.Printf("[INFO] Authenticated owner=%s", owner)
+
+	s := ownerSession(cfg, d, owner)
+	s.requestID = req.RequestContext.RequestID
+	s.autoCapabilities = strings.EqualFold(auth.Claim(req, "custom:auto_capabilities"), "true")
+	return s, nil
+}
+
+// ownerSession monta a sessão de um owner já conhecido, com os recursos
+// opcionais configurados por variável de ambiente.
+func ownerSession(cfg aws.Config, d *deps, owner string) *session {
+	s := &session{cfg: cfg, deps: d, owner: owner}
+	if bucket := os.Getenv("TEMPLATE_STAGING_BUCKET"); bucket != "" {
+		store := staging.NewS3Store(s3.NewFromConfig(cfg), bucket)
+		s.stager = staging.New(store, "templates")
+		// O catálogo usa outro prefixo: o de staging expira
+		if table := os.Getenv("TEMPLATE_CATALOG_TABLE"); table != "" {
+			s.catalog = catalog.New(catalog.NewDynamoStore(dynamodb.NewFromConfig(cfg), table), staging.New(store, "catalog"))
+		}
+	}
+	if table := os.Getenv("DRIFT_TABLE_NAME"); table != "" {
+		s.drifts = drift.NewDynamoStore(dynamodb.NewFromConfig(cfg), table)
+	}
+	if url := os.Getenv("TRACKER_QUEUE_URL"); url != "" {
+		s.queue = tracker.NewSQSQueue(sqs.NewFromConfig(cfg), url)
+	}
+	if table := os.Getenv("DEPLOYMENTS_TABLE_NAME"); table != "" {
+		s.inventory = inventory.NewDynamoStore(dynamodb.NewFromConfig(cfg), table)
+	}
+	if table := os.Getenv("GUARDRAILS_TABLE_NAME"); table != "" {
+		s.guardrails = guardrail.NewRegistry(guardrail.NewDynamoStore(dynamodb.NewFromConfig(cfg), table))
+	}
+	return s
+}
+
+// targetConfig resolve owner -> secret -> config da conta alvo na região
+// pedida (vazia = região padrão da conta).
+func (s *session) targetConfig(ctx context.Context, accountName, region string) (aws.Config, *events.APIGatewayV2HTTPResponse) {
+	targetCfg, _, errResp := s.target(ctx, accountName, region)
+	return targetCfg, errResp
+}
+
+// target é o targetConfig que devolve também o id da conta AWS alvo.
+func (s *session) target(ctx context.Context, accountName, region string) (aws.Config, string, *events.APIGatewayV2HTTPResponse) {
+	targetCfg, accountID, status, err := s.loadTarget(ctx, accountName, region)
+	if err != nil {
+		resp := httpresp.Error(status, err)
+		return aws.Config{}, "", &resp
+	}
+	return targetCfg, accountID, nil
+}
+
+// loadTargetConfig é o targetConfig para quem não responde HTTP (ex. jobs
+// agendados): devolve o status sugerido junto com o erro.
+func (s *session) loadTargetConfig(ctx context.Context, accountName, region string) (aws.Config, int, error) {
+	targetCfg, _, status, err := s.loadTarget(ctx, accountName, region)
+	return targetCfg, status, err
+}
+
+func (s *session) loadTarget(ctx context.Context, accountName, region string) (aws.Config, string, int, error) {
+	keys, err := s.accountKeys(ctx, accountName)
+	if err != nil {
+		return aws.Config{}, "", 404, err
+	}
+	region, status, err := s.resolveRegion(accountName, keys, region)
+	if err != nil {
+		return aws.Config{}, "", status, err
+	}
+	return s.buildTargetConfig(ctx, accountName, keys, region)
+}
+
+// resolveRegion valida a região pedida contra as regiões da conta; vazia
+// vira a região padrão da conta (ou a da Lambda, para contas antigas).
+func (s *session) resolveRegion(accountName string, keys types.SecretKeys, region string) (string, int, error) {
+	region, err := credentials.ResolveRegion(keys, region, s.cfg.Region)
+	if errors.Is(err, credentials.ErrRegionNotAllowed) {
+		return "", 403, fmt.Errorf("account '%s': %w", accountName, err)
+	}
+	if err != nil {
+		return "", 400, err
+	}
+	return region, 0, nil
+}
+
+// accountKeys lê as credenciais (e regiões) cadastradas da conta.
+func (s *session) accountKeys(ctx context.Context, accountName string) (types.SecretKeys, error) {
+	// ---- Secrets Manager: credenciais da conta alvo ----
+	secretName := fmt.Sprintf("%s/%s/access_keys", s.owner, accountName)
+	log.Printf("[INFO] Fetching credentials from secret: %s", secretName)
+
+	keys, err := credentials.GetAccountCreds(ctx, s.deps.sm, secretName)
+	if err != nil {
+		return types.SecretKeys{}, fmt.Errorf("failed to get credentials from secrets manager: %w", err)
+	}
+	return keys, nil
+}
+
+// buildTargetConfig devolve a config da conta alvo e o id da conta AWS
+// validado pelo STS.
+func (s *session) buildTargetConfig(ctx context.Context, accountName string, keys types.SecretKeys, region string) (aws.Config, string, int, error) {
+	// ---- Config alvo (credenciais / assume role / sts check) ----
+	targetCfg, accountID, err := credentials.BuildTargetConfig(ctx, s.cfg, keys, region)
+	if err != nil {
+		return aws.Config{}, "", 401, fmt.Errorf("invalid credentials for account '%s' in region '%s': %w", accountName, region, err)
+	}
+	return targetCfg, accountID, 0, nil
+}
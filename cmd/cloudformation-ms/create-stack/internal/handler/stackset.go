@@ -0,0 +1,427 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	sm "github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+
+	"create-stack-ms/internal/cfn"
+	"create-stack-ms/internal/credentials"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/types"
+)
+
+const (
+	instancesOperationSuffix = "-instances"
+	maxOperationIDLength     = 128
+
+	accountIDWorkers  = 4
+	accountIDCacheTTL = 15 * time.Minute
+)
+
+// StackSetHandler atende POST /cf/stack-sets: cria o stack set (modelo
+// SELF_MANAGED) na conta administradora, se ainda não existir, e adiciona
+// instâncias para as contas cadastradas x regiões pedidas. Não aguarda o
+// rollout: o acompanhamento é feito por GET /cf/stack-sets/{accountName}/{stackSetName}.
+func StackSetHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	var body types.StackSetRequest
+	if err := decodeBody(req, &body); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	log.Printf("[INFO] StackSet request: adminAccount=%s stackSetName=%s accounts=%v regions=%v templateInline=%t templateUrlSet=%t",
+		body.AccountName, body.StackSetName, body.AccountNames, body.Regions,
+		len(body.Template) > 0 || body.TemplateYAML != "", body.TemplateURL != "")
+
+	if body.AccountName == "" || body.StackSetName == "" {
+		return httpresp.Error(400, errors.New("fields 'accountName' and 'stackSetName' are required")), nil
+	}
+	accountNames := uniqueNonEmpty(body.AccountNames)
+	regions := uniqueNonEmpty(body.Regions)
+	if len(accountNames) == 0 || len(regions) == 0 {
+		return httpresp.Error(400, errors.New("fields 'accountNames' and 'regions' must not be empty")), nil
+	}
+	if len(body.StackPolicy) > 0 || body.EnableTerminationProtection != nil {
+		return httpresp.Error(400, errors.New("fields 'stackPolicy' and 'enableTerminationProtection' are not supported for stack sets")), nil
+	}
+	if body.RollbackConfiguration != nil || len(body.NotificationARNs) > 0 {
+		// Alarmes e tópicos são por conta/região; use as preferences do rollout
+		return httpresp.Error(400, errors.New("fields 'rollbackConfiguration' and 'notificationArns' are not supported for stack sets")), nil
+	}
+	prefs, err := cfn.OperationPreferences(body.Preferences, regions)
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+
+	adminCfg, errResp := s.targetConfig(ctx, body.AccountName, body.Region)
+	if errResp != nil {
+		return *errResp, nil
+	}
+	cfnClient := cf.NewFromConfig(adminCfg)
+
+	resp := types.StackSetResponse{
+		StackSetName: body.StackSetName,
+		Account:      body.AccountName,
+		Owner:        s.owner,
+		Instances:    []types.StackInstance{},
+	}
+
+	stackSet, err := cfn.LookupStackSet(ctx, cfnClient, body.StackSetName)
+	exists := err == nil
+	switch {
+	case errors.Is(err, cfn.ErrStackSetNotFound):
+	case err != nil:
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe stack set failed: %w", err)), nil
+	case len(body.Template) > 0 || body.TemplateYAML != "" || body.TemplateURL != "" || body.TemplateID != "":
+		return httpresp.Error(409, fmt.Errorf("stack set '%s' already exists (omit the template to only add instances)", body.StackSetName)), nil
+	}
+
+	// ---- Contas alvo: nome cadastrado -> id AWS; as regiões das instâncias
+	// precisam estar liberadas em cada conta ----
+	names := map[string]string{}
+	ids := make([]string, 0, len(accountNames))
+	for _, name := range accountNames {
+		keys, err := s.accountKeys(ctx, name)
+		if err != nil {
+			return httpresp.Error(404, err), nil
+		}
+		for _, region := range regions {
+			if _, status, err := s.resolveRegion(name, keys, region); err != nil {
+				return httpresp.Error(status, err), nil
+			}
+		}
+		_, id, status, err := s.buildTargetConfig(ctx, name, keys, credentials.DefaultRegion(keys, s.cfg.Region))
+		if err != nil {
+			return httpresp.Error(status, err), nil
+		}
+		if _, dup := names[id]; !dup {
+			ids = append(ids, id)
+		}
+		names[id] = name
+	}
+
+	// ---- Guardrails: o template é avaliado para cada conta x região alvo
+	// antes de criar o stack set ou as instâncias ----
+	var guarded types.RequestBody
+	if exists {
+		guarded = types.RequestBody{TemplateYAML: aws.ToString(stackSet.TemplateBody), Parameters: stackSetParameters(stackSet.Parameters)}
+	} else {
+		if errResp := s.catalogTemplate(ctx, &body.RequestBody); errResp != nil {
+			return *errResp, nil
+		}
+		findings, status, err := securityScan(body.RequestBody)
+		if err != nil {
+			return policyError(status, err), nil
+		}
+		resp.SecurityFindings = findings
+		guarded = body.RequestBody
+	}
+	warnings, status, err := s.stackSetGuardrails(ctx, guarded, accountNames, regions)
+	if err != nil {
+		return policyError(status, err), nil
+	}
+	resp.GuardrailWarnings = warnings
+
+	if exists {
+		resp.StackSetID = aws.ToString(stackSet.StackSetId)
+		resp.Status = string(stackSet.Status)
+		log.Printf("[INFO] Using existing stack set: stackSetId=%s", resp.StackSetID)
+	} else {
+		id, visible, caps, errResp := s.createStackSet(ctx, adminCfg, cfnClient, &body)
+		if errResp != nil {
+			return *errResp, nil
+		}
+		resp.StackSetID = id
+		resp.Status = string(cft.StackSetStatusActive)
+		resp.Parameters = visible
+		resp.Capabilities = cfn.CapabilityNames(caps)
+	}
+
+	in := &cf.CreateStackInstancesInput{
+		StackSetName:         aws.String(body.StackSetName),
+		Accounts:             ids,
+		Regions:              regions,
+		OperationPreferences: prefs,
+	}
+	if body.ClientRequestToken != "" {
+		in.OperationId = aws.String(instancesOperationID(body.ClientRequestToken))
+	}
+	log.Printf("[INFO] Calling CreateStackInstances: stackSetName=%s accounts=%d regions=%v concurrency=%s",
+		body.StackSetName, len(ids), regions, prefs.RegionConcurrencyType)
+	out, err := cfnClient.CreateStackInstances(ctx, in)
+	if err != nil {
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("create stack instances failed: %w", err)), nil
+	}
+	resp.OperationID = aws.ToString(out.OperationId)
+	resp.OperationStatus = string(cft.StackSetOperationStatusRunning)
+	resp.Message = "stack instances rollout started"
+
+	if resp.Instances, err = cfn.StackInstances(ctx, cfnClient, body.StackSetName, names); err != nil {
+		log.Printf("[WARN] Could not list stack instances: %v", err)
+		resp.Instances = []types.StackInstance{}
+	}
+	return httpresp.OK(202, resp), nil
+}
+
+// stackSetGuardrails avalia os guardrails como em create-stack, uma vez por
+// conta x região alvo. Qualquer par em modo enforce com violação bloqueia o
+// stack set inteiro; os avisos voltam sem repetição.
+func (s *session) stackSetGuardrails(ctx context.Context, body types.RequestBody, accountNames, regions []string) ([]types.GuardrailViolation, int, error) {
+	var out []types.GuardrailViolation
+	seen := map[types.GuardrailViolation]bool{}
+	for _, name := range accountNames {
+		for _, region := range regions {
+			warnings, status, err := s.checkGuardrails(ctx, body, name, region)
+			if err != nil {
+				return nil, status, fmt.Errorf("account '%s' region '%s': %w", name, region, err)
+			}
+			for _, w := range warnings {
+				if !seen[w] {
+					seen[w] = true
+					out = append(out, w)
+				}
+			}
+		}
+	}
+	return out, 0, nil
+}
+
+// stackSetParameters devolve os parâmetros gravados no stack set (NoEcho já
+// vem como "****").
+func stackSetParameters(in []cft.Parameter) types.Parameters {
+	out := make(types.Parameters, 0, len(in))
+	for _, p := range in {
+		out = append(out, types.Parameter{Key: aws.ToString(p.ParameterKey), Value: aws.ToString(p.ParameterValue)})
+	}
+	return out
+}
+
+// createStackSet cria o stack set com o template, parâmetros e capabilities
+// da requisição, validados como em create-stack. O templateId do catálogo já
+// foi resolvido pelo handler.
+func (s *session) createStackSet(ctx context.Context, adminCfg aws.Config, cfnClient *cf.Client, body *types.StackSetRequest) (string, []types.Parameter, []cft.Capability, *events.APIGatewayV2HTTPResponse) {
+	templateBody, errResp := s.resolveTemplate(ctx, &body.RequestBody)
+	if errResp != nil {
+		return "", nil, nil, errResp
+	}
+	caps, errResp := s.resolveCapabilities(body.RequestBody)
+	if errResp != nil {
+		return "", nil, nil, errResp
+	}
+	cfParams, visible, errResp := resolveParameters(ctx, adminCfg, cfnClient, body.RequestBody, templateBody, false)
+	if errResp != nil {
+		return "", nil, nil, errResp
+	}
+	tags, status, err := s.stackTags(body.RequestBody, "")
+	if err != nil {
+		resp := policyError(status, err)
+		return "", nil, nil, &resp
+	}
+
+	in := &cf.CreateStackSetInput{
+		StackSetName:    aws.String(body.StackSetName),
+		PermissionModel: cft.PermissionModelsSelfManaged,
+		Capabilities:    caps,
+		Tags:            tags,
+		Parameters:      cfParams,
+	}
+	if templateBody != nil {
+		in.TemplateBody = templateBody
+	} else {
+		in.TemplateURL = aws.String(body.TemplateURL)
+	}
+	if body.Description != "" {
+		in.Description = aws.String(body.Description)
+	}
+	if body.AdministrationRoleARN != "" {
+		in.AdministrationRoleARN = aws.String(body.AdministrationRoleARN)
+	}
+	if body.ExecutionRoleName != "" {
+		in.ExecutionRoleName = aws.String(body.ExecutionRoleName)
+	}
+	if body.ClientRequestToken != "" {
+		in.ClientRequestToken = aws.String(body.ClientRequestToken)
+	}
+
+	log.Printf("[INFO] Calling CreateStackSet: stackSetName=%s caps=%v params=%d tags=%d", body.StackSetName, caps, len(cfParams), len(in.Tags))
+	out, err := cfnClient.CreateStackSet(ctx, in)
+	if err != nil {
+		resp := httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("create stack set failed: %w", err))
+		return "", nil, nil, &resp
+	}
+	log.Printf("[INFO] Stack set created: stackSetId=%s", aws.ToString(out.StackSetId))
+	return aws.ToString(out.StackSetId), visible, caps, nil
+}
+
+// StackSetStatusHandler atende GET /cf/stack-sets/{accountName}/{stackSetName}.
+// Com ?operationId= inclui o status da operação (ex. a devolvida pelo POST).
+func StackSetStatusHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	accountName := strings.TrimSpace(req.PathParameters["accountName"])
+	stackSetName := strings.TrimSpace(req.PathParameters["stackSetName"])
+	if accountName == "" || stackSetName == "" {
+		return httpresp.Error(400, errors.New("path parameters 'accountName' and 'stackSetName' are required")), nil
+	}
+	operationID := strings.TrimSpace(req.QueryStringParameters["operationId"])
+
+	adminCfg, errResp := s.targetConfig(ctx, accountName, queryRegion(req))
+	if errResp != nil {
+		return *errResp, nil
+	}
+	cfnClient := cf.NewFromConfig(adminCfg)
+
+	stackSet, err := cfn.LookupStackSet(ctx, cfnClient, stackSetName)
+	if err != nil {
+		if errors.Is(err, cfn.ErrStackSetNotFound) {
+			return httpresp.Error(404, fmt.Errorf("stack set '%s' not found in account '%s'", stackSetName, accountName)), nil
+		}
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe stack set failed: %w", err)), nil
+	}
+
+	resp := types.StackSetResponse{
+		Message:      "stack set status",
+		StackSetName: aws.ToString(stackSet.StackSetName),
+		StackSetID:   aws.ToString(stackSet.StackSetId),
+		Status:       string(stackSet.Status),
+		Account:      accountName,
+		Owner:        s.owner,
+		Capabilities: cfn.CapabilityNames(stackSet.Capabilities),
+	}
+
+	if operationID != "" {
+		op, err := cfnClient.DescribeStackSetOperation(ctx, &cf.DescribeStackSetOperationInput{
+			StackSetName: aws.String(stackSetName),
+			OperationId:  aws.String(operationID),
+		})
+		if err != nil {
+			return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("describe stack set operation failed: %w", err)), nil
+		}
+		if op.StackSetOperation != nil {
+			resp.OperationID = operationID
+			resp.OperationStatus = string(op.StackSetOperation.Status)
+		}
+	}
+
+	if resp.Instances, err = cfn.StackInstances(ctx, cfnClient, stackSetName, s.knownAccountIDs(ctx)); err != nil {
+		return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("list stack instances failed: %w", err)), nil
+	}
+	log.Printf("[INFO] StackSet status: stackSetName=%s status=%s operation=%s/%s instances=%d",
+		stackSetName, resp.Status, operationID, resp.OperationStatus, len(resp.Instances))
+	return httpresp.OK(200, resp), nil
+}
+
+// instancesOperationID deriva o OperationId de CreateStackInstances do
+// ClientRequestToken, que já é usado por CreateStackSet: retentativas
+// continuam idempotentes sem reaproveitar o mesmo valor nas duas chamadas.
+func instancesOperationID(token string) string {
+	if limit := maxOperationIDLength - len(instancesOperationSuffix); len(token) > limit {
+		token = token[:limit]
+	}
+	return token + instancesOperationSuffix
+}
+
+// accountIDs guarda o id AWS de cada conta cadastrada entre invocações da
+// mesma instância da Lambda, para não repetir a chamada ao STS a cada GET.
+var accountIDs = struct {
+	sync.Mutex
+	entries map[string]accountIDEntry // chave <owner>/<account>
+}{entries: map[string]accountIDEntry{}}
+
+type accountIDEntry struct {
+	id      string
+	expires time.Time
+}
+
+// knownAccountIDs mapeia id AWS -> nome para as contas cadastradas pelo
+// owner. É só para exibição: contas que falham são ignoradas. Ids fora do
+// cache são resolvidos por até accountIDWorkers chamadas em paralelo.
+func (s *session) knownAccountIDs(ctx context.Context) map[string]string {
+	out := map[string]string{}
+	var pending []string
+	now := time.Now()
+	p := sm.NewListSecretsPaginator(s.deps.sm, &sm.ListSecretsInput{
+		Filters: []smtypes.Filter{{Key: smtypes.FilterNameStringTypeName, Values: []string{s.owner + "/"}}},
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			log.Printf("[WARN] Could not list registered accounts: %v", err)
+			return out
+		}
+		accountIDs.Lock()
+		for _, sec := range page.SecretList {
+			parts := strings.Split(aws.ToString(sec.Name), "/")
+			if len(parts) != 3 || parts[0] != s.owner || parts[2] != "access_keys" {
+				continue
+			}
+			if e, ok := accountIDs.entries[s.owner+"/"+parts[1]]; ok && now.Before(e.expires) {
+				out[e.id] = parts[1]
+				continue
+			}
+			pending = append(pending, parts[1])
+		}
+		accountIDs.Unlock()
+	}
+
+	var mu sync.Mutex
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < accountIDWorkers && w < len(pending); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				_, id, _, err := s.loadTarget(ctx, name, "")
+				if err != nil {
+					continue
+				}
+				accountIDs.Lock()
+				accountIDs.entries[s.owner+"/"+name] = accountIDEntry{id: id, expires: time.Now().Add(accountIDCacheTTL)}
+				accountIDs.Unlock()
+				mu.Lock()
+				out[id] = name
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, name := range pending {
+		jobs <- name
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
+
+// uniqueNonEmpty remove vazios e repetidos, mantendo a ordem.
+func uniqueNonEmpty(vals []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, v := range vals {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"create-stack-ms/internal/catalog"
+	"create-stack-ms/internal/cfn"
+	"create-stack-ms/internal/guardrail"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/lint"
+	"create-stack-ms/internal/scan"
+	"create-stack-ms/internal/tagpolicy"
+	"create-stack-ms/internal/types"
+)
+
+// platformTags são as tags que a plataforma grava em toda stack. accountName
+// vazio omite cloudbuilder:account (stack sets, que cobrem várias contas).
+func (s *session) platformTags(body types.RequestBody, accountName string) map[string]string {
+	tags := map[string]string{
+		tagpolicy.TagOwner:     s.owner,
+		tagpolicy.TagAccount:   accountName,
+		tagpolicy.TagRequestID: s.requestID,
+	}
+	if body.TemplateID != "" {
+		tags[catalog.TagTemplateID] = body.TemplateID
+		tags[catalog.TagTemplateVersion] = body.TemplateVersion
+	}
+	return tags
+}
+
+// stackTags aplica a tag policy às tags do cliente e acrescenta as da
+// plataforma. Devolve 400 para violações da policy e 500 quando a própria
+// policy (TAG_POLICY) é inválida.
+func (s *session) stackTags(body types.RequestBody, accountName string) ([]cft.Tag, int, error) {
+	policy, err := tagpolicy.FromEnv()
+	if err != nil {
+		return nil, 500, err
+	}
+	tags, err := policy.Apply(body.Tags, s.platformTags(body, accountName), tagpolicy.CloudFormationLimits)
+	if err != nil {
+		return nil, 400, err
+	}
+	return cfn.Tags(tags), 0, nil
+}
+
+// policyError responde uma falha de stackTags, checkGuardrails, securityScan
+// ou lintTemplate; as violações vão listadas uma a uma.
+func policyError(status int, err error) events.APIGatewayV2HTTPResponse {
+	var lerr *lint.Error
+	if errors.As(err, &lerr) {
+		return httpresp.OK(status, types.LintError{Message: err.Error(), Findings: lerr.Findings})
+	}
+	var serr *scan.BlockedError
+	if errors.As(err, &serr) {
+		return httpresp.OK(status, types.ScanError{Message: err.Error(), Findings: scanFindings(serr.Findings, nil)})
+	}
+	var gerr *guardrail.ViolationError
+	if errors.As(err, &gerr) {
+		return httpresp.OK(status, types.GuardrailError{
+			Message:    fmt.Sprintf("guardrails violated (%d violations)", len(gerr.Violations)),
+			Violations: guardrailViolations(gerr.Violations),
+		})
+	}
+	var verr *tagpolicy.ViolationError
+	if errors.As(err, &verr) {
+		resp := types.TagPolicyError{Message: fmt.Sprintf("tag policy violated (%d violations)", len(verr.Violations))}
+		for _, v := range verr.Violations {
+			resp.Violations = append(resp.Violations, types.TagViolation{Key: v.Key, Message: v.Message})
+		}
+		return httpresp.OK(status, resp)
+	}
+	return httpresp.Error(status, err)
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	cip "github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	sm "github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"create-stack-ms/internal/awsconfig"
+	"create-stack-ms/internal/cfn"
+	"create-stack-ms/internal/inventory"
+	"create-stack-ms/internal/tracker"
+)
+
+const (
+	trackerFirstPoll    = 30 * time.Second // atraso da primeira mensagem
+	trackerPollInterval = 10 * time.Second
+	trackerFollowBudget = 45 * time.Second // polling por mensagem antes de devolver à fila
+	trackerRequeueDelay = time.Minute
+	trackerMaxOperation = 24 * time.Hour // depois disso o acompanhamento é abandonado
+)
+
+// track registra uma operação recém-iniciada no inventário e a enfileira
+// para o tracker. Falhas só geram log: a operação já começou e a resposta
+// não depende disso.
+func (s *session) track(ctx context.Context, d inventory.Deployment, startedAt time.Time) {
+	d.Owner = s.owner
+	d.RequestID = s.requestID
+	d.StartedAt = startedAt.UTC()
+	d.Lifecycle = string(cfn.LifecycleOf(cft.StackStatus(d.Status)))
+
+	if s.inventory != nil {
+		if err := s.inventory.Started(ctx, d); err != nil {
+			log.Printf("[WARN] Could not record deployment: stackId=%s err=%v", d.StackID, err)
+		}
+	}
+	if s.queue == nil {
+		return
+	}
+	op := tracker.Operation{
+		Owner:     d.Owner,
+		Account:   d.Account,
+		Region:    d.Region,
+		StackID:   d.StackID,
+		StackName: d.StackName,
+		Operation: d.Operation,
+		StartedAt: d.StartedAt,
+	}
+	if err := s.queue.Enqueue(ctx, op, trackerFirstPoll); err != nil {
+		log.Printf("[WARN] Could not enqueue deployment for tracking: stackId=%s err=%v", d.StackID, err)
+	}
+}
+
+// templateHash identifica o template da operação: pelo corpo enviado quando
+// existe, senão pelo template gravado no CloudFormation (templateUrl, change sets).
+func templateHash(ctx context.Context, api *cf.Client, body *string, stackID, changeSetID string) string {
+	if body != nil {
+		return inventory.TemplateHash([]byte(*body))
+	}
+	in := &cf.GetTemplateInput{StackName: aws.String(stackID), TemplateStage: cft.TemplateStageOriginal}
+	if changeSetID != "" {
+		in.ChangeSetName = aws.String(changeSetID)
+	}
+	out, err := api.GetTemplate(ctx, in)
+	if err != nil {
+		log.Printf("[WARN] Could not read template for hashing: stackId=%s err=%v", stackID, err)
+		return ""
+	}
+	return inventory.TemplateHash([]byte(aws.ToString(out.TemplateBody)))
+}
+
+// operationOf deduz a operação do status logo após ExecuteChangeSet
+// (ex. CREATE_IN_PROGRESS -> CREATE).
+func operationOf(status string) string {
+	if op, _, found := strings.Cut(status, "_"); found && op != "" {
+		return op
+	}
+	return "UPDATE"
+}
+
+type trackerRun struct {
+	cfg     aws.Config
+	deps    *deps
+	tracker *tracker.Tracker
+	queue   tracker.Queue
+	store   inventory.Store   // nil quando DEPLOYMENTS_TABLE_NAME não está definido
+	events  tracker.Publisher // nil quando DEPLOYMENT_EVENTS_ENABLED=false
+}
+
+// TrackerHandler consome a fila de deploys (SQS): acompanha cada operação
+// até um status terminal, grava o resultado e emite o evento de conclusão.
+// Operações ainda em andamento voltam para a fila com atraso; mensagens com
+// erro são devolvidas como batch item failures e reprocessadas pelo SQS.
+func TrackerHandler(ctx context.Context, ev events.SQSEvent) (events.SQSEventResponse, error) {
+	var resp events.SQSEventResponse
+
+	queueURL := os.Getenv("TRACKER_QUEUE_URL")
+	if queueURL == "" {
+		return resp, errors.New("TRACKER_QUEUE_URL is not set")
+	}
+	cfg, err := awsconfig.Base(ctx)
+	if err != nil {
+		return resp, fmt.Errorf("aws config error: %w", err)
+	}
+
+	r := &trackerRun{
+		cfg: cfg,
+		deps: &deps{
+			cip: cip.NewFromConfig(cfg),
+			sm:  sm.NewFromConfig(cfg),
+		},
+		tracker: tracker.New(tracker.SystemClock{}, trackerPollInterval),
+		queue:   tracker.NewSQSQueue(sqs.NewFromConfig(cfg), queueURL),
+	}
+	if table := os.Getenv("DEPLOYMENTS_TABLE_NAME"); table != "" {
+		r.store = inventory.NewDynamoStore(dynamodb.NewFromConfig(cfg), table)
+	}
+	if !strings.EqualFold(os.Getenv("DEPLOYMENT_EVENTS_ENABLED"), "false") {
+		r.events = tracker.NewEventBridgePublisher(eventbridge.NewFromConfig(cfg), os.Getenv("DEPLOYMENT_EVENT_BUS"))
+	}
+
+	for _, msg := range ev.Records {
+		var op tracker.Operation
+		if err := json.Unmarshal([]byte(msg.Body), &op); err != nil || op.StackID == "" {
+			// Mensagem inválida nunca vai dar certo: descarta
+			log.Printf("[ERROR] Invalid tracker message: messageId=%s err=%v", msg.MessageId, err)
+			continue
+		}
+		if err := r.process(ctx, op); err != nil {
+			log.Printf("[ERROR] Tracking failed: messageId=%s stackId=%s err=%v", msg.MessageId, op.StackID, err)
+			resp.BatchItemFailures = append(resp.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: msg.MessageId})
+		}
+	}
+	return resp, nil
+}
+
+func (r *trackerRun) process(ctx context.Context, op tracker.Operation) error {
+	s := ownerSession(r.cfg, r.deps, op.Owner)
+	targetCfg, _, err := s.loadTargetConfig(ctx, op.Account, op.Region)
+	if err != nil {
+		return err
+	}
+
+	now := r.tracker.Clock.Now()
+	out, done, err := r.tracker.Follow(ctx, cf.NewFromConfig(targetCfg), op, now.Add(trackerFollowBudget))
+	if err != nil {
+		if tracker.IsGone(err) {
+			log.Printf("[WARN] Tracked stack no longer exists: stackId=%s", op.StackID)
+			return nil
+		}
+		return err
+	}
+	if !done {
+		if r.tracker.Clock.Now().Sub(op.StartedAt) > trackerMaxOperation {
+			log.Printf("[WARN] Giving up tracking: stackId=%s operation=%s startedAt=%s attempts=%d",
+				op.StackID, op.Operation, op.StartedAt.Format(time.RFC3339), op.Attempt)
+			return nil
+		}
+		op.Attempt++
+		return r.queue.Enqueue(ctx, op, trackerRequeueDelay)
+	}
+
+	log.Printf("[INFO] Deployment finished: stackId=%s operation=%s status=%s duration=%ds reason=%q",
+		out.StackID, out.Operation.Operation, out.Status, out.DurationSeconds, out.FailureReason)
+	if r.store != nil {
+		if err := r.store.Finished(ctx, finished(out)); err != nil {
+			return fmt.Errorf("record deployment failed: %w", err)
+		}
+	}
+	if r.events != nil {
+		if err := r.events.Publish(ctx, out); err != nil {
+			return fmt.Errorf("publish event failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func finished(o tracker.Outcome) inventory.Deployment {
+	return inventory.Deployment{
+		Owner:           o.Owner,
+		Account:         o.Account,
+		StackID:         o.StackID,
+		StackName:       o.StackName,
+		Operation:       o.Operation.Operation,
+		StartedAt:       o.StartedAt,
+		Status:          o.Status,
+		Lifecycle:       o.Lifecycle,
+		FinishedAt:      o.FinishedAt,
+		DurationSeconds: o.DurationSeconds,
+		FailureReason:   o.FailureReason,
+		FailedResource:  o.FailedResource,
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+
+	"create-stack-ms/internal/cfn"
+	"create-stack-ms/internal/httpresp"
+	"create-stack-ms/internal/lint"
+	"create-stack-ms/internal/types"
+	"create-stack-ms/internal/validate"
+)
+
+// ValidateHandler atende POST /cf/validate: roda as verificações locais
+// (estrutura e especificação de recursos) e o ValidateTemplate na conta alvo,
+// sem criar nada. Aceita as mesmas origens de template de /cf/create-stack;
+// problemas no template voltam como findings (200 com valid=false), não como
+// erro HTTP.
+func ValidateHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	var body types.RequestBody
+	if err := decodeBody(req, &body); err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	log.Printf("[INFO] Validate request: accountName=%s templateInline=%t templateUrlSet=%t",
+		body.AccountName, len(body.Template) > 0 || body.TemplateYAML != "", body.TemplateURL != "")
+
+	if body.AccountName == "" {
+		return httpresp.Error(400, errors.New("field 'accountName' is required")), nil
+	}
+
+	resp := types.ValidateResponse{
+		Account:      body.AccountName,
+		Owner:        s.owner,
+		Parameters:   []types.TemplateParameter{},
+		Capabilities: []string{},
+		Findings:     []types.Finding{},
+	}
+
+	if errResp := s.catalogTemplate(ctx, &body); errResp != nil {
+		return *errResp, nil
+	}
+
+	// ---- Verificações locais ----
+	raw, err := inlineTemplate(body)
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	if raw != nil {
+		var tpl map[string]any
+		if err := json.Unmarshal(raw, &tpl); err != nil {
+			return httpresp.Error(400, fmt.Errorf("template must be valid JSON: %v", err)), nil
+		}
+		resp.Findings = append(resp.Findings, validate.Template(tpl)...)
+		resp.Findings = append(resp.Findings, lint.Template(tpl)...)
+	} else {
+		resp.Findings = append(resp.Findings, types.Finding{
+			Severity: validate.SeverityInfo,
+			Rule:     "local-checks-skipped",
+			Message:  "local checks only run on inline templates",
+		})
+	}
+
+	templateBody, errResp := s.resolveTemplate(ctx, &body)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	targetCfg, errResp := s.targetConfig(ctx, body.AccountName, body.Region)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	// ---- CloudFormation: ValidateTemplate ----
+	in := &cf.ValidateTemplateInput{TemplateBody: templateBody}
+	if templateBody == nil {
+		in.TemplateURL = aws.String(body.TemplateURL)
+	}
+	out, err := cf.NewFromConfig(targetCfg).ValidateTemplate(ctx, in)
+	if err != nil {
+		msg, ok := cfn.ValidationMessage(err)
+		if !ok {
+			return httpresp.Error(cfn.HTTPStatus(err), fmt.Errorf("validate template failed: %w", err)), nil
+		}
+		resp.Findings = append(resp.Findings, types.Finding{
+			Severity: validate.SeverityError,
+			Rule:     "cfn-validate-template",
+			Message:  msg,
+		})
+	} else {
+		resp.Description = aws.ToString(out.Description)
+		resp.CapabilitiesReason = aws.ToString(out.CapabilitiesReason)
+		resp.Transforms = out.DeclaredTransforms
+		for _, c := range out.Capabilities {
+			resp.Capabilities = append(resp.Capabilities, string(c))
+		}
+		for _, p := range out.Parameters {
+			resp.Parameters = append(resp.Parameters, types.TemplateParameter{
+				Key:          aws.ToString(p.ParameterKey),
+				DefaultValue: aws.ToString(p.DefaultValue),
+				NoEcho:       aws.ToBool(p.NoEcho),
+				Description:  aws.ToString(p.Description),
+			})
+			if aws.ToBool(p.NoEcho) {
+				resp.Parameters[len(resp.Parameters)-1].DefaultValue = "" // nunca expor default NoEcho
+			}
+		}
+	}
+
+	resp.Valid = !validate.HasErrors(resp.Findings)
+	log.Printf("[INFO] Validation finished: valid=%t findings=%d params=%d caps=%v",
+		resp.Valid, len(resp.Findings), len(resp.Parameters), resp.Capabilities)
+	return httpresp.OK(200, resp), nil
+}
+
+// lintTemplate confere o template do create-stack com a especificação de
+// recursos (TEMPLATE_LINT). Em modo enforce, erros viram um *lint.Error com
+// status 400; em modo warn só vão para o log. Templates por URL não são
+// conferidos.
+func lintTemplate(body types.RequestBody) (int, error) {
+	mode, err := lint.ModeFromEnv()
+	if err != nil {
+		return 500, err
+	}
+	if mode == lint.ModeOff {
+		return 0, nil
+	}
+
+	raw, err := inlineTemplate(body)
+	if err != nil {
+		return 400, err
+	}
+	if raw == nil {
+		log.Printf("[WARN] Template lint skipped: template sent by URL")
+		return 0, nil
+	}
+	var tpl map[string]any
+	if err := json.Unmarshal(raw, &tpl); err != nil {
+		return 400, fmt.Errorf("template must be valid JSON: %v", err)
+	}
+
+	findings := lint.Template(tpl)
+	log.Printf("[INFO] Template lint: findings=%d errors=%t", len(findings), validate.HasErrors(findings))
+	if !validate.HasErrors(findings) {
+		return 0, nil
+	}
+	if mode == lint.ModeWarn {
+		for _, f := range findings {
+			log.Printf("[WARN] Template lint: %s %s at %s: %s", f.Severity, f.Rule, f.Path, f.Message)
+		}
+		return 0, nil
+	}
+	return 400, &lint.Error{Findings: findings}
+}
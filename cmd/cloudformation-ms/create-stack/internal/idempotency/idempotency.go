@@ -0,0 +1,218 @@
+// Package idempotency guarda e reaproveita respostas por Idempotency-Key.
+// É a versão canônica: cmd/organizations-ms/create-key/internal/idempotency
+// é uma cópia e deve acompanhar as mudanças feitas aqui.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Header é o header HTTP com a chave de idempotência escolhida pelo cliente.
+const Header = "Idempotency-Key"
+
+// ReplayedHeader marca as respostas devolvidas a partir do store.
+const ReplayedHeader = "Idempotent-Replayed"
+
+const maxKeyLength = 255
+
+const (
+	StatusInProgress = "IN_PROGRESS"
+	StatusCompleted  = "COMPLETED"
+)
+
+// Record é o que fica guardado por chave: o fingerprint da primeira
+// requisição e, depois de concluída, a resposta que ela recebeu.
+type Record struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	Status      string
+	LockedUntil time.Time
+	ExpiresAt   time.Time
+
+	StatusCode int
+	Headers    map[string]string
+	Body       string
+}
+
+type Store interface {
+	// Claim grava r como IN_PROGRESS se a chave estiver livre (inexistente,
+	// expirada, ou com lock vencido para o mesmo fingerprint). Quando não
+	// consegue, devolve o registro atual com claimed=false.
+	Claim(ctx context.Context, r Record, now time.Time) (current Record, claimed bool, err error)
+	Complete(ctx context.Context, r Record) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+type HandlerFunc func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error)
+
+// Middleware torna um handler HTTP idempotente para requisições com o header
+// Idempotency-Key. Sem o header a requisição segue direto para o handler.
+type Middleware struct {
+	Store Store
+	// TTL é por quanto tempo uma resposta pode ser repetida.
+	TTL time.Duration
+	// LockTimeout libera chaves presas em IN_PROGRESS, ex. depois de um timeout
+	// da Lambda. Deve ser maior que o timeout da função.
+	LockTimeout time.Duration
+	Now         func() time.Time
+}
+
+func New(store Store) *Middleware {
+	return &Middleware{Store: store, TTL: 24 * time.Hour, LockTimeout: 5 * time.Minute, Now: time.Now}
+}
+
+type ctxKey struct{}
+
+// Wrap devolve next protegido pela chave de idempotência. As chaves são
+// isoladas por usuário (claim sub do JWT).
+func (m *Middleware) Wrap(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		key := headerValue(req.Headers, Header)
+		if key == "" {
+			return next(ctx, req)
+		}
+		if len(key) > maxKeyLength {
+			return errorResponse(400, fmt.Sprintf("header '%s' must have at most %d characters", Header, maxKeyLength)), nil
+		}
+		scope := Scope(req)
+		if scope == "" {
+			// Sem identidade não há como isolar a chave; o handler responde 401
+			return next(ctx, req)
+		}
+		fp, err := Fingerprint(req)
+		if err != nil {
+			return errorResponse(400, err.Error()), nil
+		}
+
+		now := m.Now()
+		r := Record{
+			Scope:       scope,
+			Key:         key,
+			Fingerprint: fp,
+			Status:      StatusInProgress,
+			LockedUntil: now.Add(m.LockTimeout),
+			ExpiresAt:   now.Add(m.TTL),
+		}
+		current, claimed, err := m.Store.Claim(ctx, r, now)
+		if err != nil {
+			log.Printf("[ERROR] Idempotency claim failed: key=%s err=%v", key, err)
+			return errorResponse(500, "idempotency store unavailable"), nil
+		}
+		if !claimed {
+			return replay(current, fp), nil
+		}
+		log.Printf("[INFO] Idempotency key claimed: key=%s", key)
+
+		resp, err := next(context.WithValue(ctx, ctxKey{}, r), req)
+		if err != nil || resp.StatusCode >= 500 {
+			// Falha do servidor: libera a chave para o cliente tentar de novo
+			if rerr := m.Store.Release(ctx, scope, key); rerr != nil {
+				log.Printf("[WARN] Idempotency release failed: key=%s err=%v", key, rerr)
+			}
+			return resp, err
+		}
+
+		r.Status = StatusCompleted
+		r.StatusCode = resp.StatusCode
+		r.Headers = resp.Headers
+		r.Body = resp.Body
+		if err := m.Store.Complete(ctx, r); err != nil {
+			log.Printf("[WARN] Idempotency complete failed: key=%s err=%v", key, err)
+		}
+		return resp, nil
+	}
+}
+
+// replay responde a uma chave já usada.
+func replay(current Record, fp string) events.APIGatewayV2HTTPResponse {
+	if current.Fingerprint != fp {
+		return errorResponse(409, fmt.Sprintf("idempotency key '%s' was already used with a different request", current.Key))
+	}
+	if current.Status != StatusCompleted {
+		return errorResponse(409, fmt.Sprintf("a request with idempotency key '%s' is still in progress", current.Key))
+	}
+	log.Printf("[INFO] Idempotency replay: key=%s status=%d", current.Key, current.StatusCode)
+	headers := map[string]string{}
+	for k, v := range current.Headers {
+		headers[k] = v
+	}
+	headers[ReplayedHeader] = "true"
+	return events.APIGatewayV2HTTPResponse{StatusCode: current.StatusCode, Headers: headers, Body: current.Body}
+}
+
+// Scope identifica o dono das chaves. Usa o sub, que não muda com o username.
+func Scope(req events.APIGatewayV2HTTPRequest) string {
+	if req.RequestContext.Authorizer == nil || req.RequestContext.Authorizer.JWT == nil {
+		return ""
+	}
+	claims := req.RequestContext.Authorizer.JWT.Claims
+	if sub := claims["sub"]; sub != "" {
+		return sub
+	}
+	return claims["cognito:username"]
+}
+
+// Fingerprint resume método, rota e corpo da requisição.
+func Fingerprint(req events.APIGatewayV2HTTPRequest) (string, error) {
+	body := req.Body
+	if req.IsBase64Encoded {
+		b, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			return "", fmt.Errorf("invalid base64 body: %w", err)
+		}
+		body = string(b)
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", req.RequestContext.HTTP.Method, req.RawPath)
+	h.Write([]byte(body))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ClientRequestToken deriva um token para o CloudFormation a partir da chave
+// de idempotência da requisição em curso, ou "" quando não há chave. O mesmo
+// par usuário/chave gera sempre o mesmo token, então uma retentativa depois
+// de um timeout é reconhecida pelo próprio CloudFormation.
+func ClientRequestToken(ctx context.Context) string {
+	r, ok := ctx.Value(ctxKey{}).(Record)
+	if !ok {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(r.Scope + "\n" + r.Key))
+	// Formato aceito pelo CloudFormation: [a-zA-Z][-a-zA-Z0-9]*, até 128 caracteres
+	return "idem-" + hex.EncodeToString(sum[:16])
+}
+
+// headerValue busca o header ignorando maiúsculas; o API Gateway HTTP API
+// entrega os nomes em minúsculas.
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func errorResponse(status int, msg string) events.APIGatewayV2HTTPResponse {
+	b, _ := json.Marshal(map[string]string{"message": msg})
+	log.Printf("[ERROR] Response %d: %s", status, string(b))
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: status,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(b),
+	}
+}
@@ -0,0 +1,594 @@
+package intrinsic
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// noValue é o resultado de Ref AWS::NoValue: a chave ou o item some.
+var noValue = &struct{ name string }{"AWS::NoValue"}
+
+// ${Nome}, ${Recurso.Atributo} ou ${!Literal} dentro de Fn::Sub.
+var subVar = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+type evaluator struct {
+	tpl         map[string]any
+	in          Input
+	params      map[string]paramValue
+	conditions  map[string]bool
+	evaluating  map[string]bool // condições em avaliação, para achar ciclos
+	removed     map[string]bool // recursos descartados
+	inCondition bool            // em Conditions os NoEcho valem o valor real
+	warnings    []Warning
+}
+
+// eval resolve v. O que depende do deploy volta como a função original, com
+// os argumentos resolvidos até onde der.
+func (e *evaluator) eval(v any, path []string) (any, error) {
+	switch val := v.(type) {
+	case []any:
+		out := make([]any, 0, len(val))
+		for i, it := range val {
+			r, err := e.eval(it, child(path, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			if r != noValue {
+				out = append(out, r)
+			}
+		}
+		return out, nil
+	case map[string]any:
+		if len(val) == 1 {
+			for k, arg := range val {
+				if fn, ok := functions[k]; ok {
+					return fn(e, arg, child(path, k))
+				}
+			}
+		}
+		out := make(map[string]any, len(val))
+		for _, k := range sortedKeys(val) {
+			r, err := e.eval(val[k], child(path, k))
+			if err != nil {
+				return nil, err
+			}
+			if r != noValue {
+				out[k] = r
+			}
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+type function func(e *evaluator, arg any, path []string) (any, error)
+
+var functions map[string]function
+
+func init() {
+	functions = map[string]function{
+		"Ref":           (*evaluator).ref,
+		"Condition":     (*evaluator).conditionRef,
+		"Fn::If":        (*evaluator).fnIf,
+		"Fn::Equals":    (*evaluator).fnEquals,
+		"Fn::And":       (*evaluator).fnAnd,
+		"Fn::Or":        (*evaluator).fnOr,
+		"Fn::Not":       (*evaluator).fnNot,
+		"Fn::Sub":       (*evaluator).fnSub,
+		"Fn::Join":      (*evaluator).fnJoin,
+		"Fn::FindInMap": (*evaluator).fnFindInMap,
+		"Fn::Select":    (*evaluator).fnSelect,
+		"Fn::Split":     (*evaluator).fnSplit,
+		"Fn::Base64":    (*evaluator).fnBase64,
+		"Fn::GetAtt":    (*evaluator).fnGetAtt,
+		// Dependem do deploy ou de outras stacks: ficam como placeholder
+		"Fn::GetAZs":      (*evaluator).placeholder,
+		"Fn::ImportValue": (*evaluator).placeholder,
+		"Fn::Cidr":        (*evaluator).placeholder,
+		"Fn::Transform":   (*evaluator).placeholder,
+	}
+}
+
+func (e *evaluator) placeholder(arg any, path []string) (any, error) {
+	r, err := e.eval(arg, path)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{path[len(path)-1]: r}, nil
+}
+
+func (e *evaluator) ref(arg any, path []string) (any, error) {
+	name, ok := arg.(string)
+	if !ok {
+		return nil, e.errorf(path, "Ref takes a string")
+	}
+	if p, ok := e.params[name]; ok {
+		if p.deployed {
+			return map[string]any{"Ref": name}, nil
+		}
+		value := p.value
+		if p.noEcho && !e.inCondition {
+			value = Mask
+		}
+		if p.list {
+			return splitList(value), nil
+		}
+		return value, nil
+	}
+
+	switch name {
+	case "AWS::NoValue":
+		return noValue, nil
+	case "AWS::AccountId":
+		return e.in.AccountID, nil
+	case "AWS::Region":
+		return e.in.Region, nil
+	case "AWS::Partition":
+		return partition(e.in.Region), nil
+	case "AWS::URLSuffix":
+		if strings.HasPrefix(e.in.Region, "cn-") {
+			return "amazonaws.com.cn", nil
+		}
+		return "amazonaws.com", nil
+	case "AWS::NotificationARNs":
+		out := make([]any, 0, len(e.in.NotificationARNs))
+		for _, a := range e.in.NotificationARNs {
+			out = append(out, a)
+		}
+		return out, nil
+	case "AWS::StackName":
+		if e.in.StackName != "" {
+			return e.in.StackName, nil
+		}
+		return map[string]any{"Ref": name}, nil
+	case "AWS::StackId":
+		return map[string]any{"Ref": name}, nil
+	}
+
+	resources, _ := e.tpl["Resources"].(map[string]any)
+	if _, ok := resources[name]; ok {
+		if e.removed[name] {
+			e.warn(path, "Ref to '%s', which is removed by its condition; CloudFormation rejects this template", name)
+		}
+		return map[string]any{"Ref": name}, nil // id físico só existe depois do deploy
+	}
+	return nil, e.errorf(path, "Ref target '%s' is not a parameter, resource or pseudo parameter", name)
+}
+
+func (e *evaluator) conditionRef(arg any, path []string) (any, error) {
+	name, ok := arg.(string)
+	if !ok {
+		return nil, e.errorf(path, "Condition takes a condition name")
+	}
+	return e.condition(name, path)
+}
+
+func (e *evaluator) fnIf(arg any, path []string) (any, error) {
+	args, ok := arg.([]any)
+	if !ok || len(args) != 3 {
+		return nil, e.errorf(path, "Fn::If takes [condition, valueIfTrue, valueIfFalse]")
+	}
+	name, ok := args[0].(string)
+	if !ok {
+		return nil, e.errorf(path, "Fn::If condition must be a condition name")
+	}
+	cond, err := e.condition(name, child(path, "0"))
+	if err != nil {
+		return nil, err
+	}
+	if cond {
+		return e.eval(args[1], child(path, "1"))
+	}
+	return e.eval(args[2], child(path, "2"))
+}
+
+func (e *evaluator) fnEquals(arg any, path []string) (any, error) {
+	args, ok := arg.([]any)
+	if !ok || len(args) != 2 {
+		return nil, e.errorf(path, "Fn::Equals takes two values")
+	}
+	a, err := e.eval(args[0], child(path, "0"))
+	if err != nil {
+		return nil, err
+	}
+	b, err := e.eval(args[1], child(path, "1"))
+	if err != nil {
+		return nil, err
+	}
+	if !resolved(a) || !resolved(b) {
+		return map[string]any{"Fn::Equals": []any{a, b}}, nil
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b)), nil
+}
+
+func (e *evaluator) fnAnd(arg any, path []string) (any, error) {
+	return e.logical(arg, path, "Fn::And", true)
+}
+
+func (e *evaluator) fnOr(arg any, path []string) (any, error) {
+	return e.logical(arg, path, "Fn::Or", false)
+}
+
+// logical avalia Fn::And (all=true) e Fn::Or: todos os termos precisam estar
+// resolvidos, como no CloudFormation, que não faz curto-circuito.
+func (e *evaluator) logical(arg any, path []string, name string, all bool) (any, error) {
+	args, ok := arg.([]any)
+	if !ok || len(args) < 2 || len(args) > 10 {
+		return nil, e.errorf(path, "%s takes between 2 and 10 conditions", name)
+	}
+	out := all
+	for i, it := range args {
+		v, err := e.eval(it, child(path, strconv.Itoa(i)))
+		if err != nil {
+			return nil, err
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return map[string]any{name: args}, nil
+		}
+		if all {
+			out = out && b
+		} else {
+			out = out || b
+		}
+	}
+	return out, nil
+}
+
+func (e *evaluator) fnNot(arg any, path []string) (any, error) {
+	args, ok := arg.([]any)
+	if !ok || len(args) != 1 {
+		return nil, e.errorf(path, "Fn::Not takes a list with one condition")
+	}
+	v, err := e.eval(args[0], child(path, "0"))
+	if err != nil {
+		return nil, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return map[string]any{"Fn::Not": []any{v}}, nil
+	}
+	return !b, nil
+}
+
+func (e *evaluator) fnSub(arg any, path []string) (any, error) {
+	var text string
+	locals := map[string]any{}
+	switch a := arg.(type) {
+	case string:
+		text = a
+	case []any:
+		if len(a) != 2 {
+			return nil, e.errorf(path, "Fn::Sub takes a string or [string, variables]")
+		}
+		s, ok := a[0].(string)
+		vars, ok2 := a[1].(map[string]any)
+		if !ok || !ok2 {
+			return nil, e.errorf(path, "Fn::Sub takes a string or [string, variables]")
+		}
+		text = s
+		for _, k := range sortedKeys(vars) {
+			v, err := e.eval(vars[k], child(child(path, "1"), k))
+			if err != nil {
+				return nil, err
+			}
+			locals[k] = v
+		}
+	default:
+		return nil, e.errorf(path, "Fn::Sub takes a string or [string, variables]")
+	}
+
+	pending := map[string]any{} // variáveis locais que ficam para o deploy
+	complete := true
+	var err error
+	out := subVar.ReplaceAllStringFunc(text, func(m string) string {
+		name := strings.TrimSpace(m[2 : len(m)-1])
+		if err != nil || strings.HasPrefix(name, "!") {
+			return m // o escape só sai quando o Fn::Sub inteiro é resolvido
+		}
+		var v any
+		if local, ok := locals[name]; ok {
+			v = local
+			if !resolved(v) {
+				pending[name] = v
+			}
+		} else if res, attr, isAttr := strings.Cut(name, "."); isAttr && !e.isParam(name) {
+			v, err = e.fnGetAtt([]any{res, attr}, path)
+		} else {
+			v, err = e.ref(name, path)
+		}
+		if err != nil || !resolved(v) {
+			complete = false
+			return m
+		}
+		s, ok := str(v)
+		if !ok {
+			err = e.errorf(path, "Fn::Sub variable '${%s}' is not a string", name)
+			return m
+		}
+		return s
+	})
+	if err != nil {
+		return nil, err
+	}
+	if complete {
+		return subVar.ReplaceAllStringFunc(out, func(m string) string {
+			return "${" + strings.TrimPrefix(m[2:len(m)-1], "!") + "}"
+		}), nil
+	}
+	if len(pending) > 0 {
+		return map[string]any{"Fn::Sub": []any{out, pending}}, nil
+	}
+	return map[string]any{"Fn::Sub": out}, nil
+}
+
+func (e *evaluator) isParam(name string) bool {
+	_, ok := e.params[name]
+	return ok
+}
+
+func (e *evaluator) fnJoin(arg any, path []string) (any, error) {
+	args, ok := arg.([]any)
+	if !ok || len(args) != 2 {
+		return nil, e.errorf(path, "Fn::Join takes [delimiter, list]")
+	}
+	delim, ok := args[0].(string)
+	if !ok {
+		return nil, e.errorf(path, "Fn::Join delimiter must be a string")
+	}
+	v, err := e.eval(args[1], child(path, "1"))
+	if err != nil {
+		return nil, err
+	}
+	list, ok := v.([]any)
+	if !ok {
+		if resolved(v) {
+			return nil, e.errorf(path, "Fn::Join takes a list of values")
+		}
+		return map[string]any{"Fn::Join": []any{delim, v}}, nil
+	}
+	parts := make([]string, 0, len(list))
+	for _, it := range list {
+		if !resolved(it) {
+			return map[string]any{"Fn::Join": []any{delim, list}}, nil
+		}
+		s, ok := str(it)
+		if !ok {
+			return nil, e.errorf(path, "Fn::Join values must be strings")
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, delim), nil
+}
+
+func (e *evaluator) fnFindInMap(arg any, path []string) (any, error) {
+	args, ok := arg.([]any)
+	if !ok || len(args) < 3 || len(args) > 4 {
+		return nil, e.errorf(path, "Fn::FindInMap takes [map, topLevelKey, secondLevelKey]")
+	}
+	keys := make([]string, 3)
+	for i := range keys {
+		v, err := e.eval(args[i], child(path, strconv.Itoa(i)))
+		if err != nil {
+			return nil, err
+		}
+		if !resolved(v) {
+			return map[string]any{"Fn::FindInMap": args}, nil
+		}
+		s, ok := str(v)
+		if !ok {
+			return nil, e.errorf(path, "Fn::FindInMap keys must be strings")
+		}
+		keys[i] = s
+	}
+
+	mappings, _ := e.tpl["Mappings"].(map[string]any)
+	m, ok := mappings[keys[0]].(map[string]any)
+	if !ok {
+		return nil, e.errorf(path, "mapping '%s' is not declared", keys[0])
+	}
+	top, _ := m[keys[1]].(map[string]any)
+	if v, ok := top[keys[2]]; ok {
+		return v, nil
+	}
+	// DefaultValue, da transform AWS::LanguageExtensions
+	if len(args) == 4 {
+		if d, ok := args[3].(map[string]any); ok {
+			if def, ok := d["DefaultValue"]; ok {
+				return e.eval(def, child(path, "3"))
+			}
+		}
+	}
+	return nil, e.errorf(path, "mapping '%s' has no value for '%s' / '%s'", keys[0], keys[1], keys[2])
+}
+
+func (e *evaluator) fnSelect(arg any, path []string) (any, error) {
+	args, ok := arg.([]any)
+	if !ok || len(args) != 2 {
+		return nil, e.errorf(path, "Fn::Select takes [index, list]")
+	}
+	idx, err := e.eval(args[0], child(path, "0"))
+	if err != nil {
+		return nil, err
+	}
+	v, err := e.eval(args[1], child(path, "1"))
+	if err != nil {
+		return nil, err
+	}
+	list, isList := v.([]any)
+	if !resolved(idx) || !isList {
+		if !isList && resolved(v) {
+			return nil, e.errorf(path, "Fn::Select takes a list of values")
+		}
+		return map[string]any{"Fn::Select": []any{idx, v}}, nil
+	}
+	s, _ := str(idx)
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 {
+		return nil, e.errorf(path, "Fn::Select index must be a non-negative integer")
+	}
+	if i >= len(list) {
+		return nil, e.errorf(path, "Fn::Select index %d is out of range (list has %d values)", i, len(list))
+	}
+	return list[i], nil
+}
+
+func (e *evaluator) fnSplit(arg any, path []string) (any, error) {
+	args, ok := arg.([]any)
+	if !ok || len(args) != 2 {
+		return nil, e.errorf(path, "Fn::Split takes [delimiter, string]")
+	}
+	delim, ok := args[0].(string)
+	if !ok || delim == "" {
+		return nil, e.errorf(path, "Fn::Split delimiter must be a non-empty string")
+	}
+	v, err := e.eval(args[1], child(path, "1"))
+	if err != nil {
+		return nil, err
+	}
+	if !resolved(v) {
+		return map[string]any{"Fn::Split": []any{delim, v}}, nil
+	}
+	s, ok := str(v)
+	if !ok {
+		return nil, e.errorf(path, "Fn::Split takes a string")
+	}
+	out := []any{}
+	for _, p := range strings.Split(s, delim) {
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (e *evaluator) fnBase64(arg any, path []string) (any, error) {
+	v, err := e.eval(arg, path)
+	if err != nil {
+		return nil, err
+	}
+	if !resolved(v) {
+		return map[string]any{"Fn::Base64": v}, nil
+	}
+	s, ok := str(v)
+	if !ok {
+		return nil, e.errorf(path, "Fn::Base64 takes a string")
+	}
+	return base64.StdEncoding.EncodeToString([]byte(s)), nil
+}
+
+// fnGetAtt nunca resolve: os atributos só existem depois do deploy.
+func (e *evaluator) fnGetAtt(arg any, path []string) (any, error) {
+	var name string
+	switch a := arg.(type) {
+	case string:
+		name, _, _ = strings.Cut(a, ".")
+	case []any:
+		if len(a) > 0 {
+			name, _ = a[0].(string)
+		}
+		r, err := e.eval(a, path)
+		if err != nil {
+			return nil, err
+		}
+		arg = r
+	}
+	if e.removed[name] {
+		e.warn(path, "Fn::GetAtt on '%s', which is removed by its condition; CloudFormation rejects this template", name)
+	}
+	return map[string]any{"Fn::GetAtt": arg}, nil
+}
+
+// resolved indica um valor sem funções intrínsecas pendentes.
+func resolved(v any) bool {
+	switch val := v.(type) {
+	case []any:
+		for _, it := range val {
+			if !resolved(it) {
+				return false
+			}
+		}
+	case map[string]any:
+		if len(val) == 1 {
+			for k := range val {
+				if k == "Ref" || k == "Condition" || strings.HasPrefix(k, "Fn::") {
+					return false
+				}
+			}
+		}
+		for _, it := range val {
+			if !resolved(it) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// str converte escalares para texto, como o CloudFormation faz em Fn::Sub
+// e Fn::Join.
+func str(v any) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		return val, true
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(val), true
+	}
+	return "", false
+}
+
+// scalar é str para Defaults de parâmetros; listas viram "a,b".
+func scalar(v any) string {
+	if s, ok := str(v); ok {
+		return s
+	}
+	if list, ok := v.([]any); ok {
+		parts := make([]string, 0, len(list))
+		for _, it := range list {
+			s, _ := str(it)
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// normalize compara escalares pelo texto: "1" e 1 são iguais em Fn::Equals.
+func normalize(v any) any {
+	if list, ok := v.([]any); ok {
+		out := make([]any, len(list))
+		for i, it := range list {
+			out[i] = normalize(it)
+		}
+		return out
+	}
+	if s, ok := str(v); ok {
+		return s
+	}
+	return v
+}
+
+func splitList(s string) []any {
+	out := []any{}
+	if s == "" {
+		return out
+	}
+	for _, p := range strings.Split(s, ",") {
+		out = append(out, strings.TrimSpace(p))
+	}
+	return out
+}
+
+func partition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	}
+	return "aws"
+}
@@ -0,0 +1,307 @@
+package intrinsic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"create-stack-ms/internal/validate"
+)
+
+// Mask substitui o valor de parâmetros NoEcho no template resolvido.
+const Mask = "****"
+
+// Input é o que o CloudFormation conhece ao criar a stack.
+type Input struct {
+	Template   map[string]any
+	Parameters map[string]string // valores informados; os ausentes usam o Default
+
+	AccountID        string
+	Region           string
+	StackName        string // vazio deixa AWS::StackName como placeholder
+	NotificationARNs []string
+}
+
+// Parameter é o valor efetivo de um parâmetro.
+type Parameter struct {
+	Key    string
+	Value  string // Mask para NoEcho; nos tipos SSM, o nome do parâmetro SSM
+	Source string // "request" | "default" | "deploy"
+}
+
+// Removed é um recurso ou output descartado por uma condição falsa.
+type Removed struct {
+	Section   string // "Resources" | "Outputs"
+	LogicalID string
+	Type      string
+	Condition string
+}
+
+// Warning aponta algo que o CloudFormation rejeitaria ou que a prévia não
+// mostra como será.
+type Warning struct {
+	Path    string
+	Message string
+}
+
+// Result é o template como o CloudFormation o veria após resolver parâmetros
+// e condições. Referências só conhecidas no deploy (Fn::GetAtt, Ref a
+// recursos, Fn::ImportValue...) continuam como a função intrínseca original.
+type Result struct {
+	Template   map[string]any
+	Parameters []Parameter
+	Conditions map[string]bool
+	Removed    []Removed
+	Warnings   []Warning
+}
+
+// Error é um template que o CloudFormation rejeitaria ao resolver as funções.
+type Error struct {
+	Path    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, e.Path)
+}
+
+// Evaluate resolve Conditions, Fn::If, Fn::Sub, Fn::FindInMap, Fn::Join e as
+// demais funções que não dependem de recursos criados. Recursos e outputs com
+// condição falsa saem do template; Conditions e Mappings também, já que não
+// são mais referenciados. Parameters continua, para os placeholders.
+func Evaluate(in Input) (*Result, error) {
+	e := &evaluator{
+		tpl:        in.Template,
+		in:         in,
+		params:     map[string]paramValue{},
+		conditions: map[string]bool{},
+		evaluating: map[string]bool{},
+		removed:    map[string]bool{},
+	}
+	res := &Result{Conditions: e.conditions}
+
+	if err := e.loadParameters(res); err != nil {
+		return nil, err
+	}
+	conds, _ := in.Template["Conditions"].(map[string]any)
+	for _, name := range sortedKeys(conds) {
+		if _, err := e.condition(name, []string{"Conditions", name}); err != nil {
+			return nil, err
+		}
+	}
+
+	out := map[string]any{}
+	for k, v := range in.Template {
+		switch k {
+		case "Conditions", "Mappings", "Resources", "Outputs":
+		default:
+			out[k] = v
+		}
+	}
+
+	// Primeiro descarta, para que referências a recursos removidos virem aviso
+	skip := map[string]bool{}
+	for _, section := range []string{"Resources", "Outputs"} {
+		items, _ := in.Template[section].(map[string]any)
+		for _, name := range sortedKeys(items) {
+			item, _ := items[name].(map[string]any)
+			cond, ok := item["Condition"].(string)
+			if !ok {
+				continue
+			}
+			val, known := e.conditions[cond]
+			if !known {
+				return nil, &Error{Path: validate.Pointer(section, name, "Condition"), Message: fmt.Sprintf("condition '%s' is not declared", cond)}
+			}
+			if !val {
+				typ, _ := item["Type"].(string)
+				res.Removed = append(res.Removed, Removed{Section: section, LogicalID: name, Type: typ, Condition: cond})
+				skip[section+"/"+name] = true
+				if section == "Resources" {
+					e.removed[name] = true
+				}
+			}
+		}
+	}
+
+	for _, section := range []string{"Resources", "Outputs"} {
+		items, ok := in.Template[section].(map[string]any)
+		if !ok {
+			continue
+		}
+		resolved := map[string]any{}
+		for _, name := range sortedKeys(items) {
+			if skip[section+"/"+name] {
+				continue
+			}
+			v, err := e.item(section, name, items[name])
+			if err != nil {
+				return nil, err
+			}
+			resolved[name] = v
+		}
+		out[section] = resolved
+	}
+
+	res.Template = out
+	res.Warnings = e.warnings
+	sort.SliceStable(res.Warnings, func(i, j int) bool { return res.Warnings[i].Path < res.Warnings[j].Path })
+	return res, nil
+}
+
+// item resolve um recurso ou output. Type e Condition não passam pelas
+// funções; Condition sai porque já foi decidida.
+func (e *evaluator) item(section, name string, v any) (any, error) {
+	path := []string{section, name}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return e.eval(v, path)
+	}
+	out := map[string]any{}
+	for _, k := range sortedKeys(m) {
+		switch k {
+		case "Condition":
+			continue
+		case "Type":
+			out[k] = m[k]
+			continue
+		case "DependsOn":
+			e.dependsOn(m[k], child(path, k))
+		}
+		val, err := e.eval(m[k], child(path, k))
+		if err != nil {
+			return nil, err
+		}
+		if val != noValue {
+			out[k] = val
+		}
+	}
+	return out, nil
+}
+
+func (e *evaluator) dependsOn(v any, path []string) {
+	deps := []any{v}
+	if list, ok := v.([]any); ok {
+		deps = list
+	}
+	for _, d := range deps {
+		if name, ok := d.(string); ok && e.removed[name] {
+			e.warn(path, "depends on '%s', which is removed by its condition; CloudFormation rejects this template", name)
+		}
+	}
+}
+
+func (e *evaluator) warn(path []string, format string, args ...any) {
+	e.warnings = append(e.warnings, Warning{Path: validate.Pointer(path...), Message: fmt.Sprintf(format, args...)})
+}
+
+// paramValue é o valor de um parâmetro; list vale para os tipos de lista.
+type paramValue struct {
+	value    string
+	list     bool
+	noEcho   bool
+	deployed bool // só resolvido no deploy (tipos AWS::SSM::Parameter::Value)
+}
+
+func (e *evaluator) loadParameters(res *Result) error {
+	decl, _ := e.tpl["Parameters"].(map[string]any)
+	for k := range e.in.Parameters {
+		if _, ok := decl[k]; !ok {
+			return &Error{Message: fmt.Sprintf("parameter '%s' is not declared in the template", k)}
+		}
+	}
+	for _, name := range sortedKeys(decl) {
+		p, _ := decl[name].(map[string]any)
+		typ, _ := p["Type"].(string)
+		pv := paramValue{
+			list:   typ == "CommaDelimitedList" || strings.HasPrefix(typ, "List<"),
+			noEcho: truthy(p["NoEcho"]),
+		}
+		out := Parameter{Key: name}
+		v, given := e.in.Parameters[name]
+		def, hasDefault := p["Default"]
+		switch {
+		case given:
+			pv.value, out.Source = v, "request"
+		case hasDefault:
+			pv.value, out.Source = scalar(def), "default"
+		default:
+			return &Error{Path: validate.Pointer("Parameters", name), Message: fmt.Sprintf("parameter '%s' has no value and no default", name)}
+		}
+		if strings.HasPrefix(typ, "AWS::SSM::Parameter::Value<") {
+			// O valor é o nome do parâmetro SSM; o conteúdo só existe no deploy
+			pv.deployed, out.Source = true, "deploy"
+			pv.list = strings.Contains(typ, "List<") || strings.Contains(typ, "CommaDelimitedList")
+		}
+		out.Value = pv.value
+		if pv.noEcho {
+			out.Value = Mask
+		}
+		e.params[name] = pv
+		res.Parameters = append(res.Parameters, out)
+	}
+	return nil
+}
+
+// condition avalia e memoriza uma condição; path só entra nas mensagens.
+func (e *evaluator) condition(name string, path []string) (bool, error) {
+	if v, ok := e.conditions[name]; ok {
+		return v, nil
+	}
+	conds, _ := e.tpl["Conditions"].(map[string]any)
+	def, ok := conds[name]
+	if !ok {
+		return false, e.errorf(path, "condition '%s' is not declared", name)
+	}
+	if e.evaluating[name] {
+		return false, e.errorf(path, "condition '%s' references itself", name)
+	}
+	e.evaluating[name] = true
+	defer delete(e.evaluating, name)
+
+	prev := e.inCondition
+	e.inCondition = true
+	v, err := e.eval(def, []string{"Conditions", name})
+	e.inCondition = prev
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, e.errorf([]string{"Conditions", name}, "condition '%s' depends on a value only known at deploy time", name)
+	}
+	e.conditions[name] = b
+	return b, nil
+}
+
+func (e *evaluator) errorf(path []string, format string, args ...any) error {
+	return &Error{Path: validate.Pointer(path...), Message: fmt.Sprintf(format, args...)}
+}
+
+func truthy(v any) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return strings.EqualFold(b, "true")
+	}
+	return false
+}
+
+func sortedKeys(m map[string]any) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func child(path []string, segment string) []string {
+	out := make([]string, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, segment)
+}
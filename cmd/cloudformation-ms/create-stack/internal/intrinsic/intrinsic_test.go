@@ -0,0 +1,243 @@
+package intrinsic
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, src string) map[string]any {
+	t.Helper()
+	var tpl map[string]any
+	if err := json.Unmarshal([]byte(src), &tpl); err != nil {
+		t.Fatalf("invalid test template: %v", err)
+	}
+	return tpl
+}
+
+func evaluate(t *testing.T, src string, params map[string]string) *Result {
+	t.Helper()
+	res, err := Evaluate(Input{Template: parse(t, src), Parameters: params, AccountID: "123456789012", Region: "us-east-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// at devolve o valor em Resources/Outputs seguindo as chaves.
+func at(res *Result, keys ...string) any {
+	var v any = res.Template
+	for _, k := range keys {
+		m, _ := v.(map[string]any)
+		v = m[k]
+	}
+	return v
+}
+
+func TestIfWithNoValue(t *testing.T) {
+	res := evaluate(t, `{
+		"Parameters": {"Env": {"Type": "String", "Default": "dev"}},
+		"Conditions": {"IsProd": {"Fn::Equals": [{"Ref": "Env"}, "prod"]}},
+		"Resources": {"Fn": {"Type": "AWS::Lambda::Function", "Properties": {
+			"ReservedConcurrentExecutions": {"Fn::If": ["IsProd", 10, {"Ref": "AWS::NoValue"}]},
+			"Layers": [{"Fn::If": ["IsProd", "arn:prod-layer", {"Ref": "AWS::NoValue"}]}, "arn:base-layer"],
+			"Environment": {"Variables": {
+				"DEBUG": {"Fn::If": ["IsProd", {"Ref": "AWS::NoValue"}, "1"]},
+				"ENV": {"Ref": "Env"}
+			}}
+		}}}
+	}`, nil)
+
+	if res.Conditions["IsProd"] {
+		t.Fatal("IsProd must be false")
+	}
+	props, _ := at(res, "Resources", "Fn", "Properties").(map[string]any)
+	if _, ok := props["ReservedConcurrentExecutions"]; ok {
+		t.Fatalf("AWS::NoValue must remove the key, got %v", props)
+	}
+	if got, want := props["Layers"], []any{"arn:base-layer"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Layers = %v, want %v", got, want)
+	}
+	if got, want := at(res, "Resources", "Fn", "Properties", "Environment", "Variables"), map[string]any{"DEBUG": "1", "ENV": "dev"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Variables = %v, want %v", got, want)
+	}
+
+	res = evaluate(t, `{
+		"Parameters": {"Env": {"Type": "String"}},
+		"Conditions": {"IsProd": {"Fn::Equals": [{"Ref": "Env"}, "prod"]}},
+		"Resources": {"Q": {"Type": "AWS::SQS::Queue", "Properties": {
+			"DelaySeconds": {"Fn::If": ["IsProd", {"Ref": "AWS::NoValue"}, 5]}
+		}}}
+	}`, map[string]string{"Env": "prod"})
+	if got := at(res, "Resources", "Q", "Properties"); !reflect.DeepEqual(got, map[string]any{}) {
+		t.Fatalf("Properties = %v, want empty", got)
+	}
+}
+
+func TestFalseConditionRemovesItems(t *testing.T) {
+	res := evaluate(t, `{
+		"Parameters": {"CreateBucket": {"Type": "String", "Default": "false"}},
+		"Conditions": {"WithBucket": {"Fn::Equals": [{"Ref": "CreateBucket"}, "true"]}},
+		"Resources": {
+			"Bucket": {"Type": "AWS::S3::Bucket", "Condition": "WithBucket"},
+			"Queue": {"Type": "AWS::SQS::Queue", "DependsOn": ["Bucket"], "Properties": {
+				"QueueName": {"Ref": "Bucket"},
+				"Tags": [{"Key": "bucket", "Value": {"Fn::GetAtt": ["Bucket", "Arn"]}}]
+			}}
+		},
+		"Outputs": {
+			"BucketName": {"Condition": "WithBucket", "Value": {"Ref": "Bucket"}},
+			"QueueUrl": {"Value": {"Ref": "Queue"}}
+		}
+	}`, nil)
+
+	want := []Removed{
+		{Section: "Resources", LogicalID: "Bucket", Type: "AWS::S3::Bucket", Condition: "WithBucket"},
+		{Section: "Outputs", LogicalID: "BucketName", Condition: "WithBucket"},
+	}
+	if !reflect.DeepEqual(res.Removed, want) {
+		t.Fatalf("Removed = %+v, want %+v", res.Removed, want)
+	}
+	if at(res, "Resources", "Bucket") != nil || at(res, "Outputs", "BucketName") != nil {
+		t.Fatal("removed items must leave the template")
+	}
+	if _, ok := res.Template["Conditions"]; ok {
+		t.Fatal("Conditions must leave the resolved template")
+	}
+
+	var paths []string
+	for _, w := range res.Warnings {
+		if !strings.Contains(w.Message, "'Bucket'") {
+			t.Fatalf("unexpected warning: %+v", w)
+		}
+		paths = append(paths, w.Path)
+	}
+	wantPaths := []string{
+		"/Resources/Queue/DependsOn",
+		"/Resources/Queue/Properties/QueueName/Ref",
+		"/Resources/Queue/Properties/Tags/0/Value/Fn::GetAtt",
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Fatalf("warnings at %v, want %v", paths, wantPaths)
+	}
+}
+
+func TestSub(t *testing.T) {
+	cases := []struct {
+		name, sub string
+		want      any
+	}{
+		{"parameters and pseudo parameters", `"${Env}-${AWS::Region}-${AWS::AccountId}"`, "dev-us-east-1-123456789012"},
+		{"escape", `"${!Literal}-${Env}"`, "${Literal}-dev"},
+		{"local variables", `["${Env}-${Suffix}", {"Suffix": {"Fn::Join": ["", ["a", "b"]]}}]`, "dev-ab"},
+		{"attribute stays", `"${Env}-${Bucket.Arn}-${!Literal}"`, map[string]any{"Fn::Sub": "dev-${Bucket.Arn}-${!Literal}"}},
+		{"partly resolved locals", `["${Env}-${Name}-${Id}", {"Name": {"Ref": "Env"}, "Id": {"Ref": "Bucket"}}]`,
+			map[string]any{"Fn::Sub": []any{"dev-dev-${Id}", map[string]any{"Id": map[string]any{"Ref": "Bucket"}}}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := evaluate(t, `{
+				"Parameters": {"Env": {"Type": "String", "Default": "dev"}},
+				"Resources": {"Bucket": {"Type": "AWS::S3::Bucket"}},
+				"Outputs": {"Out": {"Value": {"Fn::Sub": `+tc.sub+`}}}
+			}`, nil)
+			if got := at(res, "Outputs", "Out", "Value"); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFindInMapDefaultValue(t *testing.T) {
+	const tpl = `{
+		"Transform": "AWS::LanguageExtensions",
+		"Parameters": {"Env": {"Type": "String"}},
+		"Mappings": {"Sizes": {"prod": {"Memory": 1024}}},
+		"Resources": {"Fn": {"Type": "AWS::Lambda::Function", "Properties": {
+			"MemorySize": {"Fn::FindInMap": ["Sizes", {"Ref": "Env"}, "Memory", {"DefaultValue": 128}]}
+		}}}
+	}`
+	if got := at(evaluate(t, tpl, map[string]string{"Env": "prod"}), "Resources", "Fn", "Properties", "MemorySize"); got != 1024.0 {
+		t.Fatalf("MemorySize = %v, want the mapped 1024", got)
+	}
+	if got := at(evaluate(t, tpl, map[string]string{"Env": "dev"}), "Resources", "Fn", "Properties", "MemorySize"); got != 128.0 {
+		t.Fatalf("MemorySize = %v, want the default 128", got)
+	}
+
+	_, err := Evaluate(Input{Template: parse(t, `{
+		"Mappings": {"Sizes": {"prod": {"Memory": 1024}}},
+		"Outputs": {"Out": {"Value": {"Fn::FindInMap": ["Sizes", "dev", "Memory"]}}}
+	}`)})
+	var ierr *Error
+	if !errors.As(err, &ierr) || !strings.Contains(ierr.Message, "has no value for 'dev'") {
+		t.Fatalf("expected a missing mapping key error, got %v", err)
+	}
+}
+
+func TestConditionCycle(t *testing.T) {
+	_, err := Evaluate(Input{Template: parse(t, `{
+		"Conditions": {
+			"A": {"Fn::Not": [{"Condition": "B"}]},
+			"B": {"Fn::And": [{"Condition": "A"}, true]}
+		}
+	}`)})
+	var ierr *Error
+	if !errors.As(err, &ierr) || !strings.Contains(ierr.Message, "references itself") {
+		t.Fatalf("expected a condition cycle error, got %v", err)
+	}
+}
+
+func TestNoEcho(t *testing.T) {
+	res := evaluate(t, `{
+		"Parameters": {"Password": {"Type": "String", "NoEcho": true}},
+		"Conditions": {"Default": {"Fn::Equals": [{"Ref": "Password"}, "changeme"]}},
+		"Resources": {"Secret": {"Type": "AWS::SecretsManager::Secret", "Properties": {
+			"SecretString": {"Fn::Sub": "{\"password\":\"${Password}\"}"}
+		}}},
+		"Outputs": {"Weak": {"Value": {"Fn::If": ["Default", "yes", "no"]}}}
+	}`, map[string]string{"Password": "changeme"})
+
+	if !res.Conditions["Default"] {
+		t.Fatal("conditions must compare the real NoEcho value")
+	}
+	if got := at(res, "Outputs", "Weak", "Value"); got != "yes" {
+		t.Fatalf("Weak = %v, want yes", got)
+	}
+	if got := at(res, "Resources", "Secret", "Properties", "SecretString"); got != `{"password":"****"}` {
+		t.Fatalf("SecretString = %v, want the masked value", got)
+	}
+	if want := []Parameter{{Key: "Password", Value: Mask, Source: "request"}}; !reflect.DeepEqual(res.Parameters, want) {
+		t.Fatalf("Parameters = %+v, want %+v", res.Parameters, want)
+	}
+}
+
+func TestSSMParameterTypes(t *testing.T) {
+	res := evaluate(t, `{
+		"Parameters": {
+			"Ami": {"Type": "AWS::SSM::Parameter::Value<AWS::EC2::Image::Id>", "Default": "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64"},
+			"Subnets": {"Type": "AWS::SSM::Parameter::Value<List<String>>", "Default": "/app/subnets"}
+		},
+		"Resources": {"Vm": {"Type": "AWS::EC2::Instance", "Properties": {
+			"ImageId": {"Ref": "Ami"},
+			"SubnetId": {"Fn::Select": [0, {"Ref": "Subnets"}]},
+			"UserData": {"Fn::Base64": {"Fn::Sub": "echo ${Ami}"}}
+		}}}
+	}`, nil)
+
+	props := at(res, "Resources", "Vm", "Properties")
+	want := map[string]any{
+		"ImageId":  map[string]any{"Ref": "Ami"},
+		"SubnetId": map[string]any{"Fn::Select": []any{0.0, map[string]any{"Ref": "Subnets"}}},
+		"UserData": map[string]any{"Fn::Base64": map[string]any{"Fn::Sub": "echo ${Ami}"}},
+	}
+	if !reflect.DeepEqual(props, want) {
+		t.Fatalf("Properties = %#v, want %#v", props, want)
+	}
+	for _, p := range res.Parameters {
+		if p.Source != "deploy" || !strings.HasPrefix(p.Value, "/") {
+			t.Fatalf("SSM parameter %s must keep the parameter name for the deploy, got %+v", p.Key, p)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package inventory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"create-stack-ms/internal/types"
+)
+
+// Deployment é uma operação (create/update/delete) iniciada pela API.
+type Deployment struct {
+	Owner        string
+	Account      string
+	Region       string
+	StackID      string
+	StackName    string
+	Operation    string // "CREATE" | "UPDATE" | "DELETE"
+	TemplateHash string
+	Parameters   []types.Parameter // NoEcho já mascarado
+	RequestID    string
+	StartedAt    time.Time
+	Status       string
+	Lifecycle    string
+
+	// Preenchidos pelo tracker quando a operação termina
+	FinishedAt      time.Time
+	DurationSeconds int64
+	FailureReason   string
+	FailedResource  string
+}
+
+type Store interface {
+	Started(ctx context.Context, d Deployment) error
+	Finished(ctx context.Context, d Deployment) error
+	StackIDs(ctx context.Context, owner, account, region string) (map[string]bool, error)
+	Owns(ctx context.Context, owner, stackID string) (bool, error)
+}
+
+type API interface {
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(ctx context.Context, in *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	dynamodb.QueryAPIClient
+}
+
+// Chaves da tabela (single-table):
+//
+//	pk=STACK#<stackId> sk=OP#<startedAt>   uma por operação (histórico da stack)
+//	pk=STACK#<stackId> sk=LATEST           última operação da stack
+//
+// Só o item LATEST entra no índice gsi1 (gsi1pk=OWNER#<owner>,
+// gsi1sk=<account>#<region>#<stackName>), que responde "todas as stacks do
+// owner" e, com begins_with, "todas as stacks de uma conta".
+const (
+	IndexName = "gsi1"
+	latestKey = "LATEST"
+)
+
+func StackKey(stackID string) string { return "STACK#" + stackID }
+
+func OwnerKey(owner string) string { return "OWNER#" + owner }
+
+func opKey(startedAt time.Time) string {
+	return "OP#" + startedAt.UTC().Format(time.RFC3339Nano)
+}
+
+// TemplateHash identifica o conteúdo do template (sha256 em hex).
+func TemplateHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+type DynamoStore struct {
+	api   API
+	table string
+}
+
+func NewDynamoStore(api API, table string) *DynamoStore {
+	return &DynamoStore{api: api, table: table}
+}
+
+// Started grava o item da operação e substitui o LATEST da stack.
+func (s *DynamoStore) Started(ctx context.Context, d Deployment) error {
+	item := startedItem(d)
+	item["pk"] = &ddbtypes.AttributeValueMemberS{Value: StackKey(d.StackID)}
+	item["sk"] = &ddbtypes.AttributeValueMemberS{Value: opKey(d.StartedAt)}
+	if _, err := s.api.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(s.table), Item: item}); err != nil {
+		return err
+	}
+
+	latest := startedItem(d)
+	latest["pk"] = &ddbtypes.AttributeValueMemberS{Value: StackKey(d.StackID)}
+	latest["sk"] = &ddbtypes.AttributeValueMemberS{Value: latestKey}
+	latest["gsi1pk"] = &ddbtypes.AttributeValueMemberS{Value: OwnerKey(d.Owner)}
+	latest["gsi1sk"] = &ddbtypes.AttributeValueMemberS{Value: d.Account + "#" + d.Region + "#" + d.StackName}
+	_, err := s.api.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(s.table), Item: latest})
+	return err
+}
+
+// Finished grava o status final no item da operação e no LATEST, este só se
+// ainda apontar para a mesma operação (uma mais nova pode ter começado).
+func (s *DynamoStore) Finished(ctx context.Context, d Deployment) error {
+	expr := "SET #status = :status, lifecycle = :lifecycle, finishedAt = :finishedAt, durationSeconds = :duration"
+	values := map[string]ddbtypes.AttributeValue{
+		":status":     &ddbtypes.AttributeValueMemberS{Value: d.Status},
+		":lifecycle":  &ddbtypes.AttributeValueMemberS{Value: d.Lifecycle},
+		":finishedAt": &ddbtypes.AttributeValueMemberS{Value: d.FinishedAt.UTC().Format(time.RFC3339)},
+		":duration":   &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(d.DurationSeconds, 10)},
+		":startedAt":  &ddbtypes.AttributeValueMemberS{Value: d.StartedAt.UTC().Format(time.RFC3339Nano)},
+	}
+	if d.FailureReason != "" {
+		expr += ", failureReason = :reason"
+		values[":reason"] = &ddbtypes.AttributeValueMemberS{Value: d.FailureReason}
+	}
+	if d.FailedResource != "" {
+		expr += ", failedResource = :resource"
+		values[":resource"] = &ddbtypes.AttributeValueMemberS{Value: d.FailedResource}
+	}
+
+	for _, sk := range []string{opKey(d.StartedAt), latestKey} {
+		_, err := s.api.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(s.table),
+			Key: map[string]ddbtypes.AttributeValue{
+				"pk": &ddbtypes.AttributeValueMemberS{Value: StackKey(d.StackID)},
+				"sk": &ddbtypes.AttributeValueMemberS{Value: sk},
+			},
+			UpdateExpression:          aws.String(expr),
+			ConditionExpression:       aws.String("startedAt = :startedAt"),
+			ExpressionAttributeNames:  map[string]string{"#status": "status"},
+			ExpressionAttributeValues: values,
+		})
+		var ccf *ddbtypes.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			// Operação não registrada (ex. tabela criada depois) ou LATEST já é de outra
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StackIDs devolve as stacks do owner na conta e região que já passaram pela
+// API (itens LATEST do gsi1).
+func (s *DynamoStore) StackIDs(ctx context.Context, owner, account, region string) (map[string]bool, error) {
+	out := map[string]bool{}
+	p := dynamodb.NewQueryPaginator(s.api, &dynamodb.QueryInput{
+		TableName:              aws.String(s.table),
+		IndexName:              aws.String(IndexName),
+		KeyConditionExpression: aws.String("gsi1pk = :pk AND begins_with(gsi1sk, :prefix)"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":pk":     &ddbtypes.AttributeValueMemberS{Value: OwnerKey(owner)},
+			":prefix": &ddbtypes.AttributeValueMemberS{Value: account + "#" + region + "#"},
+		},
+		ProjectionExpression: aws.String("stackId"),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			if v, ok := item["stackId"].(*ddbtypes.AttributeValueMemberS); ok && v.Value != "" {
+				out[v.Value] = true
+			}
+		}
+	}
+	return out, nil
+}
+
+// Owns diz se a última operação registrada da stack é do owner.
+func (s *DynamoStore) Owns(ctx context.Context, owner, stackID string) (bool, error) {
+	out, err := s.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key: map[string]ddbtypes.AttributeValue{
+			"pk": &ddbtypes.AttributeValueMemberS{Value: StackKey(stackID)},
+			"sk": &ddbtypes.AttributeValueMemberS{Value: latestKey},
+		},
+		ProjectionExpression:     aws.String("#owner"),
+		ExpressionAttributeNames: map[string]string{"#owner": "owner"},
+	})
+	if err != nil {
+		return false, err
+	}
+	v, ok := out.Item["owner"].(*ddbtypes.AttributeValueMemberS)
+	return ok && v.Value == owner, nil
+}
+
+func startedItem(d Deployment) map[string]ddbtypes.AttributeValue {
+	item := map[string]ddbtypes.AttributeValue{
+		"owner":     &ddbtypes.AttributeValueMemberS{Value: d.Owner},
+		"account":   &ddbtypes.AttributeValueMemberS{Value: d.Account},
+		"region":    &ddbtypes.AttributeValueMemberS{Value: d.Region},
+		"stackId":   &ddbtypes.AttributeValueMemberS{Value: d.StackID},
+		"stackName": &ddbtypes.AttributeValueMemberS{Value: d.StackName},
+		"operation": &ddbtypes.AttributeValueMemberS{Value: d.Operation},
+		"requestId": &ddbtypes.AttributeValueMemberS{Value: d.RequestID},
+		"startedAt": &ddbtypes.AttributeValueMemberS{Value: d.StartedAt.UTC().Format(time.RFC3339Nano)},
+		"status":    &ddbtypes.AttributeValueMemberS{Value: d.Status},
+		"lifecycle": &ddbtypes.AttributeValueMemberS{Value: d.Lifecycle},
+	}
+	if d.TemplateHash != "" {
+		item["templateHash"] = &ddbtypes.AttributeValueMemberS{Value: d.TemplateHash}
+	}
+	if len(d.Parameters) > 0 {
+		params := map[string]ddbtypes.AttributeValue{}
+		for _, p := range d.Parameters {
+			v := p.Value
+			if p.UsePreviousValue {
+				v = "<previous>"
+			}
+			params[p.Key] = &ddbtypes.AttributeValueMemberS{Value: v}
+		}
+		item["parameters"] = &ddbtypes.AttributeValueMemberM{Value: params}
+	}
+	return item
+}
@@ -0,0 +1,393 @@
+package lint
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"create-stack-ms/internal/types"
+	"create-stack-ms/internal/validate"
+)
+
+// EnvVar liga o lint no create-stack: "warn" (padrão) só registra os erros no
+// log; "enforce" rejeita templates com erros; "off" desliga. A especificação
+// embutida é parcial e pode estar atrasada em relação a uma propriedade nova,
+// por isso o padrão não bloqueia.
+const EnvVar = "TEMPLATE_LINT"
+
+const (
+	ModeOff     = "off"
+	ModeWarn    = "warn"
+	ModeEnforce = "enforce"
+)
+
+// ModeFromEnv lê o modo de TEMPLATE_LINT.
+func ModeFromEnv() (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv(EnvVar))); mode {
+	case "", ModeWarn:
+		return ModeWarn, nil
+	case ModeOff, ModeEnforce:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid %s '%s' (use off, warn or enforce)", EnvVar, mode)
+	}
+}
+
+// Error é a falha do lint no create-stack; Findings traz todos os achados.
+type Error struct {
+	Findings []types.Finding
+}
+
+func (e *Error) Error() string {
+	n := 0
+	for _, f := range e.Findings {
+		if f.Severity == validate.SeverityError {
+			n++
+		}
+	}
+	return fmt.Sprintf("template does not match the resource specification: %d errors", n)
+}
+
+// ${Recurso.Atributo} dentro de Fn::Sub; ${!Literal} é escape.
+var subAttr = regexp.MustCompile(`\$\{([^!}.][^}.]*)\.([^}]+)\}`)
+
+type linter struct {
+	spec      *Spec
+	types     map[string]string // recurso -> tipo, só para os tipos da especificação
+	transform bool
+	findings  []types.Finding
+}
+
+// Template confere os recursos do template contra a especificação embutida.
+func Template(tpl map[string]any) []types.Finding {
+	return Bundled().Template(tpl)
+}
+
+// Template confere tipos de recurso, propriedades (nomes, obrigatórias e
+// tipos primitivos) e os atributos usados em Fn::GetAtt e Fn::Sub. Valores
+// com funções intrínsecas não são avaliados. Os achados saem ordenados pelo
+// path (JSON pointer).
+func (s *Spec) Template(tpl map[string]any) []types.Finding {
+	l := &linter{spec: s, types: map[string]string{}, transform: tpl["Transform"] != nil}
+	resources, _ := tpl["Resources"].(map[string]any)
+	for _, name := range sortedKeys(resources) {
+		res, _ := resources[name].(map[string]any)
+		l.resource(name, res)
+	}
+	for _, section := range []string{"Resources", "Outputs"} {
+		if v, ok := tpl[section]; ok {
+			l.walk(v, []string{section})
+		}
+	}
+
+	sort.SliceStable(l.findings, func(i, j int) bool { return l.findings[i].Path < l.findings[j].Path })
+	return l.findings
+}
+
+func (l *linter) add(severity, rule string, path []string, format string, args ...any) {
+	l.findings = append(l.findings, types.Finding{
+		Severity: severity,
+		Rule:     rule,
+		Path:     validate.Pointer(path...),
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) resource(name string, res map[string]any) {
+	typ, _ := res["Type"].(string)
+	if typ == "" || !strings.HasPrefix(typ, "AWS::") || typ == "AWS::CloudFormation::CustomResource" ||
+		(l.transform && strings.HasPrefix(typ, "AWS::Serverless::")) {
+		return // custom resources, módulos e tipos de macros não estão na especificação
+	}
+	path := []string{"Resources", name, "Type"}
+	spec, ok := l.spec.ResourceTypes[typ]
+	if !ok {
+		// Numa especificação parcial, só uma edição: tipos reais fora dela
+		// costumam estar a duas ou três edições de um tipo conhecido
+		limit := 2
+		if l.spec.Partial {
+			limit = 1
+		}
+		hint := suggest(typ, sortedKeys(l.spec.ResourceTypes), limit)
+		switch {
+		case hint != "":
+			l.add(validate.SeverityError, "unknown-resource-type", path, "unknown resource type '%s' (did you mean '%s'?)", typ, hint)
+		case l.spec.Partial:
+			l.add(validate.SeverityInfo, "resource-type-not-covered", path, "resource type '%s' is not in the bundled resource specification; its properties were not checked", typ)
+		default:
+			l.add(validate.SeverityError, "unknown-resource-type", path, "unknown resource type '%s'", typ)
+		}
+		return
+	}
+	l.types[name] = typ
+
+	path[2] = "Properties"
+	props, ok := res["Properties"]
+	if !ok {
+		props = map[string]any{}
+	}
+	if isIntrinsic(props) {
+		return
+	}
+	m, ok := props.(map[string]any)
+	if !ok {
+		l.add(validate.SeverityError, "property-type", path, "Properties of '%s' must be an object", name)
+		return
+	}
+	l.properties(m, spec, typ, typ, path)
+}
+
+// properties confere um objeto contra o tipo spec, chamado label nas
+// mensagens. owner é o tipo de recurso, usado para achar os tipos de
+// propriedade aninhados.
+func (l *linter) properties(m map[string]any, spec TypeSpec, owner, label string, path []string) {
+	names := sortedKeys(spec.Properties)
+	for _, k := range sortedKeys(m) {
+		p, ok := spec.Properties[k]
+		if !ok {
+			if hint := suggest(k, names, nameLimit(k)); hint != "" {
+				l.add(validate.SeverityError, "unknown-property", child(path, k), "unknown property '%s' (did you mean '%s'?)", k, hint)
+			} else {
+				l.add(validate.SeverityError, "unknown-property", child(path, k), "unknown property '%s' for %s", k, label)
+			}
+			continue
+		}
+		l.value(m[k], p.PrimitiveType, p.Type, p.PrimitiveItemType, p.ItemType, owner, child(path, k))
+	}
+	for _, k := range names {
+		if _, ok := m[k]; spec.Properties[k].Required && !ok {
+			l.add(validate.SeverityError, "required-property", path, "missing required property '%s'", k)
+		}
+	}
+}
+
+// value confere um valor contra a declaração da propriedade (ou do item de
+// uma lista/mapa). Tipos de propriedade fora da especificação não são
+// conferidos por dentro.
+func (l *linter) value(v any, primitive, typ, primitiveItem, item, owner string, path []string) {
+	if v == nil || isIntrinsic(v) {
+		return
+	}
+	switch {
+	case primitive != "":
+		l.primitive(v, primitive, path)
+	case typ == "List":
+		list, ok := v.([]any)
+		if !ok {
+			l.add(validate.SeverityError, "property-type", path, "expected a list, got %s", kind(v))
+			return
+		}
+		for i, it := range list {
+			l.value(it, primitiveItem, item, "", "", owner, child(path, strconv.Itoa(i)))
+		}
+	case typ == "Map":
+		m, ok := v.(map[string]any)
+		if !ok {
+			l.add(validate.SeverityError, "property-type", path, "expected an object, got %s", kind(v))
+			return
+		}
+		for _, k := range sortedKeys(m) {
+			l.value(m[k], primitiveItem, item, "", "", owner, child(path, k))
+		}
+	case typ != "":
+		m, ok := v.(map[string]any)
+		if !ok {
+			l.add(validate.SeverityError, "property-type", path, "expected an object (%s), got %s", typ, kind(v))
+			return
+		}
+		if spec, ok := l.spec.propertyType(owner, typ); ok {
+			l.properties(m, spec, owner, owner+"."+typ, path)
+		}
+	}
+}
+
+// primitive segue as conversões que o CloudFormation aceita: números e
+// booleanos podem vir como string.
+func (l *linter) primitive(v any, primitive string, path []string) {
+	ok := true
+	switch primitive {
+	case "String", "Timestamp":
+		switch v.(type) {
+		case map[string]any, []any:
+			ok = false
+		}
+	case "Integer", "Long":
+		switch n := v.(type) {
+		case float64:
+			ok = n == math.Trunc(n)
+		case string:
+			_, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+			ok = err == nil
+		default:
+			ok = false
+		}
+	case "Double":
+		switch n := v.(type) {
+		case float64:
+		case string:
+			_, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+			ok = err == nil
+		default:
+			ok = false
+		}
+	case "Boolean":
+		switch b := v.(type) {
+		case bool:
+		case string:
+			ok = strings.EqualFold(b, "true") || strings.EqualFold(b, "false")
+		default:
+			ok = false
+		}
+	case "Json":
+		switch v.(type) {
+		case float64, bool:
+			ok = false
+		}
+	}
+	if !ok {
+		l.add(validate.SeverityError, "property-type", path, "expected %s, got %s", primitive, describe(v))
+	}
+}
+
+// walk procura Fn::GetAtt e Fn::Sub para conferir os atributos.
+func (l *linter) walk(v any, path []string) {
+	switch val := v.(type) {
+	case []any:
+		for i, it := range val {
+			l.walk(it, child(path, strconv.Itoa(i)))
+		}
+	case map[string]any:
+		if len(val) == 1 {
+			for k, arg := range val {
+				switch k {
+				case "Fn::GetAtt":
+					l.getAtt(arg, child(path, k))
+				case "Fn::Sub":
+					l.sub(arg, child(path, k))
+				}
+			}
+		}
+		for _, k := range sortedKeys(val) {
+			l.walk(val[k], child(path, k))
+		}
+	}
+}
+
+func (l *linter) getAtt(arg any, path []string) {
+	switch a := arg.(type) {
+	case []any:
+		if len(a) != 2 {
+			return
+		}
+		name, _ := a[0].(string)
+		attr, ok := a[1].(string) // o atributo pode ser um Ref
+		if ok {
+			l.attribute(name, attr, path)
+		}
+	case string:
+		if name, attr, ok := strings.Cut(a, "."); ok {
+			l.attribute(name, attr, path)
+		}
+	}
+}
+
+func (l *linter) sub(arg any, path []string) {
+	var text string
+	switch a := arg.(type) {
+	case string:
+		text = a
+	case []any:
+		if len(a) > 0 {
+			text, _ = a[0].(string)
+		}
+	}
+	for _, m := range subAttr.FindAllStringSubmatch(text, -1) {
+		l.attribute(strings.TrimSpace(m[1]), strings.TrimSpace(m[2]), path)
+	}
+}
+
+// attribute confere o atributo de um recurso cujo tipo está na especificação;
+// recursos desconhecidos já são tratados pelo validate.
+func (l *linter) attribute(name, attr string, path []string) {
+	typ, ok := l.types[name]
+	if !ok {
+		return
+	}
+	if typ == "AWS::CloudFormation::Stack" {
+		if !strings.HasPrefix(attr, "Outputs.") {
+			l.add(validate.SeverityError, "invalid-getatt", path, "attribute '%s' of nested stack '%s' must be 'Outputs.<name>'", attr, name)
+		}
+		return
+	}
+	attrs := l.spec.ResourceTypes[typ].Attributes
+	if _, ok := attrs[attr]; ok {
+		return
+	}
+	switch names := sortedKeys(attrs); {
+	case len(names) == 0:
+		l.add(validate.SeverityError, "invalid-getatt", path, "%s '%s' has no attributes for Fn::GetAtt", typ, name)
+	case suggest(attr, names, nameLimit(attr)) != "":
+		l.add(validate.SeverityError, "invalid-getatt", path, "%s has no attribute '%s' (did you mean '%s'?)", typ, attr, suggest(attr, names, nameLimit(attr)))
+	default:
+		l.add(validate.SeverityError, "invalid-getatt", path, "%s has no attribute '%s' (available: %s)", typ, attr, strings.Join(names, ", "))
+	}
+}
+
+// nameLimit é a distância aceita para sugerir nomes de propriedade e atributo.
+func nameLimit(name string) int {
+	if len(name) > 12 {
+		return 3
+	}
+	return 2
+}
+
+// isIntrinsic indica um valor resolvido pelo CloudFormation (Ref, Fn::*).
+func isIntrinsic(v any) bool {
+	m, ok := v.(map[string]any)
+	if !ok || len(m) != 1 {
+		return false
+	}
+	for k := range m {
+		return k == "Ref" || k == "Condition" || strings.HasPrefix(k, "Fn::")
+	}
+	return false
+}
+
+func child(path []string, segment string) []string {
+	out := make([]string, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, segment)
+}
+
+func kind(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "a list"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// describe é kind com o valor, para escalares curtos.
+func describe(v any) string {
+	switch val := v.(type) {
+	case string:
+		if len(val) <= 40 {
+			return fmt.Sprintf("'%s'", val)
+		}
+	case bool, float64:
+		return fmt.Sprintf("%s %v", kind(v), val)
+	}
+	return kind(v)
+}
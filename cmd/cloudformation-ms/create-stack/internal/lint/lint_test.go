@@ -0,0 +1,147 @@
+package lint
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"create-stack-ms/internal/types"
+	"create-stack-ms/internal/validate"
+)
+
+func parse(t *testing.T, src string) map[string]any {
+	t.Helper()
+	var tpl map[string]any
+	if err := json.Unmarshal([]byte(src), &tpl); err != nil {
+		t.Fatalf("invalid test template: %v", err)
+	}
+	return tpl
+}
+
+// only exige exatamente um finding e o devolve.
+func only(t *testing.T, findings []types.Finding) types.Finding {
+	t.Helper()
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d: %+v", len(findings), findings)
+	}
+	return findings[0]
+}
+
+func expect(t *testing.T, f types.Finding, severity, rule, path, message string) {
+	t.Helper()
+	if f.Severity != severity || f.Rule != rule || f.Path != path {
+		t.Fatalf("got %s %s at %s, want %s %s at %s", f.Severity, f.Rule, f.Path, severity, rule, path)
+	}
+	if !strings.Contains(f.Message, message) {
+		t.Fatalf("message %q does not mention %q", f.Message, message)
+	}
+}
+
+func TestValidTemplate(t *testing.T) {
+	tpl := parse(t, `{
+		"Resources": {
+			"Queue": {"Type": "AWS::SQS::Queue", "Properties": {"DelaySeconds": "10", "FifoQueue": true}},
+			"Fn": {"Type": "AWS::Lambda::Function", "Properties": {
+				"Role": {"Fn::GetAtt": ["FnRole", "Arn"]},
+				"Code": {"ZipFile": "exports.handler = async () => {}"},
+				"MemorySize": {"Ref": "Memory"}
+			}},
+			"FnRole": {"Type": "AWS::IAM::Role", "Properties": {"AssumeRolePolicyDocument": {}}},
+			"Custom": {"Type": "Custom::Thing", "Properties": {"Anything": 1}}
+		},
+		"Outputs": {"QueueArn": {"Value": {"Fn::Sub": "${Queue.Arn}"}}}
+	}`)
+	if findings := Template(tpl); len(findings) != 0 {
+		t.Fatalf("expected no findings, got %+v", findings)
+	}
+}
+
+func TestUnknownResourceTypeSuggestion(t *testing.T) {
+	tpl := parse(t, `{"Resources": {"Bucket": {"Type": "AWS::S3::Buckett"}}}`)
+	expect(t, only(t, Template(tpl)), validate.SeverityError, "unknown-resource-type", "/Resources/Bucket/Type", "did you mean 'AWS::S3::Bucket'")
+}
+
+func TestResourceTypeOutsidePartialSpec(t *testing.T) {
+	tpl := parse(t, `{"Resources": {"Job": {"Type": "AWS::Glue::Job", "Properties": {"Command": {}, "Role": "r"}}}}`)
+	expect(t, only(t, Template(tpl)), validate.SeverityInfo, "resource-type-not-covered", "/Resources/Job/Type", "AWS::Glue::Job")
+
+	// Numa especificação completa o mesmo tipo é erro
+	full, err := Load(strings.NewReader(`{"ResourceTypes": {"AWS::S3::Bucket": {"Properties": {}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(t, only(t, full.Template(tpl)), validate.SeverityError, "unknown-resource-type", "/Resources/Job/Type", "unknown resource type 'AWS::Glue::Job'")
+}
+
+func TestMisspelledProperty(t *testing.T) {
+	tpl := parse(t, `{"Resources": {"Queue": {"Type": "AWS::SQS::Queue", "Properties": {"VisibilityTimout": 30}}}}`)
+	expect(t, only(t, Template(tpl)), validate.SeverityError, "unknown-property", "/Resources/Queue/Properties/VisibilityTimout", "did you mean 'VisibilityTimeout'")
+}
+
+func TestMisspelledNestedProperty(t *testing.T) {
+	tpl := parse(t, `{"Resources": {"Fn": {"Type": "AWS::Lambda::Function", "Properties": {"Role": "r", "Code": {"ZipFiles": "x"}}}}}`)
+	expect(t, only(t, Template(tpl)), validate.SeverityError, "unknown-property", "/Resources/Fn/Properties/Code/ZipFiles", "did you mean 'ZipFile'")
+}
+
+func TestMissingRequiredProperty(t *testing.T) {
+	tpl := parse(t, `{"Resources": {"Fn": {"Type": "AWS::Lambda::Function", "Properties": {"Code": {"ZipFile": "x"}}}}}`)
+	expect(t, only(t, Template(tpl)), validate.SeverityError, "required-property", "/Resources/Fn/Properties", "'Role'")
+
+	// Sem Properties, todas as obrigatórias faltam
+	tpl = parse(t, `{"Resources": {"Fn": {"Type": "AWS::Lambda::Function"}}}`)
+	if findings := Template(tpl); len(findings) != 2 {
+		t.Fatalf("expected Code and Role to be missing, got %+v", findings)
+	}
+}
+
+func TestWrongPrimitiveType(t *testing.T) {
+	cases := []struct {
+		name, props, path, message string
+	}{
+		{"integer", `{"DelaySeconds": "ten"}`, "/Resources/Queue/Properties/DelaySeconds", "expected Integer"},
+		{"fractional integer", `{"DelaySeconds": 1.5}`, "/Resources/Queue/Properties/DelaySeconds", "expected Integer"},
+		{"boolean", `{"FifoQueue": "yes"}`, "/Resources/Queue/Properties/FifoQueue", "expected Boolean"},
+		{"string", `{"QueueName": ["a"]}`, "/Resources/Queue/Properties/QueueName", "expected String"},
+		{"list", `{"Tags": {"Key": "a", "Value": "b"}}`, "/Resources/Queue/Properties/Tags", "expected a list"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tpl := parse(t, `{"Resources": {"Queue": {"Type": "AWS::SQS::Queue", "Properties": `+tc.props+`}}}`)
+			expect(t, only(t, Template(tpl)), validate.SeverityError, "property-type", tc.path, tc.message)
+		})
+	}
+}
+
+func TestInvalidGetAtt(t *testing.T) {
+	cases := []struct {
+		name, value, message string
+	}{
+		{"misspelled", `{"Fn::GetAtt": ["Queue", "Arnn"]}`, "did you mean 'Arn'"},
+		{"dotted", `{"Fn::GetAtt": "Queue.QueueNam"}`, "did you mean 'QueueName'"},
+		{"in Fn::Sub", `{"Fn::Sub": "${Queue.Url}"}`, "has no attribute 'Url'"},
+		{"nested stack", `{"Fn::GetAtt": ["Child", "VpcId"]}`, "must be 'Outputs.<name>'"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tpl := parse(t, `{
+				"Resources": {
+					"Queue": {"Type": "AWS::SQS::Queue"},
+					"Child": {"Type": "AWS::CloudFormation::Stack", "Properties": {"TemplateURL": "https://example.com/t.json"}}
+				},
+				"Outputs": {"Out": {"Value": `+tc.value+`}}
+			}`)
+			f := only(t, Template(tpl))
+			if f.Rule != "invalid-getatt" || f.Severity != validate.SeverityError || !strings.Contains(f.Message, tc.message) {
+				t.Fatalf("unexpected finding: %+v", f)
+			}
+		})
+	}
+
+	tpl := parse(t, `{
+		"Resources": {"Child": {"Type": "AWS::CloudFormation::Stack", "Properties": {"TemplateURL": "https://example.com/t.json"}}},
+		"Outputs": {"Out": {"Value": {"Fn::GetAtt": ["Child", "Outputs.VpcId"]}}}
+	}`)
+	if findings := Template(tpl); len(findings) != 0 {
+		t.Fatalf("nested stack outputs are valid attributes, got %+v", findings)
+	}
+}
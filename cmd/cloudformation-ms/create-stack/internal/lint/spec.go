@@ -0,0 +1,140 @@
+package lint
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// resource-spec.json segue o formato do CloudFormationResourceSpecification.json
+// publicado pela AWS, mas cobre só os tipos mais usados na plataforma. O
+// arquivo oficial completo pode substituí-lo sem mudar código.
+//
+//go:embed resource-spec.json
+var bundledSpec []byte
+
+// Spec é a especificação de recursos do CloudFormation.
+type Spec struct {
+	Version       string              `json:"ResourceSpecificationVersion"`
+	PropertyTypes map[string]TypeSpec `json:"PropertyTypes"`
+	ResourceTypes map[string]TypeSpec `json:"ResourceTypes"`
+
+	// Partial não existe no arquivo oficial: marca uma especificação que não
+	// lista todos os tipos, em que um tipo ausente não é necessariamente erro.
+	Partial bool `json:"Partial"`
+}
+
+// TypeSpec descreve um tipo de recurso ou de propriedade.
+type TypeSpec struct {
+	Properties map[string]Property  `json:"Properties"`
+	Attributes map[string]Attribute `json:"Attributes"`
+}
+
+// Property descreve uma propriedade. Type é List, Map ou o nome de um tipo de
+// propriedade; PrimitiveType vale para os escalares.
+type Property struct {
+	Required          bool   `json:"Required"`
+	PrimitiveType     string `json:"PrimitiveType"`
+	Type              string `json:"Type"`
+	ItemType          string `json:"ItemType"`
+	PrimitiveItemType string `json:"PrimitiveItemType"`
+}
+
+type Attribute struct {
+	PrimitiveType     string `json:"PrimitiveType"`
+	Type              string `json:"Type"`
+	PrimitiveItemType string `json:"PrimitiveItemType"`
+}
+
+// Load lê uma especificação no formato oficial.
+func Load(r io.Reader) (*Spec, error) {
+	var s Spec
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid resource specification: %w", err)
+	}
+	if len(s.ResourceTypes) == 0 {
+		return nil, fmt.Errorf("invalid resource specification: no resource types")
+	}
+	return &s, nil
+}
+
+var (
+	bundledOnce sync.Once
+	bundled     *Spec
+)
+
+// Bundled devolve a especificação embutida no binário. Um arquivo inválido é
+// erro de build, por isso o panic.
+func Bundled() *Spec {
+	bundledOnce.Do(func() {
+		s, err := Load(bytes.NewReader(bundledSpec))
+		if err != nil {
+			panic(err)
+		}
+		bundled = s
+	})
+	return bundled
+}
+
+// propertyType acha o tipo de propriedade name declarado em resourceType;
+// Tag e outros tipos comuns aparecem sem prefixo.
+func (s *Spec) propertyType(resourceType, name string) (TypeSpec, bool) {
+	if t, ok := s.PropertyTypes[resourceType+"."+name]; ok {
+		return t, true
+	}
+	t, ok := s.PropertyTypes[name]
+	return t, ok
+}
+
+// suggest devolve o candidato mais parecido com name, a no máximo limit
+// edições, ou "" quando nenhum é próximo o bastante para ser erro de digitação.
+func suggest(name string, candidates []string, limit int) string {
+	best, bestDist := "", limit+1
+	lower := strings.ToLower(name)
+	for _, c := range candidates {
+		d := distance(lower, strings.ToLower(c))
+		if d < bestDist || (d == bestDist && c < best) {
+			best, bestDist = c, d
+		}
+	}
+	if bestDist > limit {
+		return ""
+	}
+	return best
+}
+
+// distance é a distância de Levenshtein entre a e b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,168 @@
+package params
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+
+	"create-stack-ms/internal/types"
+)
+
+const masked = "****"
+
+// Spec é a declaração de um parâmetro no template.
+type Spec struct {
+	Type       string
+	NoEcho     bool
+	HasDefault bool
+}
+
+// SSM indica parâmetros do tipo AWS::SSM::Parameter::Value<...>, cujo valor
+// informado é o nome do parâmetro no SSM da conta alvo.
+func (s Spec) SSM() bool {
+	return strings.HasPrefix(s.Type, "AWS::SSM::Parameter::Value<")
+}
+
+type TemplateSummaryAPI interface {
+	GetTemplateSummary(ctx context.Context, in *cf.GetTemplateSummaryInput, optFns ...func(*cf.Options)) (*cf.GetTemplateSummaryOutput, error)
+}
+
+type SSMAPI interface {
+	GetParameter(ctx context.Context, in *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// Declared lê os parâmetros declarados no template via GetTemplateSummary, o
+// que funciona igual para templates inline e por URL.
+func Declared(ctx context.Context, api TemplateSummaryAPI, templateBody *string, templateURL string) (map[string]Spec, error) {
+	in := &cf.GetTemplateSummaryInput{TemplateBody: templateBody}
+	if templateBody == nil {
+		in.TemplateURL = aws.String(templateURL)
+	}
+	out, err := api.GetTemplateSummary(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	specs := make(map[string]Spec, len(out.Parameters))
+	for _, p := range out.Parameters {
+		specs[aws.ToString(p.ParameterKey)] = Spec{
+			Type:       aws.ToString(p.ParameterType),
+			NoEcho:     aws.ToBool(p.NoEcho),
+			HasDefault: p.DefaultValue != nil,
+		}
+	}
+	return specs, nil
+}
+
+// Build valida os parâmetros da requisição contra o template e monta a lista
+// para o CloudFormation. usePreviousValue só faz sentido em updates.
+func Build(in types.Parameters, specs map[string]Spec, allowPrevious bool) ([]cft.Parameter, error) {
+	var problems []string
+	seen := map[string]bool{}
+	out := make([]cft.Parameter, 0, len(in))
+
+	for _, p := range in {
+		if seen[p.Key] {
+			problems = append(problems, fmt.Sprintf("'%s' given more than once", p.Key))
+			continue
+		}
+		seen[p.Key] = true
+		if _, ok := specs[p.Key]; !ok {
+			problems = append(problems, fmt.Sprintf("'%s' is not declared in the template", p.Key))
+			continue
+		}
+		if p.UsePreviousValue {
+			if !allowPrevious {
+				problems = append(problems, fmt.Sprintf("'%s' uses usePreviousValue, which is only valid for updates", p.Key))
+				continue
+			}
+			if p.Value != "" {
+				problems = append(problems, fmt.Sprintf("'%s' sets both value and usePreviousValue", p.Key))
+				continue
+			}
+			out = append(out, cft.Parameter{ParameterKey: aws.String(p.Key), UsePreviousValue: aws.Bool(true)})
+			continue
+		}
+		out = append(out, cft.Parameter{ParameterKey: aws.String(p.Key), ParameterValue: aws.String(p.Value)})
+	}
+
+	// Em updates, parâmetros omitidos são tratados pelo próprio CloudFormation
+	if !allowPrevious {
+		for key, spec := range specs {
+			if !seen[key] && !spec.HasDefault {
+				problems = append(problems, fmt.Sprintf("'%s' is required (no default value)", key))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("invalid parameters: %s", strings.Join(problems, "; "))
+	}
+	return out, nil
+}
+
+// Masked devolve os parâmetros com os valores NoEcho trocados por "****",
+// próprios para log e para a resposta HTTP.
+func Masked(in types.Parameters, specs map[string]Spec) []types.Parameter {
+	out := make([]types.Parameter, 0, len(in))
+	for _, p := range in {
+		p.ResolvedValue = ""
+		if specs[p.Key].NoEcho && p.Value != "" {
+			p.Value = masked
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// Summary formata os parâmetros para log (k=v separados por espaço), sem
+// nunca escrever valores NoEcho.
+func Summary(in types.Parameters, specs map[string]Spec) string {
+	parts := make([]string, 0, len(in))
+	for _, p := range Masked(in, specs) {
+		if p.UsePreviousValue {
+			parts = append(parts, p.Key+"=<previous>")
+			continue
+		}
+		parts = append(parts, p.Key+"="+p.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ResolveSSM resolve, na conta alvo, o valor dos parâmetros de tipo SSM e o
+// preenche em ResolvedValue. Parâmetros NoEcho e SecureString nunca são
+// resolvidos. Um parâmetro SSM inexistente é erro de requisição: o
+// CloudFormation falharia do mesmo jeito, só que depois. Outros erros (ex.
+// falta de permissão) apenas deixam o ResolvedValue vazio.
+func ResolveSSM(ctx context.Context, api SSMAPI, in []types.Parameter, specs map[string]Spec) error {
+	for i, p := range in {
+		spec := specs[p.Key]
+		if !spec.SSM() || spec.NoEcho || p.UsePreviousValue || p.Value == "" {
+			continue
+		}
+		out, err := api.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(p.Value)})
+		if err != nil {
+			var nf *ssmtypes.ParameterNotFound
+			if errors.As(err, &nf) {
+				return fmt.Errorf("SSM parameter '%s' (for '%s') not found in target account", p.Value, p.Key)
+			}
+			// Sem permissão de leitura no SSM a prévia fica sem valor, mas não bloqueia
+			log.Printf("[WARN] Could not resolve SSM parameter: key=%s name=%s err=%v", p.Key, p.Value, err)
+			continue
+		}
+		if out.Parameter == nil || out.Parameter.Type == ssmtypes.ParameterTypeSecureString {
+			continue
+		}
+		in[i].ResolvedValue = aws.ToString(out.Parameter.Value)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package scan
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvVar liga o scan automático no create-stack: "off" (padrão), "warn"
+// (findings voltam na resposta) ou "enforce" (findings high bloqueiam).
+const EnvVar = "SECURITY_SCAN"
+
+const (
+	ModeOff     = "off"
+	ModeWarn    = "warn"
+	ModeEnforce = "enforce"
+)
+
+// ModeFromEnv lê o modo de SECURITY_SCAN.
+func ModeFromEnv() (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv(EnvVar))); mode {
+	case "", ModeOff:
+		return ModeOff, nil
+	case ModeWarn, ModeEnforce:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid %s '%s' (use off, warn or enforce)", EnvVar, mode)
+	}
+}
+
+// BlockedError é a falha do scan automático em modo enforce; Findings traz
+// todos os findings, não só os que bloquearam.
+type BlockedError struct {
+	Findings []Finding
+}
+
+func (e *BlockedError) Error() string {
+	n := 0
+	for _, f := range e.Findings {
+		if f.Severity == SeverityHigh {
+			n++
+		}
+	}
+	return fmt.Sprintf("security scan failed: %d high severity findings", n)
+}
@@ -0,0 +1,152 @@
+package scan
+
+// SARIF 2.1.0, o formato aceito pelo code scanning do GitHub e por outras
+// ferramentas de revisão de código. Só os campos que o scanner preenche.
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "cloudbuilder-scan"
+)
+
+// Log é um documento SARIF com uma única execução do scanner.
+type Log struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string         `json:"id"`
+	Name                 string         `json:"name"`
+	ShortDescription     sarifText      `json:"shortDescription"`
+	DefaultConfiguration sarifConfig    `json:"defaultConfiguration"`
+	Properties           map[string]any `json:"properties,omitempty"`
+}
+
+type sarifConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifText       `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysical  `json:"physicalLocation"`
+	LogicalLocations []sarifLogical `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysical struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogical struct {
+	Name               string `json:"name"`
+	Kind               string `json:"kind"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+}
+
+// level e securitySeverity traduzem a severidade para o SARIF e para a
+// escala do GitHub (>= 7 high, >= 4 medium).
+func level(severity string) string {
+	switch severity {
+	case SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	}
+	return "note"
+}
+
+func securitySeverity(severity string) string {
+	switch severity {
+	case SeverityHigh:
+		return "8.0"
+	case SeverityMedium:
+		return "5.0"
+	}
+	return "2.0"
+}
+
+// SARIF monta o log SARIF dos findings. artifactURI é o caminho do template
+// no repositório; line, quando não é nil, traduz o JSON pointer do finding na
+// linha do texto original.
+func SARIF(findings []Finding, artifactURI string, line func(pointer string) int) Log {
+	driver := sarifDriver{Name: toolName}
+	index := map[string]int{}
+	for i, r := range rules {
+		index[r.ID] = i
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   r.ID,
+			Name:                 r.Name,
+			ShortDescription:     sarifText{Text: r.Description},
+			DefaultConfiguration: sarifConfig{Level: level(r.Severity)},
+			Properties: map[string]any{
+				"tags":              []string{"security", "cloudformation"},
+				"security-severity": securitySeverity(r.Severity),
+			},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		loc := sarifLocation{PhysicalLocation: sarifPhysical{ArtifactLocation: sarifArtifact{URI: artifactURI}}}
+		if line != nil {
+			if n := line(f.Path); n > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: n}
+			}
+		}
+		if f.LogicalID != "" {
+			kind := "resource"
+			if f.ResourceType == "" {
+				kind = "parameter"
+			}
+			loc.LogicalLocations = []sarifLogical{{Name: f.LogicalID, Kind: kind, FullyQualifiedName: f.Path}}
+		}
+		results = append(results, sarifResult{
+			RuleID:    f.RuleID,
+			RuleIndex: index[f.RuleID],
+			Level:     level(f.Severity),
+			Message:   sarifText{Text: f.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	return Log{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
@@ -0,0 +1,322 @@
+package scan
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"create-stack-ms/internal/validate"
+)
+
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// Rule é uma regra do pacote de segurança. As regras não dependem de conta
+// nem de região: o scanner olha só o template.
+type Rule struct {
+	ID          string
+	Name        string
+	Severity    string
+	Description string
+}
+
+var rules = []Rule{
+	{"iam-wildcard-action", "IAMWildcardAction", SeverityHigh, "IAM policy allows every action of a service (\"*\" or \"service:*\")"},
+	{"iam-wildcard-resource", "IAMWildcardResource", SeverityMedium, "IAM policy allows actions on every resource (\"*\")"},
+	{"iam-allow-not-action", "IAMAllowNotAction", SeverityMedium, "IAM policy uses Allow with NotAction, which grants everything not listed"},
+	{"s3-encryption", "S3BucketEncryption", SeverityHigh, "S3 bucket without default encryption (BucketEncryption)"},
+	{"rds-encryption", "RDSStorageEncryption", SeverityHigh, "RDS instance or cluster without StorageEncrypted"},
+	{"ebs-encryption", "EBSEncryption", SeverityHigh, "EBS volume without Encrypted"},
+	{"efs-encryption", "EFSEncryption", SeverityMedium, "EFS file system without Encrypted"},
+	{"s3-access-logging", "S3AccessLogging", SeverityMedium, "S3 bucket without server access logging (LoggingConfiguration)"},
+	{"elb-access-logging", "LoadBalancerAccessLogging", SeverityMedium, "load balancer without access logs"},
+	{"plaintext-secret-default", "PlaintextSecretDefault", SeverityHigh, "secret-like parameter with a plaintext Default value"},
+	{"secret-parameter-no-echo", "SecretParameterNoEcho", SeverityMedium, "secret-like parameter without NoEcho"},
+	{"public-ssh", "PublicSSH", SeverityHigh, "security group opens SSH (22) to 0.0.0.0/0 or ::/0"},
+	{"public-rdp", "PublicRDP", SeverityHigh, "security group opens RDP (3389) to 0.0.0.0/0 or ::/0"},
+}
+
+// Rules devolve o pacote de regras, na ordem em que é avaliado.
+func Rules() []Rule {
+	return append([]Rule(nil), rules...)
+}
+
+func ruleByID(id string) Rule {
+	for _, r := range rules {
+		if r.ID == id {
+			return r
+		}
+	}
+	panic("scan: unknown rule " + id)
+}
+
+// Finding é um problema de segurança. LogicalID é o recurso ou o parâmetro
+// (ResourceType vazio); Path é um JSON pointer no template.
+type Finding struct {
+	RuleID       string
+	Severity     string
+	LogicalID    string
+	ResourceType string
+	Path         string
+	Message      string
+}
+
+// HasHigh indica se algum finding tem severidade high.
+func HasHigh(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityHigh {
+			return true
+		}
+	}
+	return false
+}
+
+// Nomes de parâmetro que costumam guardar segredos.
+var secretName = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|private_?key|credential)`)
+
+// Template roda todas as regras sobre o template (JSON já decodificado).
+// Só literais são avaliados: intrínsecas não geram findings.
+func Template(tpl map[string]any) []Finding {
+	s := &scanner{tpl: tpl}
+	if res, ok := tpl["Resources"].(map[string]any); ok {
+		for name := range res {
+			s.names = append(s.names, name)
+		}
+		sort.Strings(s.names)
+	}
+
+	s.parameters()
+	for _, name := range s.names {
+		r, _ := tpl["Resources"].(map[string]any)[name].(map[string]any)
+		typ, _ := r["Type"].(string)
+		props, _ := r["Properties"].(map[string]any)
+		s.resource(name, typ, props)
+	}
+	return s.findings
+}
+
+type scanner struct {
+	tpl      map[string]any
+	names    []string
+	findings []Finding
+}
+
+func (s *scanner) add(ruleID, logicalID, resourceType, ptr, format string, args ...any) {
+	s.findings = append(s.findings, Finding{
+		RuleID:       ruleID,
+		Severity:     ruleByID(ruleID).Severity,
+		LogicalID:    logicalID,
+		ResourceType: resourceType,
+		Path:         ptr,
+		Message:      fmt.Sprintf(format, args...),
+	})
+}
+
+func (s *scanner) parameters() {
+	params, _ := s.tpl["Parameters"].(map[string]any)
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !secretName.MatchString(name) {
+			continue
+		}
+		decl, _ := params[name].(map[string]any)
+		if def, ok := literal(decl["Default"]); ok && def != "" {
+			s.add("plaintext-secret-default", name, "", validate.Pointer("Parameters", name, "Default"),
+				"parameter '%s' looks like a secret and has a plaintext Default (use a dynamic reference to Secrets Manager or SSM)", name)
+		}
+		if noEcho, _ := literal(decl["NoEcho"]); !strings.EqualFold(noEcho, "true") {
+			s.add("secret-parameter-no-echo", name, "", validate.Pointer("Parameters", name),
+				"parameter '%s' looks like a secret but is not NoEcho", name)
+		}
+	}
+}
+
+func (s *scanner) resource(name, typ string, props map[string]any) {
+	prop := func(segments ...string) string {
+		return validate.Pointer(append([]string{"Resources", name, "Properties"}, segments...)...)
+	}
+
+	switch typ {
+	case "AWS::IAM::Policy", "AWS::IAM::ManagedPolicy":
+		s.policyDocument(name, typ, props["PolicyDocument"], prop("PolicyDocument"))
+	case "AWS::IAM::Role", "AWS::IAM::User", "AWS::IAM::Group":
+		policies, _ := props["Policies"].([]any)
+		for i, p := range policies {
+			policy, _ := p.(map[string]any)
+			s.policyDocument(name, typ, policy["PolicyDocument"], prop("Policies", strconv.Itoa(i), "PolicyDocument"))
+		}
+
+	case "AWS::S3::Bucket":
+		enc, _ := props["BucketEncryption"].(map[string]any)
+		if rules, ok := enc["ServerSideEncryptionConfiguration"].([]any); !ok || len(rules) == 0 {
+			s.add("s3-encryption", name, typ, prop("BucketEncryption"), "bucket '%s' has no default encryption", name)
+		}
+		if _, ok := props["LoggingConfiguration"]; !ok {
+			s.add("s3-access-logging", name, typ, prop("LoggingConfiguration"), "bucket '%s' has no server access logging", name)
+		}
+	case "AWS::RDS::DBInstance", "AWS::RDS::DBCluster":
+		// Instâncias de um cluster Aurora herdam a criptografia do cluster
+		if _, ok := props["DBClusterIdentifier"]; ok && typ == "AWS::RDS::DBInstance" {
+			return
+		}
+		s.requireTrue("rds-encryption", name, typ, props["StorageEncrypted"], prop("StorageEncrypted"), "StorageEncrypted")
+	case "AWS::EC2::Volume":
+		s.requireTrue("ebs-encryption", name, typ, props["Encrypted"], prop("Encrypted"), "Encrypted")
+	case "AWS::EFS::FileSystem":
+		s.requireTrue("efs-encryption", name, typ, props["Encrypted"], prop("Encrypted"), "Encrypted")
+
+	case "AWS::ElasticLoadBalancingV2::LoadBalancer":
+		if lbType, _ := literal(props["Type"]); lbType == "gateway" {
+			return // Gateway Load Balancers não têm access logs
+		}
+		attrs, _ := props["LoadBalancerAttributes"].([]any)
+		for _, a := range attrs {
+			attr, _ := a.(map[string]any)
+			key, _ := literal(attr["Key"])
+			value, ok := literal(attr["Value"])
+			if key == "access_logs.s3.enabled" && (!ok || strings.EqualFold(value, "true")) {
+				return
+			}
+		}
+		s.add("elb-access-logging", name, typ, prop("LoadBalancerAttributes"), "load balancer '%s' does not enable access_logs.s3.enabled", name)
+	case "AWS::ElasticLoadBalancing::LoadBalancer":
+		policy, _ := props["AccessLoggingPolicy"].(map[string]any)
+		enabled, set := policy["Enabled"]
+		if v, ok := literal(enabled); set && (!ok || strings.EqualFold(v, "true")) {
+			return
+		}
+		s.add("elb-access-logging", name, typ, prop("AccessLoggingPolicy"), "load balancer '%s' has no AccessLoggingPolicy enabled", name)
+
+	case "AWS::EC2::SecurityGroup":
+		ingress, _ := props["SecurityGroupIngress"].([]any)
+		for i, r := range ingress {
+			rule, _ := r.(map[string]any)
+			s.ingress(name, typ, rule, prop("SecurityGroupIngress", strconv.Itoa(i)))
+		}
+	case "AWS::EC2::SecurityGroupIngress":
+		s.ingress(name, typ, props, prop())
+	}
+}
+
+// requireTrue gera o finding quando a propriedade falta ou é false literal.
+func (s *scanner) requireTrue(ruleID, name, typ string, v any, ptr, property string) {
+	if v == nil {
+		s.add(ruleID, name, typ, ptr, "resource '%s' does not set %s", name, property)
+		return
+	}
+	if b, ok := literal(v); ok && !strings.EqualFold(b, "true") {
+		s.add(ruleID, name, typ, ptr, "resource '%s' sets %s to %s", name, property, b)
+	}
+}
+
+func (s *scanner) policyDocument(name, typ string, doc any, ptr string) {
+	d, _ := doc.(map[string]any)
+	stmts := list(d["Statement"])
+	for i, st := range stmts {
+		stmt, _ := st.(map[string]any)
+		if effect, _ := literal(stmt["Effect"]); effect != "Allow" {
+			continue
+		}
+		at := ptr + "/Statement"
+		if _, isList := d["Statement"].([]any); isList {
+			at += "/" + strconv.Itoa(i)
+		}
+
+		for _, a := range list(stmt["Action"]) {
+			if action, ok := literal(a); ok && (action == "*" || strings.HasSuffix(action, ":*")) {
+				s.add("iam-wildcard-action", name, typ, at+"/Action", "resource '%s' allows action '%s'", name, action)
+			}
+		}
+		if _, ok := stmt["NotAction"]; ok {
+			s.add("iam-allow-not-action", name, typ, at+"/NotAction", "resource '%s' uses Allow with NotAction", name)
+		}
+		for _, r := range list(stmt["Resource"]) {
+			if res, ok := literal(r); ok && res == "*" {
+				s.add("iam-wildcard-resource", name, typ, at+"/Resource", "resource '%s' allows actions on Resource '*'", name)
+				break
+			}
+		}
+	}
+}
+
+func (s *scanner) ingress(name, typ string, rule map[string]any, ptr string) {
+	cidr, _ := literal(rule["CidrIp"])
+	cidr6, _ := literal(rule["CidrIpv6"])
+	source := cidr
+	if cidr != "0.0.0.0/0" {
+		if cidr6 != "::/0" {
+			return
+		}
+		source = cidr6
+	}
+
+	// SSH e RDP são TCP: só tcp e "todos os protocolos" abrem as portas. ICMP
+	// usa FromPort/ToPort para tipo e código (-1 = todos), não para portas.
+	// Protocolo que não é literal conta como tcp.
+	from, to := 0, 65535
+	proto, isLiteral := literal(rule["IpProtocol"])
+	proto = strings.ToLower(proto)
+	switch {
+	case proto == "-1" || proto == "all":
+	case proto == "tcp" || proto == "6" || !isLiteral:
+		// Portas que não são literais contam como o intervalo inteiro
+		if n, ok := port(rule["FromPort"]); ok {
+			from = n
+		}
+		if n, ok := port(rule["ToPort"]); ok {
+			to = n
+		}
+	default:
+		return
+	}
+	if from <= 22 && 22 <= to {
+		s.add("public-ssh", name, typ, ptr, "resource '%s' opens port 22 to %s", name, source)
+	}
+	if from <= 3389 && 3389 <= to {
+		s.add("public-rdp", name, typ, ptr, "resource '%s' opens port 3389 to %s", name, source)
+	}
+}
+
+// literal devolve o valor de strings, números e booleanos; ok=false para
+// intrínsecas e ausentes.
+func literal(v any) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(x), true
+	}
+	return "", false
+}
+
+func port(v any) (int, bool) {
+	s, ok := literal(v)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n >= 0
+}
+
+// list aceita um valor único ou uma lista, como Action e Resource nas policies.
+func list(v any) []any {
+	if l, ok := v.([]any); ok {
+		return l
+	}
+	if v == nil {
+		return nil
+	}
+	return []any{v}
+}
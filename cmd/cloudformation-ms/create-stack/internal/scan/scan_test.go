@@ -0,0 +1,297 @@
+package scan
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func ingressTemplate(rule map[string]any) map[string]any {
+	return map[string]any{
+		"Resources": map[string]any{
+			"Rule": map[string]any{
+				"Type":       "AWS::EC2::SecurityGroupIngress",
+				"Properties": rule,
+			},
+		},
+	}
+}
+
+func ingressRules(findings []Finding) []string {
+	var out []string
+	for _, f := range findings {
+		if f.RuleID == "public-ssh" || f.RuleID == "public-rdp" {
+			out = append(out, f.RuleID)
+		}
+	}
+	return out
+}
+
+func TestIngressProtocols(t *testing.T) {
+	both := []string{"public-ssh", "public-rdp"}
+	cases := []struct {
+		name string
+		rule map[string]any
+		want []string
+	}{
+		{"icmp all types", map[string]any{"IpProtocol": "icmp", "FromPort": -1.0, "ToPort": -1.0, "CidrIp": "0.0.0.0/0"}, nil},
+		{"icmp by number", map[string]any{"IpProtocol": "1", "FromPort": -1.0, "ToPort": -1.0, "CidrIp": "0.0.0.0/0"}, nil},
+		{"icmpv6", map[string]any{"IpProtocol": "icmpv6", "FromPort": -1.0, "ToPort": -1.0, "CidrIpv6": "::/0"}, nil},
+		{"icmpv6 by number", map[string]any{"IpProtocol": "58", "FromPort": -1.0, "ToPort": -1.0, "CidrIpv6": "::/0"}, nil},
+		{"udp on 22", map[string]any{"IpProtocol": "udp", "FromPort": 22.0, "ToPort": 22.0, "CidrIp": "0.0.0.0/0"}, nil},
+		{"tcp on 22", map[string]any{"IpProtocol": "tcp", "FromPort": 22.0, "ToPort": 22.0, "CidrIp": "0.0.0.0/0"}, []string{"public-ssh"}},
+		{"tcp by number on 3389", map[string]any{"IpProtocol": 6.0, "FromPort": 3389.0, "ToPort": 3389.0, "CidrIpv6": "::/0"}, []string{"public-rdp"}},
+		{"tcp upper case", map[string]any{"IpProtocol": "TCP", "FromPort": 0.0, "ToPort": 65535.0, "CidrIp": "0.0.0.0/0"}, both},
+		{"tcp on 443", map[string]any{"IpProtocol": "tcp", "FromPort": 443.0, "ToPort": 443.0, "CidrIp": "0.0.0.0/0"}, nil},
+		{"all protocols", map[string]any{"IpProtocol": "-1", "CidrIp": "0.0.0.0/0"}, both},
+		{"tcp from a private range", map[string]any{"IpProtocol": "tcp", "FromPort": 22.0, "ToPort": 22.0, "CidrIp": "10.0.0.0/8"}, nil},
+		{"protocol from a parameter", map[string]any{"IpProtocol": map[string]any{"Ref": "Protocol"}, "FromPort": 22.0, "ToPort": 22.0, "CidrIp": "0.0.0.0/0"}, []string{"public-ssh"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ingressRules(Template(ingressTemplate(tc.rule)))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIngressInSecurityGroup(t *testing.T) {
+	tpl := map[string]any{
+		"Resources": map[string]any{
+			"Web": map[string]any{
+				"Type": "AWS::EC2::SecurityGroup",
+				"Properties": map[string]any{
+					"SecurityGroupIngress": []any{
+						map[string]any{"IpProtocol": "icmp", "FromPort": -1.0, "ToPort": -1.0, "CidrIp": "0.0.0.0/0"},
+						map[string]any{"IpProtocol": "tcp", "FromPort": 22.0, "ToPort": 22.0, "CidrIp": "0.0.0.0/0"},
+					},
+				},
+			},
+		},
+	}
+	findings := Template(tpl)
+	var paths []string
+	for _, f := range findings {
+		if f.RuleID == "public-ssh" || f.RuleID == "public-rdp" {
+			paths = append(paths, f.RuleID+" "+f.Path)
+		}
+	}
+	want := []string{"public-ssh /Resources/Web/Properties/SecurityGroupIngress/1"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("got %v, want %v", paths, want)
+	}
+}
+
+func resourceTemplate(typ string, props map[string]any) map[string]any {
+	return map[string]any{
+		"Resources": map[string]any{
+			"Res": map[string]any{"Type": typ, "Properties": props},
+		},
+	}
+}
+
+// matching devolve "regra path" dos findings das regras pedidas.
+func matching(findings []Finding, ids ...string) []string {
+	var out []string
+	for _, f := range findings {
+		for _, id := range ids {
+			if f.RuleID == id {
+				out = append(out, f.RuleID+" "+f.Path)
+			}
+		}
+	}
+	return out
+}
+
+func TestIAMPolicies(t *testing.T) {
+	const doc = "/Resources/Res/Properties/PolicyDocument/Statement"
+	cases := []struct {
+		name      string
+		statement any
+		want      []string
+	}{
+		{"scoped", map[string]any{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::app/*"}, nil},
+		{"every action", map[string]any{"Effect": "Allow", "Action": "*", "Resource": "arn:aws:s3:::app/*"}, []string{"iam-wildcard-action " + doc + "/Action"}},
+		{"every action of a service", []any{
+			map[string]any{"Effect": "Allow", "Action": []any{"s3:GetObject", "s3:*"}, "Resource": "arn:aws:s3:::app/*"},
+		}, []string{"iam-wildcard-action " + doc + "/0/Action"}},
+		{"every resource", map[string]any{"Effect": "Allow", "Action": "s3:GetObject", "Resource": []any{"arn:aws:s3:::app/*", "*"}}, []string{"iam-wildcard-resource " + doc + "/Resource"}},
+		{"allow with NotAction", map[string]any{"Effect": "Allow", "NotAction": "iam:*", "Resource": "arn:aws:s3:::app/*"}, []string{"iam-allow-not-action " + doc + "/NotAction"}},
+		{"deny is ignored", map[string]any{"Effect": "Deny", "Action": "*", "NotAction": "iam:*", "Resource": "*"}, nil},
+		{"intrinsic resource", map[string]any{"Effect": "Allow", "Action": "s3:GetObject", "Resource": map[string]any{"Fn::GetAtt": []any{"Bucket", "Arn"}}}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tpl := resourceTemplate("AWS::IAM::Policy", map[string]any{
+				"PolicyDocument": map[string]any{"Statement": tc.statement},
+			})
+			got := matching(Template(tpl), "iam-wildcard-action", "iam-wildcard-resource", "iam-allow-not-action")
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	role := resourceTemplate("AWS::IAM::Role", map[string]any{
+		"Policies": []any{map[string]any{"PolicyDocument": map[string]any{
+			"Statement": []any{map[string]any{"Effect": "Allow", "Action": "*", "Resource": "*"}},
+		}}},
+	})
+	want := []string{
+		"iam-wildcard-action /Resources/Res/Properties/Policies/0/PolicyDocument/Statement/0/Action",
+		"iam-wildcard-resource /Resources/Res/Properties/Policies/0/PolicyDocument/Statement/0/Resource",
+	}
+	if got := matching(Template(role), "iam-wildcard-action", "iam-wildcard-resource"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("inline role policy: got %v, want %v", got, want)
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	encrypted := map[string]any{"ServerSideEncryptionConfiguration": []any{
+		map[string]any{"ServerSideEncryptionByDefault": map[string]any{"SSEAlgorithm": "aws:kms"}},
+	}}
+	cases := []struct {
+		name  string
+		typ   string
+		props map[string]any
+		want  []string
+	}{
+		{"s3 without encryption", "AWS::S3::Bucket", nil, []string{"s3-encryption /Resources/Res/Properties/BucketEncryption"}},
+		{"s3 with empty rules", "AWS::S3::Bucket", map[string]any{"BucketEncryption": map[string]any{"ServerSideEncryptionConfiguration": []any{}}}, []string{"s3-encryption /Resources/Res/Properties/BucketEncryption"}},
+		{"s3 encrypted", "AWS::S3::Bucket", map[string]any{"BucketEncryption": encrypted}, nil},
+		{"rds instance unset", "AWS::RDS::DBInstance", map[string]any{"Engine": "postgres"}, []string{"rds-encryption /Resources/Res/Properties/StorageEncrypted"}},
+		{"rds instance false", "AWS::RDS::DBInstance", map[string]any{"StorageEncrypted": false}, []string{"rds-encryption /Resources/Res/Properties/StorageEncrypted"}},
+		{"rds instance encrypted", "AWS::RDS::DBInstance", map[string]any{"StorageEncrypted": "true"}, nil},
+		{"aurora instance", "AWS::RDS::DBInstance", map[string]any{"DBClusterIdentifier": map[string]any{"Ref": "Cluster"}}, nil},
+		{"rds cluster unset", "AWS::RDS::DBCluster", map[string]any{"Engine": "aurora-postgresql"}, []string{"rds-encryption /Resources/Res/Properties/StorageEncrypted"}},
+		{"rds cluster from a parameter", "AWS::RDS::DBCluster", map[string]any{"StorageEncrypted": map[string]any{"Ref": "Encrypt"}}, nil},
+		{"ebs unset", "AWS::EC2::Volume", map[string]any{"Size": 10.0}, []string{"ebs-encryption /Resources/Res/Properties/Encrypted"}},
+		{"ebs encrypted", "AWS::EC2::Volume", map[string]any{"Encrypted": true}, nil},
+		{"efs false", "AWS::EFS::FileSystem", map[string]any{"Encrypted": "false"}, []string{"efs-encryption /Resources/Res/Properties/Encrypted"}},
+		{"efs encrypted", "AWS::EFS::FileSystem", map[string]any{"Encrypted": true}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := matching(Template(resourceTemplate(tc.typ, tc.props)), "s3-encryption", "rds-encryption", "ebs-encryption", "efs-encryption")
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAccessLogging(t *testing.T) {
+	logs := func(enabled any) map[string]any {
+		return map[string]any{"LoadBalancerAttributes": []any{map[string]any{"Key": "access_logs.s3.enabled", "Value": enabled}}}
+	}
+	cases := []struct {
+		name  string
+		typ   string
+		props map[string]any
+		want  []string
+	}{
+		{"s3 without logging", "AWS::S3::Bucket", nil, []string{"s3-access-logging /Resources/Res/Properties/LoggingConfiguration"}},
+		{"s3 with logging", "AWS::S3::Bucket", map[string]any{"LoggingConfiguration": map[string]any{"DestinationBucketName": "logs"}}, nil},
+		{"alb without attributes", "AWS::ElasticLoadBalancingV2::LoadBalancer", nil, []string{"elb-access-logging /Resources/Res/Properties/LoadBalancerAttributes"}},
+		{"alb logs disabled", "AWS::ElasticLoadBalancingV2::LoadBalancer", logs("false"), []string{"elb-access-logging /Resources/Res/Properties/LoadBalancerAttributes"}},
+		{"alb logs enabled", "AWS::ElasticLoadBalancingV2::LoadBalancer", logs("true"), nil},
+		{"alb logs from a parameter", "AWS::ElasticLoadBalancingV2::LoadBalancer", logs(map[string]any{"Ref": "Logs"}), nil},
+		{"gateway load balancer", "AWS::ElasticLoadBalancingV2::LoadBalancer", map[string]any{"Type": "gateway"}, nil},
+		{"classic without policy", "AWS::ElasticLoadBalancing::LoadBalancer", nil, []string{"elb-access-logging /Resources/Res/Properties/AccessLoggingPolicy"}},
+		{"classic disabled", "AWS::ElasticLoadBalancing::LoadBalancer", map[string]any{"AccessLoggingPolicy": map[string]any{"Enabled": false}}, []string{"elb-access-logging /Resources/Res/Properties/AccessLoggingPolicy"}},
+		{"classic enabled", "AWS::ElasticLoadBalancing::LoadBalancer", map[string]any{"AccessLoggingPolicy": map[string]any{"Enabled": true}}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := matching(Template(resourceTemplate(tc.typ, tc.props)), "s3-access-logging", "elb-access-logging")
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSecretParameters(t *testing.T) {
+	cases := []struct {
+		name string
+		decl map[string]any
+		want []string
+	}{
+		{"no echo without default", map[string]any{"Type": "String", "NoEcho": true}, nil},
+		{"no echo as string", map[string]any{"Type": "String", "NoEcho": "true"}, nil},
+		{"echoed", map[string]any{"Type": "String"}, []string{"secret-parameter-no-echo /Parameters/DbPassword"}},
+		{"plaintext default", map[string]any{"Type": "String", "NoEcho": true, "Default": "hunter2"}, []string{"plaintext-secret-default /Parameters/DbPassword/Default"}},
+		{"empty default", map[string]any{"Type": "String", "NoEcho": true, "Default": ""}, nil},
+		{"both", map[string]any{"Type": "String", "Default": "hunter2"}, []string{
+			"plaintext-secret-default /Parameters/DbPassword/Default",
+			"secret-parameter-no-echo /Parameters/DbPassword",
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tpl := map[string]any{"Parameters": map[string]any{
+				"DbPassword":   tc.decl,
+				"InstanceType": map[string]any{"Type": "String", "Default": "t3.micro"},
+			}}
+			got := matching(Template(tpl), "plaintext-secret-default", "secret-parameter-no-echo")
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	tpl := map[string]any{
+		"Parameters": map[string]any{"ApiToken": map[string]any{"Type": "String", "NoEcho": true, "Default": "abc"}},
+		"Resources": map[string]any{
+			"Data": map[string]any{"Type": "AWS::EFS::FileSystem", "Properties": map[string]any{"Encrypted": true}},
+			"Disk": map[string]any{"Type": "AWS::EC2::Volume", "Properties": map[string]any{"Encrypted": false}},
+		},
+	}
+	findings := Template(tpl)
+	lines := map[string]int{"/Parameters/ApiToken/Default": 4}
+	log := SARIF(findings, "templates/app.yaml", func(pointer string) int { return lines[pointer] })
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "cloudbuilder-scan" || len(run.Tool.Driver.Rules) != len(Rules()) {
+		t.Fatalf("driver must list every rule, got %d", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", run.Results)
+	}
+
+	param, disk := run.Results[0], run.Results[1]
+	if param.RuleID != "plaintext-secret-default" || param.Level != "error" {
+		t.Fatalf("unexpected parameter result: %+v", param)
+	}
+	if r := run.Tool.Driver.Rules[param.RuleIndex]; r.ID != param.RuleID || r.Properties["security-severity"] != "8.0" {
+		t.Fatalf("ruleIndex %d points to %s", param.RuleIndex, r.ID)
+	}
+	loc := param.Locations[0]
+	if loc.PhysicalLocation.ArtifactLocation.URI != "templates/app.yaml" || loc.PhysicalLocation.Region == nil || loc.PhysicalLocation.Region.StartLine != 4 {
+		t.Fatalf("unexpected physical location: %+v", loc.PhysicalLocation)
+	}
+	if len(loc.LogicalLocations) != 1 || loc.LogicalLocations[0].Kind != "parameter" || loc.LogicalLocations[0].Name != "ApiToken" {
+		t.Fatalf("unexpected logical location: %+v", loc.LogicalLocations)
+	}
+
+	// Sem linha conhecida, a região fica de fora
+	if disk.RuleID != "ebs-encryption" || disk.Locations[0].PhysicalLocation.Region != nil || disk.Locations[0].LogicalLocations[0].Kind != "resource" {
+		t.Fatalf("unexpected resource result: %+v", disk)
+	}
+
+	b, err := json.Marshal(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"$schema":"https://json.schemastore.org/sarif-2.1.0.json"`) {
+		t.Fatalf("missing $schema: %s", b)
+	}
+}
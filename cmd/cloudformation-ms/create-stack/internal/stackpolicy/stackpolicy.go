@@ -0,0 +1,211 @@
+package stackpolicy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaxBodyBytes é o limite do CloudFormation para StackPolicyBody.
+const MaxBodyBytes = 16384
+
+var actions = map[string]bool{
+	"Update:*":       true,
+	"Update:Modify":  true,
+	"Update:Replace": true,
+	"Update:Delete":  true,
+}
+
+var statementFields = map[string]bool{
+	"Effect":      true,
+	"Action":      true,
+	"NotAction":   true,
+	"Principal":   true,
+	"Resource":    true,
+	"NotResource": true,
+	"Condition":   true,
+}
+
+// Resource é "*" ou LogicalResourceId/<id>, com * e ? como curingas no id.
+var resourcePattern = regexp.MustCompile(`^(\*|LogicalResourceId/[A-Za-z0-9*?]+)$`)
+
+// Normalize valida uma stack policy recebida como objeto JSON ou como string
+// com o JSON, e devolve o JSON compacto enviado ao CloudFormation. Sem policy
+// (campo ausente, null ou "") devolve "".
+func Normalize(raw json.RawMessage) (string, error) {
+	src := strings.TrimSpace(string(raw))
+	if strings.HasPrefix(src, `"`) {
+		var s string
+		if err := json.Unmarshal([]byte(src), &s); err != nil {
+			return "", fmt.Errorf("stackPolicy: %w", err)
+		}
+		src = strings.TrimSpace(s)
+	}
+	if src == "" || src == "null" {
+		return "", nil
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(src), &doc); err != nil {
+		return "", fmt.Errorf("stackPolicy must be a JSON object: %w", err)
+	}
+	if err := validate(doc); err != nil {
+		return "", fmt.Errorf("stackPolicy: %w", err)
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	if len(b) > MaxBodyBytes {
+		return "", fmt.Errorf("stackPolicy has %d bytes, the limit is %d", len(b), MaxBodyBytes)
+	}
+	return string(b), nil
+}
+
+func validate(doc map[string]any) error {
+	for _, k := range sortedKeys(doc) {
+		if k != "Statement" {
+			return fmt.Errorf("unknown field '%s' (only 'Statement' is allowed)", k)
+		}
+	}
+	list, ok := doc["Statement"].([]any)
+	if !ok || len(list) == 0 {
+		return errors.New("'Statement' must be a non-empty array")
+	}
+	for i, item := range list {
+		st, ok := item.(map[string]any)
+		if !ok {
+			return fmt.Errorf("Statement[%d] must be an object", i)
+		}
+		if err := validateStatement(st); err != nil {
+			return fmt.Errorf("Statement[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateStatement(st map[string]any) error {
+	for _, k := range sortedKeys(st) {
+		if !statementFields[k] {
+			return fmt.Errorf("unknown field '%s'", k)
+		}
+	}
+
+	if e := st["Effect"]; e != "Allow" && e != "Deny" {
+		return errors.New("'Effect' must be 'Allow' or 'Deny'")
+	}
+	if p := st["Principal"]; p != "*" {
+		return errors.New("'Principal' must be \"*\"")
+	}
+
+	actionField, err := exactlyOne(st, "Action", "NotAction")
+	if err != nil {
+		return err
+	}
+	values, err := stringList(st[actionField])
+	if err != nil {
+		return fmt.Errorf("'%s': %w", actionField, err)
+	}
+	for _, a := range values {
+		if !actions[a] {
+			return fmt.Errorf("'%s': unsupported action '%s' (use Update:*, Update:Modify, Update:Replace or Update:Delete)", actionField, a)
+		}
+	}
+
+	resourceField, err := exactlyOne(st, "Resource", "NotResource")
+	if err != nil {
+		return err
+	}
+	if values, err = stringList(st[resourceField]); err != nil {
+		return fmt.Errorf("'%s': %w", resourceField, err)
+	}
+	for _, r := range values {
+		if !resourcePattern.MatchString(r) {
+			return fmt.Errorf("'%s': invalid resource '%s' (use \"*\" or \"LogicalResourceId/<id>\")", resourceField, r)
+		}
+	}
+
+	if c, ok := st["Condition"]; ok {
+		if err := validateCondition(c); err != nil {
+			return fmt.Errorf("'Condition': %w", err)
+		}
+	}
+	return nil
+}
+
+// validateCondition aceita só o que o CloudFormation entende numa stack
+// policy: StringEquals/StringLike sobre ResourceType.
+func validateCondition(c any) error {
+	m, ok := c.(map[string]any)
+	if !ok || len(m) == 0 {
+		return errors.New("must be a non-empty object")
+	}
+	for _, op := range sortedKeys(m) {
+		if op != "StringEquals" && op != "StringLike" {
+			return fmt.Errorf("unsupported operator '%s' (use StringEquals or StringLike)", op)
+		}
+		keys, ok := m[op].(map[string]any)
+		if !ok || len(keys) == 0 {
+			return fmt.Errorf("'%s' must be a non-empty object", op)
+		}
+		for _, k := range sortedKeys(keys) {
+			if k != "ResourceType" {
+				return fmt.Errorf("'%s': unsupported key '%s' (only ResourceType is allowed)", op, k)
+			}
+			if _, err := stringList(keys[k]); err != nil {
+				return fmt.Errorf("'%s.ResourceType': %w", op, err)
+			}
+		}
+	}
+	return nil
+}
+
+// exactlyOne exige um, e só um, dos dois campos.
+func exactlyOne(st map[string]any, a, b string) (string, error) {
+	_, hasA := st[a]
+	_, hasB := st[b]
+	switch {
+	case hasA && hasB:
+		return "", fmt.Errorf("use either '%s' or '%s', not both", a, b)
+	case hasA:
+		return a, nil
+	case hasB:
+		return b, nil
+	}
+	return "", fmt.Errorf("'%s' (or '%s') is required", a, b)
+}
+
+// stringList aceita "x" ou ["x", "y"], como nas policies IAM.
+func stringList(v any) ([]string, error) {
+	switch val := v.(type) {
+	case string:
+		return []string{val}, nil
+	case []any:
+		if len(val) == 0 {
+			return nil, errors.New("must not be empty")
+		}
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			s, ok := item.(string)
+			if !ok {
+				return nil, errors.New("must be a string or an array of strings")
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	return nil, errors.New("must be a string or an array of strings")
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
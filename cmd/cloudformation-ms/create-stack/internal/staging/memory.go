@@ -0,0 +1,47 @@
+package staging
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MemoryStore é um Store em memória, para testes e uso local.
+type MemoryStore struct {
+	mu      sync.Mutex
+	Objects map[string][]byte
+	Puts    int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{Objects: map[string][]byte{}}
+}
+
+func (m *MemoryStore) Exists(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.Objects[key]
+	return ok, nil
+}
+
+func (m *MemoryStore) Put(_ context.Context, key string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Objects[key] = append([]byte(nil), body...)
+	m.Puts++
+	return nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.Objects[key]
+	if !ok {
+		return nil, fmt.Errorf("object not found: %s", key)
+	}
+	return append([]byte(nil), b...), nil
+}
+
+func (m *MemoryStore) URL(_ context.Context, key string) (string, error) {
+	return "https://staging.local/" + key, nil
+}
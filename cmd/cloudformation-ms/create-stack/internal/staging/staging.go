@@ -0,0 +1,87 @@
+package staging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+)
+
+// MaxTemplateBytes é o limite do CloudFormation para templates lidos do S3.
+const MaxTemplateBytes = 1024 * 1024
+
+var ErrTooLarge = errors.New("template exceeds the 1 MB limit for S3 templates")
+
+// Store abstrai o bucket de staging; o S3 em produção, memória em testes.
+type Store interface {
+	Exists(ctx context.Context, key string) (bool, error)
+	Put(ctx context.Context, key string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// URL devolve um endereço que o CloudFormation da conta alvo consegue ler.
+	URL(ctx context.Context, key string) (string, error)
+}
+
+// Stager publica templates grandes demais para o TemplateBody.
+type Stager struct {
+	store  Store
+	prefix string
+}
+
+// New cria um Stager que grava em prefix/<owner>/<sha256>.json. O prefixo
+// fixo permite expirar os objetos com uma regra de lifecycle do bucket.
+func New(store Store, prefix string) *Stager {
+	return &Stager{store: store, prefix: prefix}
+}
+
+// Key monta a chave do template: o conteúdo define o nome, então o mesmo
+// template do mesmo owner é enviado uma única vez.
+func (s *Stager) Key(owner string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return path.Join(s.prefix, owner, hex.EncodeToString(sum[:])+".json")
+}
+
+// Stage grava o template (se ainda não existir) e devolve a URL para o TemplateURL.
+func (s *Stager) Stage(ctx context.Context, owner string, body []byte) (string, error) {
+	key, err := s.Save(ctx, owner, body)
+	if err != nil {
+		return "", err
+	}
+	url, err := s.store.URL(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("presign template url: %w", err)
+	}
+	return url, nil
+}
+
+// Save grava o template (se ainda não existir) e devolve a chave.
+func (s *Stager) Save(ctx context.Context, owner string, body []byte) (string, error) {
+	if len(body) > MaxTemplateBytes {
+		return "", fmt.Errorf("%w (size=%d bytes)", ErrTooLarge, len(body))
+	}
+	if owner == "" {
+		return "", errors.New("owner is required to stage a template")
+	}
+
+	key := s.Key(owner, body)
+	exists, err := s.store.Exists(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("check staged template: %w", err)
+	}
+	if exists {
+		log.Printf("[INFO] Template already staged: key=%s", key)
+		return key, nil
+	}
+	if err := s.store.Put(ctx, key, body); err != nil {
+		return "", fmt.Errorf("upload template: %w", err)
+	}
+	log.Printf("[INFO] Template staged: key=%s size=%d bytes", key, len(body))
+	return key, nil
+}
+
+// Load lê um template gravado por Save.
+func (s *Stager) Load(ctx context.Context, key string) ([]byte, error) {
+	return s.store.Get(ctx, key)
+}
@@ -0,0 +1,109 @@
+package staging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSaveDeduplicatesByContent(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	s := New(store, "templates")
+	body := []byte(`{"Resources":{}}`)
+
+	first, err := s.Save(ctx, "alice", body)
+	if err != nil {
+		t.Fatalf("first save: %v", err)
+	}
+	second, err := s.Save(ctx, "alice", body)
+	if err != nil {
+		t.Fatalf("second save: %v", err)
+	}
+	if first != second {
+		t.Fatalf("same template got different keys: %s != %s", first, second)
+	}
+	if store.Puts != 1 {
+		t.Fatalf("expected 1 upload, got %d", store.Puts)
+	}
+
+	if _, err := s.Save(ctx, "alice", []byte(`{"Resources":{"A":{}}}`)); err != nil {
+		t.Fatalf("save other template: %v", err)
+	}
+	if store.Puts != 2 {
+		t.Fatalf("a different template must be uploaded, got %d uploads", store.Puts)
+	}
+}
+
+func TestKeysAreScopedByOwner(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	s := New(store, "templates")
+	body := []byte(`{"Resources":{}}`)
+
+	a, err := s.Save(ctx, "alice", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.Save(ctx, "bob", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Fatalf("owners share the key %s", a)
+	}
+	if !strings.HasPrefix(a, "templates/alice/") || !strings.HasPrefix(b, "templates/bob/") {
+		t.Fatalf("unexpected keys: %s, %s", a, b)
+	}
+	if store.Puts != 2 {
+		t.Fatalf("expected one upload per owner, got %d", store.Puts)
+	}
+
+	got, err := s.Load(ctx, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Fatalf("loaded %q, want %q", got, body)
+	}
+}
+
+func TestSaveSizeLimit(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	s := New(store, "templates")
+
+	if _, err := s.Save(ctx, "alice", bytes.Repeat([]byte("a"), MaxTemplateBytes)); err != nil {
+		t.Fatalf("template at the limit must be accepted: %v", err)
+	}
+	_, err := s.Save(ctx, "alice", bytes.Repeat([]byte("a"), MaxTemplateBytes+1))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+	if store.Puts != 1 {
+		t.Fatalf("oversized template must not be uploaded, got %d uploads", store.Puts)
+	}
+}
+
+func TestSaveRequiresOwner(t *testing.T) {
+	s := New(NewMemoryStore(), "templates")
+	if _, err := s.Save(context.Background(), "", []byte("{}")); err == nil {
+		t.Fatal("expected an error for an empty owner")
+	}
+}
+
+func TestStageReturnsURL(t *testing.T) {
+	ctx := context.Background()
+	s := New(NewMemoryStore(), "templates")
+	body := []byte(`{"Resources":{}}`)
+
+	url, err := s.Stage(ctx, "alice", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://staging.local/" + s.Key("alice", body); url != want {
+		t.Fatalf("url = %s, want %s", url, want)
+	}
+}
@@ -0,0 +1,194 @@
+// Package tagpolicy valida as tags do cliente e acrescenta as da plataforma.
+// É a versão canônica: cmd/organizations-ms/create-key/internal/tagpolicy
+// é uma cópia e deve acompanhar as mudanças feitas aqui.
+package tagpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ReservedPrefix marca as tags gravadas pela plataforma; o cliente não pode
+// enviá-las.
+const ReservedPrefix = "cloudbuilder:"
+
+const (
+	TagOwner     = "cloudbuilder:owner"
+	TagAccount   = "cloudbuilder:account"
+	TagRequestID = "cloudbuilder:request-id"
+)
+
+// EnvVar guarda a policy em JSON, ex.
+// {"rules": [{"key": "cost-center", "required": true, "pattern": "^CC-\\d{4}$"}]}.
+const EnvVar = "TAG_POLICY"
+
+// Limits são os limites de tags do serviço que recebe as tags.
+type Limits struct {
+	MaxTags        int
+	MaxKeyLength   int
+	MinValueLength int
+	MaxValueLength int
+}
+
+var (
+	CloudFormationLimits = Limits{MaxTags: 50, MaxKeyLength: 128, MinValueLength: 1, MaxValueLength: 256}
+	SecretsManagerLimits = Limits{MaxTags: 50, MaxKeyLength: 128, MinValueLength: 0, MaxValueLength: 256}
+)
+
+// Caracteres aceitos em chaves e valores pelo CloudFormation e pelo Secrets Manager.
+var charset = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+
+type Rule struct {
+	Key      string `json:"key"`
+	Required bool   `json:"required,omitempty"`
+	Pattern  string `json:"pattern,omitempty"` // Regex do valor; vazio aceita qualquer valor
+
+	re *regexp.Regexp
+}
+
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Parse lê uma policy em JSON. Texto vazio é uma policy sem regras.
+func Parse(src string) (*Policy, error) {
+	p := &Policy{}
+	if strings.TrimSpace(src) == "" {
+		return p, nil
+	}
+	if err := json.Unmarshal([]byte(src), p); err != nil {
+		return nil, fmt.Errorf("invalid tag policy: %w", err)
+	}
+	seen := map[string]bool{}
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Key == "" {
+			return nil, fmt.Errorf("invalid tag policy: rule %d has no key", i)
+		}
+		if seen[r.Key] {
+			return nil, fmt.Errorf("invalid tag policy: duplicated rule for '%s'", r.Key)
+		}
+		seen[r.Key] = true
+		if r.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag policy: pattern for '%s': %w", r.Key, err)
+		}
+		r.re = re
+	}
+	return p, nil
+}
+
+var (
+	envOnce   sync.Once
+	envPolicy *Policy
+	envErr    error
+)
+
+// FromEnv lê a policy de TAG_POLICY uma vez por execução da Lambda.
+func FromEnv() (*Policy, error) {
+	envOnce.Do(func() {
+		envPolicy, envErr = Parse(os.Getenv(EnvVar))
+	})
+	return envPolicy, envErr
+}
+
+// Violation é um problema de uma tag do cliente.
+type Violation struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// ViolationError reúne todas as violações de uma requisição.
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Message)
+	}
+	return "tag policy violated: " + strings.Join(msgs, "; ")
+}
+
+// Apply valida as tags do cliente e devolve o conjunto final, com as tags
+// da plataforma por cima. Todas as violações voltam num *ViolationError.
+func (p *Policy) Apply(client, platform map[string]string, limits Limits) (map[string]string, error) {
+	var violations []Violation
+	add := func(key, format string, args ...any) {
+		violations = append(violations, Violation{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	keys := make([]string, 0, len(client))
+	for k := range client {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := client[k]
+		switch {
+		case k == "":
+			add(k, "tag keys must not be empty")
+			continue
+		case strings.HasPrefix(k, ReservedPrefix):
+			add(k, "tag '%s' uses the reserved prefix '%s'", k, ReservedPrefix)
+			continue
+		case platform[k] != "":
+			add(k, "tag '%s' is set by the platform and cannot be overridden", k)
+			continue
+		case strings.HasPrefix(strings.ToLower(k), "aws:"):
+			add(k, "tag '%s' uses the reserved prefix 'aws:'", k)
+			continue
+		}
+		if n := utf8.RuneCountInString(k); n > limits.MaxKeyLength {
+			add(k, "tag key '%s' has %d characters (max %d)", k, n, limits.MaxKeyLength)
+		}
+		if !charset.MatchString(k) {
+			add(k, "tag key '%s' has invalid characters (allowed: letters, numbers, spaces and _ . : / = + - @)", k)
+		}
+		if n := utf8.RuneCountInString(v); n < limits.MinValueLength || n > limits.MaxValueLength {
+			add(k, "tag '%s' value must have between %d and %d characters (got %d)", k, limits.MinValueLength, limits.MaxValueLength, n)
+		}
+		if !charset.MatchString(v) {
+			add(k, "tag '%s' value has invalid characters (allowed: letters, numbers, spaces and _ . : / = + - @)", k)
+		}
+	}
+
+	for _, r := range p.Rules {
+		v, ok := client[r.Key]
+		switch {
+		case !ok && r.Required:
+			add(r.Key, "tag '%s' is required", r.Key)
+		case ok && r.re != nil && !r.re.MatchString(v):
+			add(r.Key, "tag '%s' value '%s' does not match %s", r.Key, v, r.Pattern)
+		}
+	}
+
+	out := make(map[string]string, len(client)+len(platform))
+	for k, v := range client {
+		out[k] = v
+	}
+	for k, v := range platform {
+		if v != "" {
+			out[k] = v
+		}
+	}
+	if len(out) > limits.MaxTags {
+		add("", "too many tags: %d including %d platform tags (max %d)", len(out), len(out)-len(client), limits.MaxTags)
+	}
+
+	if len(violations) > 0 {
+		return nil, &ViolationError{Violations: violations}
+	}
+	return out, nil
+}
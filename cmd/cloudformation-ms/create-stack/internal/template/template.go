@@ -0,0 +1,350 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document é um template CloudFormation já lido, com as intrínsecas na forma
+// longa ({"Fn::Sub": ...}). A ordem das chaves do original é preservada.
+type Document struct {
+	root *yaml.Node
+}
+
+// Funções com forma curta no YAML do CloudFormation (!Ref, !Sub, ...).
+var shortForms = map[string]string{
+	"Ref":          "Ref",
+	"Condition":    "Condition",
+	"And":          "Fn::And",
+	"Base64":       "Fn::Base64",
+	"Cidr":         "Fn::Cidr",
+	"Equals":       "Fn::Equals",
+	"FindInMap":    "Fn::FindInMap",
+	"ForEach":      "Fn::ForEach",
+	"GetAZs":       "Fn::GetAZs",
+	"GetAtt":       "Fn::GetAtt",
+	"If":           "Fn::If",
+	"ImportValue":  "Fn::ImportValue",
+	"Join":         "Fn::Join",
+	"Length":       "Fn::Length",
+	"Not":          "Fn::Not",
+	"Or":           "Fn::Or",
+	"Select":       "Fn::Select",
+	"Split":        "Fn::Split",
+	"Sub":          "Fn::Sub",
+	"ToJsonString": "Fn::ToJsonString",
+	"Transform":    "Fn::Transform",
+}
+
+// Parse lê um template em YAML (com tags curtas) ou JSON, que é YAML válido.
+func Parse(src []byte) (*Document, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, errors.New("template is empty")
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("template must be an object at the top level")
+	}
+	if err := expand(root); err != nil {
+		return nil, err
+	}
+	return &Document{root: root}, nil
+}
+
+// expand troca, recursivamente, as tags curtas pela forma longa.
+func expand(n *yaml.Node) error {
+	for _, c := range n.Content {
+		if err := expand(c); err != nil {
+			return err
+		}
+	}
+	if !strings.HasPrefix(n.Tag, "!") || strings.HasPrefix(n.Tag, "!!") {
+		return nil
+	}
+	name := strings.TrimPrefix(n.Tag, "!")
+	key, ok := shortForms[name]
+	if !ok {
+		return fmt.Errorf("line %d: unknown tag '%s'", n.Line, n.Tag)
+	}
+
+	arg := *n
+	arg.Tag = ""
+	if arg.Kind == yaml.ScalarNode {
+		arg.Tag = "!!str"
+		// !GetAtt Recurso.Atributo vira ["Recurso", "Atributo"]
+		if name == "GetAtt" {
+			res, attr, found := strings.Cut(arg.Value, ".")
+			if !found {
+				return fmt.Errorf("line %d: !GetAtt expects 'Resource.Attribute', got '%s'", n.Line, arg.Value)
+			}
+			arg = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: n.Line, Column: n.Column, Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: res},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: attr},
+			}}
+		}
+	}
+
+	*n = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: n.Line, Column: n.Column, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&arg,
+	}}
+	return nil
+}
+
+// JSON serializa o template em JSON compacto, o formato enviado ao CloudFormation.
+func (d *Document) JSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, d.root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// IndentedJSON é o mesmo que JSON, formatado para leitura.
+func (d *Document) IndentedJSON() ([]byte, error) {
+	b, err := d.JSON()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Map devolve o template como map genérico, para quem só precisa inspecioná-lo.
+func (d *Document) Map() (map[string]any, error) {
+	b, err := d.JSON()
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Line devolve a linha do texto original apontada por um JSON pointer
+// (RFC 6901). Quando o ponto não existe (ex. uma propriedade ausente), devolve
+// a linha do ancestral mais próximo que existe.
+func (d *Document) Line(pointer string) int {
+	n, line := d.root, d.root.Line
+	if pointer == "" {
+		return line
+	}
+	for _, seg := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		seg = strings.NewReplacer("~1", "/", "~0", "~").Replace(seg)
+		next, at := child(n, seg)
+		if next == nil {
+			break
+		}
+		n, line = next, at
+	}
+	return line
+}
+
+// child devolve o valor da chave (mapas) ou do índice (listas) seg e a linha
+// onde ele começa: a da chave, para mapas.
+func child(n *yaml.Node, seg string) (*yaml.Node, int) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == seg {
+				return n.Content[i+1], n.Content[i].Line
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(seg); err == nil && i >= 0 && i < len(n.Content) {
+			return n.Content[i], n.Content[i].Line
+		}
+	}
+	return nil, 0
+}
+
+// YAML serializa o template em YAML em bloco. Com short=true as intrínsecas
+// voltam para a forma curta (!Ref, !Sub, ...).
+func (d *Document) YAML(short bool) ([]byte, error) {
+	root := plain(d.root, short)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// plain copia a árvore sem os estilos herdados do original (ex. flow do JSON)
+// e, opcionalmente, reduz as intrínsecas à forma curta.
+func plain(n *yaml.Node, short bool) *yaml.Node {
+	if n.Kind == yaml.AliasNode {
+		return plain(n.Alias, short)
+	}
+	out := &yaml.Node{Kind: n.Kind, Tag: n.Tag, Value: n.Value}
+	if n.Kind == yaml.ScalarNode && n.Style&yaml.LiteralStyle != 0 {
+		out.Style = yaml.LiteralStyle
+	}
+	if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!str" && ambiguous(n.Value) {
+		out.Style = yaml.DoubleQuotedStyle
+	}
+	for _, c := range n.Content {
+		out.Content = append(out.Content, plain(c, short))
+	}
+	if short {
+		if s := shorten(out); s != nil {
+			return s
+		}
+	}
+	return out
+}
+
+// ambiguous indica strings que parsers YAML 1.1 leriam como outro tipo
+// (yes/no/on/off) ou que somem sem aspas (string vazia).
+func ambiguous(s string) bool {
+	switch strings.ToLower(s) {
+	case "", "y", "n", "yes", "no", "on", "off":
+		return true
+	}
+	return false
+}
+
+// shorten devolve a forma curta de um mapa {"Fn::X": arg}, ou nil quando o
+// nó não é uma intrínseca. "Condition" fica na forma longa: a mesma chave é
+// atributo de recurso e a forma curta não ganha nada em legibilidade.
+func shorten(n *yaml.Node) *yaml.Node {
+	if n.Kind != yaml.MappingNode || len(n.Content) != 2 {
+		return nil
+	}
+	key, arg := n.Content[0].Value, n.Content[1]
+	name := ""
+	for short, long := range shortForms {
+		if long == key && short != "Condition" {
+			name = short
+			break
+		}
+	}
+	if name == "" {
+		return nil
+	}
+
+	out := *arg
+	if name == "GetAtt" && arg.Kind == yaml.SequenceNode && len(arg.Content) == 2 &&
+		arg.Content[0].Kind == yaml.ScalarNode && arg.Content[1].Kind == yaml.ScalarNode {
+		out = yaml.Node{Kind: yaml.ScalarNode, Value: arg.Content[0].Value + "." + arg.Content[1].Value}
+	}
+	if out.Kind == yaml.ScalarNode && out.Tag != "" && out.Tag != "!!str" {
+		// O argumento de uma tag curta é sempre lido como string: {"Fn::Base64": 5} fica na forma longa
+		return nil
+	}
+	out.Tag = "!" + name
+	return &out
+}
+
+func writeJSON(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return writeJSON(buf, n.Alias)
+
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i < len(n.Content); i += 2 {
+			k := n.Content[i]
+			if k.Kind == yaml.AliasNode {
+				k = k.Alias
+			}
+			if k.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: mapping keys must be scalars", k.Line)
+			}
+			if k.Tag == "!!merge" {
+				return fmt.Errorf("line %d: merge keys ('<<') are not supported", k.Line)
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeString(buf, k.Value)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, c := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, c); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+
+	case yaml.ScalarNode:
+		return writeScalar(buf, n)
+	}
+	return fmt.Errorf("line %d: unsupported YAML node", n.Line)
+}
+
+func writeScalar(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.ShortTag() {
+	case "!!null":
+		buf.WriteString("null")
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		buf.WriteString(strconv.FormatBool(b))
+	case "!!int", "!!float":
+		// O texto vai como está: reformatar perde zeros à esquerda (IDs de
+		// conta), lê 0755 como octal e arredonda inteiros grandes. O que não
+		// é número JSON (0755, 0x1F, .inf) vira string.
+		if jsonNumber(n.Value) {
+			buf.WriteString(n.Value)
+		} else {
+			writeString(buf, n.Value)
+		}
+	default:
+		// !!str, !!timestamp (ex. AWSTemplateFormatVersion: 2010-09-09), !!binary
+		writeString(buf, n.Value)
+	}
+	return nil
+}
+
+// jsonNumber diz se s já é um número JSON válido.
+func jsonNumber(s string) bool {
+	if s == "" || !strings.ContainsRune("-0123456789", rune(s[0])) {
+		return false
+	}
+	return json.Valid([]byte(s))
+}
+
+// writeString escreve s como string JSON sem escapar <, > e &, comuns em !Sub.
+func writeString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	buf.Truncate(buf.Len() - 1) // Encode termina com '\n'
+}
@@ -0,0 +1,53 @@
+package template
+
+import "testing"
+
+func TestScalarsKeepTheirText(t *testing.T) {
+	cases := []struct {
+		name, value, want string
+	}{
+		{"account id with leading zero", "012345678901", `"012345678901"`},
+		{"octal-looking mode", "0755", `"0755"`},
+		{"hexadecimal", "0x1F", `"0x1F"`},
+		{"large integer", "100000000000000000000000", "100000000000000000000000"},
+		{"integer", "30", "30"},
+		{"negative", "-1", "-1"},
+		{"float", "0.5", "0.5"},
+		{"exponent", "1e3", "1e3"},
+		{"infinity", ".inf", `".inf"`},
+		{"quoted number", `"42"`, `"42"`},
+		{"version date", "2010-09-09", `"2010-09-09"`},
+		{"boolean", "true", "true"},
+		{"null", "~", "null"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := Parse([]byte("Value: " + tc.value + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := doc.JSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := `{"Value":` + tc.want + `}`; string(got) != want {
+				t.Fatalf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestTemplateFormatVersion(t *testing.T) {
+	doc, err := Parse([]byte("AWSTemplateFormatVersion: 2010-09-09\nResources:\n  Bucket:\n    Type: AWS::S3::Bucket\n    Properties:\n      Tags:\n        - Key: account\n          Value: 000123456789\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := doc.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"AWSTemplateFormatVersion":"2010-09-09","Resources":{"Bucket":{"Type":"AWS::S3::Bucket","Properties":{"Tags":[{"Key":"account","Value":"000123456789"}]}}}}`
+	if string(got) != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+const (
+	EventSource     = "cloudbuilder.deployments"
+	EventDetailType = "Deployment Completed"
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, o Outcome) error
+}
+
+type PutEventsAPI interface {
+	PutEvents(ctx context.Context, in *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridgePublisher emite o evento de conclusão no barramento informado
+// (vazio = default).
+type EventBridgePublisher struct {
+	api PutEventsAPI
+	bus string
+}
+
+func NewEventBridgePublisher(api PutEventsAPI, bus string) *EventBridgePublisher {
+	return &EventBridgePublisher{api: api, bus: bus}
+}
+
+func (p *EventBridgePublisher) Publish(ctx context.Context, o Outcome) error {
+	detail, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	entry := ebtypes.PutEventsRequestEntry{
+		Source:     aws.String(EventSource),
+		DetailType: aws.String(EventDetailType),
+		Detail:     aws.String(string(detail)),
+		Resources:  []string{o.StackID},
+	}
+	if p.bus != "" {
+		entry.EventBusName = aws.String(p.bus)
+	}
+	out, err := p.api.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: []ebtypes.PutEventsRequestEntry{entry}})
+	if err != nil {
+		return err
+	}
+	// PutEvents não falha a chamada quando uma entrada é rejeitada
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		return fmt.Errorf("event rejected: %s", aws.ToString(out.Entries[0].ErrorMessage))
+	}
+	return nil
+}
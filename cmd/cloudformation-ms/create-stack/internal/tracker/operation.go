@@ -0,0 +1,27 @@
+package tracker
+
+import "time"
+
+// Operation é a mensagem da fila: uma operação iniciada numa stack que o
+// tracker deve acompanhar até um status terminal.
+type Operation struct {
+	Owner     string    `json:"owner"`
+	Account   string    `json:"account"`
+	Region    string    `json:"region,omitempty"` // Vazio em mensagens antigas: região padrão da conta
+	StackID   string    `json:"stackId"`
+	StackName string    `json:"stackName"`
+	Operation string    `json:"operation"` // "CREATE" | "UPDATE" | "DELETE"
+	StartedAt time.Time `json:"startedAt"`
+	Attempt   int       `json:"attempt,omitempty"`
+}
+
+// Outcome é o resultado final gravado no DynamoDB e publicado no EventBridge.
+type Outcome struct {
+	Operation
+	Status          string    `json:"status"`
+	Lifecycle       string    `json:"lifecycle"`
+	FinishedAt      time.Time `json:"finishedAt"`
+	DurationSeconds int64     `json:"durationSeconds"`
+	FailureReason   string    `json:"failureReason,omitempty"`
+	FailedResource  string    `json:"failedResource,omitempty"`
+}
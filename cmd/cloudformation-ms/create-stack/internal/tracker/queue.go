@@ -0,0 +1,46 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// MaxDelay é o maior DelaySeconds aceito pelo SQS.
+const MaxDelay = 15 * time.Minute
+
+type Queue interface {
+	Enqueue(ctx context.Context, op Operation, delay time.Duration) error
+}
+
+type SendMessageAPI interface {
+	SendMessage(ctx context.Context, in *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+type SQSQueue struct {
+	api SendMessageAPI
+	url string
+}
+
+func NewSQSQueue(api SendMessageAPI, url string) *SQSQueue {
+	return &SQSQueue{api: api, url: url}
+}
+
+func (q *SQSQueue) Enqueue(ctx context.Context, op Operation, delay time.Duration) error {
+	body, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	if delay > MaxDelay {
+		delay = MaxDelay
+	}
+	_, err = q.api.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:     aws.String(q.url),
+		MessageBody:  aws.String(string(body)),
+		DelaySeconds: int32(delay / time.Second),
+	})
+	return err
+}
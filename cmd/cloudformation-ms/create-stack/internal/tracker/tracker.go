@@ -0,0 +1,122 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"create-stack-ms/internal/cfn"
+)
+
+// StackAPI é o subconjunto do CloudFormation usado pelo tracker.
+type StackAPI interface {
+	cf.DescribeStacksAPIClient
+	cf.DescribeStackEventsAPIClient
+}
+
+// Tracker acompanha uma operação por polling até um status terminal.
+type Tracker struct {
+	Clock        Clock
+	PollInterval time.Duration
+}
+
+func New(clock Clock, pollInterval time.Duration) *Tracker {
+	return &Tracker{Clock: clock, PollInterval: pollInterval}
+}
+
+// Follow consulta a stack até um status terminal ou até deadline. Devolve
+// done=false quando o prazo acaba antes: a operação deve voltar para a fila.
+func (t *Tracker) Follow(ctx context.Context, api StackAPI, op Operation, deadline time.Time) (Outcome, bool, error) {
+	for {
+		stack, err := cfn.LookupStack(ctx, api, op.StackID)
+		if err != nil {
+			return Outcome{}, false, err
+		}
+		lc := cfn.LifecycleOf(stack.StackStatus)
+		if lc.Terminal() {
+			return t.outcome(ctx, api, op, stack, lc)
+		}
+		if t.Clock.Now().Add(t.PollInterval).After(deadline) {
+			return Outcome{}, false, nil
+		}
+		if err := t.Clock.Sleep(ctx, t.PollInterval); err != nil {
+			return Outcome{}, false, err
+		}
+	}
+}
+
+func (t *Tracker) outcome(ctx context.Context, api StackAPI, op Operation, stack cft.Stack, lc cfn.Lifecycle) (Outcome, bool, error) {
+	out := Outcome{
+		Operation:  op,
+		Status:     string(stack.StackStatus),
+		Lifecycle:  string(lc),
+		FinishedAt: t.Clock.Now(),
+	}
+
+	ev, err := scanEvents(ctx, api, op)
+	if err != nil {
+		return Outcome{}, false, err
+	}
+	if !ev.finishedAt.IsZero() {
+		out.FinishedAt = ev.finishedAt
+	}
+	out.FailureReason, out.FailedResource = ev.failureReason, ev.failedResource
+	if lc != cfn.LifecycleSucceeded && lc != cfn.LifecycleDeleted && out.FailureReason == "" {
+		out.FailureReason = aws.ToString(stack.StackStatusReason)
+	}
+	if d := out.FinishedAt.Sub(op.StartedAt); d > 0 {
+		out.DurationSeconds = int64(d.Round(time.Second) / time.Second)
+	}
+	return out, true, nil
+}
+
+type eventScan struct {
+	finishedAt     time.Time
+	failureReason  string
+	failedResource string
+}
+
+// scanEvents percorre os eventos da operação (do mais novo até StartedAt):
+// o mais novo da própria stack marca o fim, e a falha mais antiga é a causa
+// raiz; as seguintes costumam ser só cancelamentos em cascata.
+func scanEvents(ctx context.Context, api StackAPI, op Operation) (eventScan, error) {
+	var res eventScan
+	p := cf.NewDescribeStackEventsPaginator(api, &cf.DescribeStackEventsInput{StackName: aws.String(op.StackID)})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return res, err
+		}
+		for _, e := range page.StackEvents {
+			ts := aws.ToTime(e.Timestamp)
+			if ts.Before(op.StartedAt) {
+				return res, nil
+			}
+			if res.finishedAt.IsZero() && aws.ToString(e.PhysicalResourceId) == op.StackID {
+				res.finishedAt = ts
+			}
+			reason := aws.ToString(e.ResourceStatusReason)
+			if strings.HasSuffix(string(e.ResourceStatus), "_FAILED") && reason != "" && !cancelled(reason) {
+				res.failureReason = reason
+				res.failedResource = aws.ToString(e.LogicalResourceId)
+			}
+		}
+	}
+	return res, nil
+}
+
+func cancelled(reason string) bool {
+	return strings.HasPrefix(reason, "Resource creation cancelled") ||
+		strings.HasPrefix(reason, "Resource update cancelled")
+}
+
+// IsGone reconhece stacks que não existem mais (ex. DELETE_COMPLETE já
+// expurgado), cujo acompanhamento deve ser encerrado.
+func IsGone(err error) bool {
+	return errors.Is(err, cfn.ErrStackNotFound)
+}
@@ -0,0 +1,192 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/smithy-go"
+)
+
+const stackID = "arn:aws:cloudformation:us-east-1:123456789012:stack/app/1"
+
+// fakeStacks devolve um status por DescribeStacks (o último se repete) e
+// os eventos informados, do mais novo para o mais antigo.
+type fakeStacks struct {
+	statuses []cft.StackStatus
+	reason   string
+	err      error
+	events   []cft.StackEvent
+	calls    int
+}
+
+func (f *fakeStacks) DescribeStacks(ctx context.Context, in *cf.DescribeStacksInput, optFns ...func(*cf.Options)) (*cf.DescribeStacksOutput, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	i := min(f.calls, len(f.statuses)) - 1
+	return &cf.DescribeStacksOutput{Stacks: []cft.Stack{{
+		StackId:           aws.String(stackID),
+		StackStatus:       f.statuses[i],
+		StackStatusReason: aws.String(f.reason),
+	}}}, nil
+}
+
+func (f *fakeStacks) DescribeStackEvents(ctx context.Context, in *cf.DescribeStackEventsInput, optFns ...func(*cf.Options)) (*cf.DescribeStackEventsOutput, error) {
+	return &cf.DescribeStackEventsOutput{StackEvents: f.events}, nil
+}
+
+func event(ts time.Time, logicalID, physicalID string, status cft.ResourceStatus, reason string) cft.StackEvent {
+	return cft.StackEvent{
+		Timestamp:            aws.Time(ts),
+		LogicalResourceId:    aws.String(logicalID),
+		PhysicalResourceId:   aws.String(physicalID),
+		ResourceStatus:       status,
+		ResourceStatusReason: aws.String(reason),
+	}
+}
+
+func operation(startedAt time.Time) Operation {
+	return Operation{Owner: "alice", Account: "dev", StackID: stackID, StackName: "app", Operation: "CREATE", StartedAt: startedAt}
+}
+
+func TestFollowTerminalOnFirstPoll(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start.Add(2 * time.Minute))
+	api := &fakeStacks{
+		statuses: []cft.StackStatus{cft.StackStatusCreateComplete},
+		events: []cft.StackEvent{
+			event(start.Add(90*time.Second), "app", stackID, cft.ResourceStatusCreateComplete, ""),
+			event(start.Add(30*time.Second), "Bucket", "bucket-1", cft.ResourceStatusCreateComplete, ""),
+		},
+	}
+
+	out, done, err := New(clock, 10*time.Second).Follow(context.Background(), api, operation(start), clock.Now().Add(time.Minute))
+	if err != nil || !done {
+		t.Fatalf("done=%t err=%v", done, err)
+	}
+	if api.calls != 1 {
+		t.Fatalf("expected a single poll, got %d", api.calls)
+	}
+	if out.Status != string(cft.StackStatusCreateComplete) || out.FailureReason != "" {
+		t.Fatalf("unexpected outcome: %+v", out)
+	}
+	if !out.FinishedAt.Equal(start.Add(90 * time.Second)) {
+		t.Fatalf("finishedAt = %s, want the last stack event", out.FinishedAt)
+	}
+	if out.DurationSeconds != 90 {
+		t.Fatalf("durationSeconds = %d, want 90", out.DurationSeconds)
+	}
+}
+
+func TestFollowPollsUntilTerminal(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	api := &fakeStacks{statuses: []cft.StackStatus{
+		cft.StackStatusCreateInProgress,
+		cft.StackStatusCreateInProgress,
+		cft.StackStatusCreateComplete,
+	}}
+
+	out, done, err := New(clock, 10*time.Second).Follow(context.Background(), api, operation(start), start.Add(time.Minute))
+	if err != nil || !done {
+		t.Fatalf("done=%t err=%v", done, err)
+	}
+	if api.calls != 3 {
+		t.Fatalf("expected 3 polls, got %d", api.calls)
+	}
+	// Sem eventos, o fim é o relógio do tracker
+	if out.DurationSeconds != 20 {
+		t.Fatalf("durationSeconds = %d, want 20", out.DurationSeconds)
+	}
+}
+
+func TestFollowDeadlineRequeues(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	api := &fakeStacks{statuses: []cft.StackStatus{cft.StackStatusUpdateInProgress}}
+
+	_, done, err := New(clock, 10*time.Second).Follow(context.Background(), api, operation(start), start.Add(45*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done {
+		t.Fatal("an operation still in progress at the deadline must go back to the queue")
+	}
+	if api.calls != 5 {
+		t.Fatalf("expected 5 polls before the deadline, got %d", api.calls)
+	}
+	if now := clock.Now(); now.After(start.Add(45 * time.Second)) {
+		t.Fatalf("tracker slept past the deadline: %s", now)
+	}
+}
+
+func TestFollowPicksRootCauseOverCancellations(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start.Add(5 * time.Minute))
+	api := &fakeStacks{
+		statuses: []cft.StackStatus{cft.StackStatusRollbackComplete},
+		reason:   "The following resource(s) failed to create: [Queue, Bucket].",
+		events: []cft.StackEvent{
+			event(start.Add(4*time.Minute), "app", stackID, cft.ResourceStatus(cft.StackStatusRollbackComplete), ""),
+			event(start.Add(3*time.Minute), "app", stackID, cft.ResourceStatus(cft.StackStatusRollbackInProgress), "The following resource(s) failed to create: [Queue, Bucket]."),
+			event(start.Add(2*time.Minute), "Queue", "", cft.ResourceStatusCreateFailed, "Resource creation cancelled"),
+			event(start.Add(time.Minute), "Bucket", "", cft.ResourceStatusCreateFailed, "app-logs already exists"),
+			// De uma operação anterior: fica fora da varredura
+			event(start.Add(-time.Hour), "Old", "", cft.ResourceStatusCreateFailed, "older failure"),
+		},
+	}
+
+	out, done, err := New(clock, 10*time.Second).Follow(context.Background(), api, operation(start), clock.Now().Add(time.Minute))
+	if err != nil || !done {
+		t.Fatalf("done=%t err=%v", done, err)
+	}
+	if out.FailedResource != "Bucket" || out.FailureReason != "app-logs already exists" {
+		t.Fatalf("root cause = %s: %q, want Bucket: %q", out.FailedResource, out.FailureReason, "app-logs already exists")
+	}
+	if out.Lifecycle == "" || !out.FinishedAt.Equal(start.Add(4*time.Minute)) {
+		t.Fatalf("unexpected outcome: %+v", out)
+	}
+}
+
+func TestFollowFallsBackToStackReason(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	api := &fakeStacks{
+		statuses: []cft.StackStatus{cft.StackStatusUpdateRollbackComplete},
+		reason:   "Export app-vpc cannot be updated as it is in use",
+	}
+
+	out, _, err := New(clock, 10*time.Second).Follow(context.Background(), api, operation(start), start.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.FailureReason != api.reason || out.FailedResource != "" {
+		t.Fatalf("unexpected failure: %s: %q", out.FailedResource, out.FailureReason)
+	}
+}
+
+func TestIsGone(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	tr := New(clock, 10*time.Second)
+
+	gone := &fakeStacks{err: &smithy.GenericAPIError{Code: "ValidationError", Message: "Stack with id " + stackID + " does not exist"}}
+	if _, _, err := tr.Follow(context.Background(), gone, operation(start), start.Add(time.Minute)); !IsGone(err) {
+		t.Fatalf("expected a gone stack, got %v", err)
+	}
+
+	throttled := &fakeStacks{err: &smithy.GenericAPIError{Code: "Throttling", Message: "Rate exceeded"}}
+	_, _, err := tr.Follow(context.Background(), throttled, operation(start), start.Add(time.Minute))
+	if err == nil || IsGone(err) {
+		t.Fatalf("throttling must be retried, got %v", err)
+	}
+	if IsGone(errors.New("boom")) {
+		t.Fatal("generic errors are not gone stacks")
+	}
+}
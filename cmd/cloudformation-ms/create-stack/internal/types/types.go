@@ -1,6 +1,13 @@
 package types
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
 
 type SecretKeys struct {
 	AccessKeyID     string `json:"accessKeyId"`
@@ -8,13 +15,22 @@ type SecretKeys struct {
 	SessionToken    string `json:"sessionToken,omitempty"`
 	RoleARN         string `json:"roleArn,omitempty"`
 	ExternalID      string `json:"externalId,omitempty"`
+
+	// Regiões da conta, gravadas no cadastro (register-keys)
+	DefaultRegion  string   `json:"defaultRegion,omitempty"`
+	AllowedRegions []string `json:"allowedRegions,omitempty"`
 }
 
 type RequestBody struct {
 	AccountName        string            `json:"accountName"`
+	AccountNames       []string          `json:"accountNames,omitempty"` // Alternativa: mesma stack em várias contas
 	StackName          string            `json:"stackName"`
-	Template           json.RawMessage   `json:"template"`               // Inline JSON (TemplateBody)
+	Region             string            `json:"region,omitempty"`       // Padrão: região padrão da conta
+	Template           json.RawMessage   `json:"template"`               // Inline JSON (TemplateBody) ou string YAML/JSON
+	TemplateYAML       string            `json:"templateYaml,omitempty"` // Inline YAML (aceita !Ref, !Sub, !GetAtt...)
 	TemplateURL        string            `json:"templateUrl,omitempty"`  // Alternativa: URL
+	TemplateID         string            `json:"templateId,omitempty"`   // Alternativa: template do catálogo
+	TemplateVersion    string            `json:"version,omitempty"`      // Versão do catálogo (padrão: a mais nova não depreciada)
 	Capabilities       []string          `json:"capabilities,omitempty"` // ["CAPABILITY_IAM", ...]
 	RoleARN            string            `json:"roleArn,omitempty"`
 	Tags               map[string]string `json:"tags,omitempty"`
@@ -22,6 +38,79 @@ type RequestBody struct {
 	DisableRollback    *bool             `json:"disableRollback,omitempty"`
 	TimeoutInMinutes   *int32            `json:"timeoutInMinutes,omitempty"`
 	ClientRequestToken string            `json:"clientRequestToken,omitempty"`
+	Parameters         Parameters        `json:"parameters,omitempty"`
+
+	StackPolicy                 json.RawMessage `json:"stackPolicy,omitempty"` // Objeto JSON ou string com o JSON
+	EnableTerminationProtection *bool           `json:"enableTerminationProtection,omitempty"`
+
+	RollbackConfiguration *RollbackConfiguration `json:"rollbackConfiguration,omitempty"`
+	NotificationARNs      []string               `json:"notificationArns,omitempty"` // Tópicos SNS da conta/região alvo
+}
+
+// RollbackConfiguration desfaz o deploy quando algum alarme do CloudWatch
+// dispara durante a operação ou o tempo de monitoramento.
+type RollbackConfiguration struct {
+	AlarmARNs               []string `json:"alarmArns"` // Alarmes da conta/região alvo
+	MonitoringTimeInMinutes *int32   `json:"monitoringTimeInMinutes,omitempty"`
+}
+
+type Parameter struct {
+	Key              string `json:"key"`
+	Value            string `json:"value,omitempty"`
+	UsePreviousValue bool   `json:"usePreviousValue,omitempty"`
+	ResolvedValue    string `json:"resolvedValue,omitempty"` // só na resposta (tipos SSM)
+}
+
+// Parameters aceita tanto um objeto {"Env": "dev"} quanto uma lista
+// [{"key": "Env", "value": "dev"}, {"key": "DbPassword", "usePreviousValue": true}].
+type Parameters []Parameter
+
+func (p *Parameters) UnmarshalJSON(b []byte) error {
+	trimmed := strings.TrimSpace(string(b))
+	switch {
+	case trimmed == "null":
+		*p = nil
+		return nil
+	case strings.HasPrefix(trimmed, "["):
+		var list []Parameter
+		if err := json.Unmarshal(b, &list); err != nil {
+			return err
+		}
+		for _, it := range list {
+			if it.Key == "" {
+				return errors.New("parameters: every item needs a 'key'")
+			}
+		}
+		*p = list
+		return nil
+	case strings.HasPrefix(trimmed, "{"):
+		var m map[string]any
+		if err := json.Unmarshal(b, &m); err != nil {
+			return err
+		}
+		out := make(Parameters, 0, len(m))
+		for k, v := range m {
+			switch val := v.(type) {
+			case string:
+				out = append(out, Parameter{Key: k, Value: val})
+			case float64, bool:
+				out = append(out, Parameter{Key: k, Value: fmt.Sprint(val)})
+			case []any:
+				// Parâmetros List<...> / CommaDelimitedList
+				parts := make([]string, 0, len(val))
+				for _, item := range val {
+					parts = append(parts, fmt.Sprint(item))
+				}
+				out = append(out, Parameter{Key: k, Value: strings.Join(parts, ",")})
+			default:
+				return fmt.Errorf("parameters: unsupported value for '%s'", k)
+			}
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+		*p = out
+		return nil
+	}
+	return errors.New("parameters must be an object or an array")
 }
 
 type ResponseBody struct {
@@ -29,6 +118,424 @@ type ResponseBody struct {
 	StackID   string `json:"stackId,omitempty"`
 	StackName string `json:"stackName,omitempty"`
 	Account   string `json:"account,omitempty"`
+	Region    string `json:"region,omitempty"`
 	Owner     string `json:"owner,omitempty"`
 	Status    string `json:"status,omitempty"`
+	Lifecycle string `json:"lifecycle,omitempty"`
+
+	Parameters   []Parameter `json:"parameters,omitempty"`   // NoEcho mascarado
+	Capabilities []string    `json:"capabilities,omitempty"` // Enviadas ao CloudFormation (inclui as detectadas)
+
+	TemplateID      string `json:"templateId,omitempty"`
+	TemplateVersion string `json:"version,omitempty"`
+
+	GuardrailWarnings []GuardrailViolation `json:"guardrailWarnings,omitempty"` // Violações de policies em modo warn
+	SecurityFindings  []ScanFinding        `json:"securityFindings,omitempty"`  // Scan automático em modo warn
+}
+
+// TagPolicyError é a resposta 400 quando as tags violam a tag policy.
+type TagPolicyError struct {
+	Message    string         `json:"message"`
+	Violations []TagViolation `json:"violations"`
+}
+
+type TagViolation struct {
+	Key     string `json:"key,omitempty"` // vazio para violações do conjunto (ex. número de tags)
+	Message string `json:"message"`
+}
+
+// ScanRequest é o corpo de POST /cf/scan.
+type ScanRequest struct {
+	Template        json.RawMessage `json:"template,omitempty"`     // Objeto JSON ou string YAML/JSON
+	TemplateYAML    string          `json:"templateYaml,omitempty"` // Alternativa: YAML
+	TemplateID      string          `json:"templateId,omitempty"`   // Alternativa: template do catálogo
+	TemplateVersion string          `json:"version,omitempty"`
+	Format          string          `json:"format,omitempty"`      // "json" (padrão) | "sarif"
+	ArtifactURI     string          `json:"artifactUri,omitempty"` // Caminho do template no repositório, usado no SARIF
+}
+
+// ScanFinding é um problema encontrado pelo scanner de segurança.
+type ScanFinding struct {
+	RuleID       string `json:"ruleId"`
+	Severity     string `json:"severity"`            // "high" | "medium" | "low"
+	LogicalID    string `json:"logicalId,omitempty"` // Recurso ou parâmetro
+	ResourceType string `json:"resourceType,omitempty"`
+	Path         string `json:"path,omitempty"` // JSON pointer no template
+	Line         int    `json:"line,omitempty"` // Só para templates enviados como texto
+	Message      string `json:"message"`
+}
+
+type ScanSummary struct {
+	High   int `json:"high"`
+	Medium int `json:"medium"`
+	Low    int `json:"low"`
+}
+
+type ScanResponse struct {
+	Passed   bool          `json:"passed"` // Nenhum finding high
+	Summary  ScanSummary   `json:"summary"`
+	Findings []ScanFinding `json:"findings"`
+
+	TemplateID      string `json:"templateId,omitempty"`
+	TemplateVersion string `json:"version,omitempty"`
+}
+
+// ScanError é a resposta 400 do create-stack quando o scan automático, em
+// modo enforce, encontra findings high.
+type ScanError struct {
+	Message  string        `json:"message"`
+	Findings []ScanFinding `json:"findings"`
+}
+
+// LintError é a resposta 400 do create-stack quando o template não confere
+// com a especificação de recursos do CloudFormation.
+type LintError struct {
+	Message  string    `json:"message"`
+	Findings []Finding `json:"findings"`
+}
+
+// GuardrailViolation é uma regra de guardrail descumprida pelo template.
+type GuardrailViolation struct {
+	RuleID       string `json:"ruleId"`
+	Severity     string `json:"severity"` // "low" | "medium" | "high" | "critical"
+	Policy       string `json:"policy"`   // Versão que trouxe a regra, ex. "owner@3" ou "account:prod@1"
+	LogicalID    string `json:"logicalId,omitempty"`
+	ResourceType string `json:"resourceType,omitempty"`
+	Path         string `json:"path,omitempty"` // JSON pointer no template
+	Message      string `json:"message"`
+}
+
+// GuardrailError é a resposta 403 quando o template viola guardrails em modo enforce.
+type GuardrailError struct {
+	Message    string               `json:"message"`
+	Violations []GuardrailViolation `json:"violations"`
+}
+
+// GuardrailRequest é o corpo de PUT /cf/guardrails.
+type GuardrailRequest struct {
+	AccountName string          `json:"accountName,omitempty"` // Vazio = policy do owner, vale para todas as contas
+	Document    json.RawMessage `json:"document"`              // Objeto JSON ou string YAML/JSON
+}
+
+// GuardrailPolicy é uma versão publicada de uma policy de guardrails.
+type GuardrailPolicy struct {
+	Owner       string          `json:"owner"`
+	AccountName string          `json:"accountName,omitempty"`
+	Version     int             `json:"version"`
+	Mode        string          `json:"mode"` // "enforce" | "warn"
+	Document    json.RawMessage `json:"document"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	Versions    []int           `json:"versions,omitempty"` // Todas as versões do escopo, da mais nova para a mais antiga
+}
+
+// AccountError é a falha de uma conta num create-stack com accountNames.
+type AccountError struct {
+	Code       string               `json:"code"` // ex. ALREADY_EXISTS, ACCOUNT_NOT_REGISTERED, REGION_NOT_ALLOWED
+	Message    string               `json:"message"`
+	Violations []GuardrailViolation `json:"violations,omitempty"` // Só para GUARDRAIL_VIOLATION
+}
+
+type AccountResult struct {
+	Account     string        `json:"account"`
+	Region      string        `json:"region,omitempty"`
+	Status      int           `json:"status"` // Status HTTP que a conta teria numa chamada individual
+	StackID     string        `json:"stackId,omitempty"`
+	StackStatus string        `json:"stackStatus,omitempty"`
+	Parameters  []Parameter   `json:"parameters,omitempty"` // NoEcho mascarado
+	Error       *AccountError `json:"error,omitempty"`
+
+	GuardrailWarnings []GuardrailViolation `json:"guardrailWarnings,omitempty"`
+}
+
+type MultiAccountResponse struct {
+	Message      string          `json:"message"`
+	StackName    string          `json:"stackName"`
+	Owner        string          `json:"owner"`
+	Succeeded    int             `json:"succeeded"`
+	Failed       int             `json:"failed"`
+	Capabilities []string        `json:"capabilities,omitempty"`
+	Results      []AccountResult `json:"results"` // Na ordem de accountNames
+
+	TemplateID      string `json:"templateId,omitempty"`
+	TemplateVersion string `json:"version,omitempty"`
+
+	SecurityFindings []ScanFinding `json:"securityFindings,omitempty"`
+}
+
+// StackPolicyRequest é o corpo de PUT .../policy.
+type StackPolicyRequest struct {
+	StackPolicy json.RawMessage `json:"stackPolicy"` // Objeto JSON ou string com o JSON
+}
+
+// TerminationProtectionRequest é o corpo de PUT .../termination-protection.
+type TerminationProtectionRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// StackProtectionResponse descreve as proteções de uma stack.
+type StackProtectionResponse struct {
+	Message               string          `json:"message"`
+	StackID               string          `json:"stackId"`
+	StackName             string          `json:"stackName"`
+	Account               string          `json:"account"`
+	Region                string          `json:"region"`
+	Owner                 string          `json:"owner"`
+	Status                string          `json:"status"`
+	TerminationProtection bool            `json:"terminationProtection"`
+	StackPolicy           json.RawMessage `json:"stackPolicy"` // null quando a stack não tem policy
+}
+
+type ChangeSetRequest struct {
+	RequestBody
+	ChangeSetName string `json:"changeSetName,omitempty"`
+	ChangeSetType string `json:"changeSetType,omitempty"` // "UPDATE" | "CREATE" (padrão: detectado)
+	Description   string `json:"description,omitempty"`
+}
+
+type ChangeDetail struct {
+	Attribute          string `json:"attribute,omitempty"`
+	Name               string `json:"name,omitempty"`
+	RequiresRecreation string `json:"requiresRecreation,omitempty"`
+	Evaluation         string `json:"evaluation,omitempty"`
+	ChangeSource       string `json:"changeSource,omitempty"`
+	CausingEntity      string `json:"causingEntity,omitempty"`
+}
+
+type ResourceChange struct {
+	Action       string         `json:"action"`
+	LogicalID    string         `json:"logicalId"`
+	PhysicalID   string         `json:"physicalId,omitempty"`
+	ResourceType string         `json:"resourceType"`
+	Replacement  string         `json:"replacement,omitempty"`
+	Scope        []string       `json:"scope,omitempty"`
+	Details      []ChangeDetail `json:"details,omitempty"`
+}
+
+type ChangeSetResponse struct {
+	Message         string           `json:"message"`
+	ChangeSetID     string           `json:"changeSetId"`
+	ChangeSetName   string           `json:"changeSetName,omitempty"`
+	ChangeSetType   string           `json:"changeSetType,omitempty"`
+	StackID         string           `json:"stackId,omitempty"`
+	StackName       string           `json:"stackName,omitempty"`
+	Account         string           `json:"account,omitempty"`
+	Region          string           `json:"region,omitempty"`
+	Owner           string           `json:"owner,omitempty"`
+	Status          string           `json:"status,omitempty"`
+	ExecutionStatus string           `json:"executionStatus,omitempty"`
+	StatusReason    string           `json:"statusReason,omitempty"`
+	CreatedAt       *time.Time       `json:"createdAt,omitempty"`
+	Parameters      []Parameter      `json:"parameters,omitempty"` // NoEcho mascarado
+	Capabilities    []string         `json:"capabilities,omitempty"`
+	Changes         []ResourceChange `json:"changes"`
+
+	GuardrailWarnings []GuardrailViolation `json:"guardrailWarnings,omitempty"`
+	SecurityFindings  []ScanFinding        `json:"securityFindings,omitempty"` // Scan automático em modo warn (só CREATE)
+}
+
+type ConvertRequest struct {
+	Template     json.RawMessage `json:"template,omitempty"`     // Objeto JSON ou string YAML/JSON
+	TemplateYAML string          `json:"templateYaml,omitempty"` // Alternativa: YAML
+	Format       string          `json:"format"`                 // "json" | "yaml"
+	ShortForm    bool            `json:"shortForm,omitempty"`    // Só para yaml: !Ref, !Sub...
+}
+
+type ConvertResponse struct {
+	Format     string `json:"format"`
+	Template   string `json:"template"`
+	JSONSize   int    `json:"jsonSize"`   // Tamanho do JSON compacto enviado ao CloudFormation
+	FitsInline bool   `json:"fitsInline"` // JSONSize <= 51.200 bytes
+}
+
+// Finding é um problema encontrado no template; Path é um JSON pointer
+// (RFC 6901) para o ponto do template, vazio quando vale para o todo.
+type Finding struct {
+	Severity string `json:"severity"` // "error" | "warning" | "info"
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+	Path     string `json:"path,omitempty"`
+}
+
+type TemplateParameter struct {
+	Key          string `json:"key"`
+	DefaultValue string `json:"defaultValue,omitempty"`
+	NoEcho       bool   `json:"noEcho,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+type ValidateResponse struct {
+	Valid              bool                `json:"valid"`
+	Account            string              `json:"account,omitempty"`
+	Owner              string              `json:"owner,omitempty"`
+	Description        string              `json:"description,omitempty"`
+	Parameters         []TemplateParameter `json:"parameters"`
+	Capabilities       []string            `json:"capabilities"`
+	CapabilitiesReason string              `json:"capabilitiesReason,omitempty"`
+	Transforms         []string            `json:"transforms,omitempty"`
+	Findings           []Finding           `json:"findings"`
+}
+
+// PreviewParameter é o valor efetivo de um parâmetro na prévia.
+type PreviewParameter struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`  // "****" para NoEcho; nos tipos SSM, o nome do parâmetro SSM
+	Source string `json:"source"` // "request" | "default" | "deploy"
+}
+
+type PreviewResource struct {
+	LogicalID string `json:"logicalId"`
+	Type      string `json:"type"`
+}
+
+// PreviewRemoved é um recurso ou output descartado por uma condição falsa.
+type PreviewRemoved struct {
+	Section   string `json:"section"` // "Resources" | "Outputs"
+	LogicalID string `json:"logicalId"`
+	Type      string `json:"type,omitempty"`
+	Condition string `json:"condition"`
+}
+
+// PreviewResponse é o template efetivo para os parâmetros informados.
+type PreviewResponse struct {
+	Account         string             `json:"account"`
+	AccountID       string             `json:"accountId"`
+	Region          string             `json:"region"`
+	Owner           string             `json:"owner,omitempty"`
+	StackName       string             `json:"stackName,omitempty"`
+	TemplateID      string             `json:"templateId,omitempty"`
+	TemplateVersion string             `json:"version,omitempty"`
+	Parameters      []PreviewParameter `json:"parameters"`
+	Conditions      map[string]bool    `json:"conditions"`
+	Resources       []PreviewResource  `json:"resources"` // Os que serão criados
+	Removed         []PreviewRemoved   `json:"removed"`
+	Warnings        []Finding          `json:"warnings"`
+	Template        map[string]any     `json:"template"` // GetAtt e Ref a recursos ficam como placeholders
+}
+
+type PropertyDifference struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"` // "ADD" | "REMOVE" | "NOT_EQUAL"
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+type ResourceDrift struct {
+	LogicalID    string               `json:"logicalId"`
+	PhysicalID   string               `json:"physicalId,omitempty"`
+	ResourceType string               `json:"resourceType"`
+	Status       string               `json:"status"` // "MODIFIED" | "DELETED" | "IN_SYNC" | "NOT_CHECKED"
+	CheckedAt    *time.Time           `json:"checkedAt,omitempty"`
+	Differences  []PropertyDifference `json:"differences,omitempty"`
+}
+
+type DriftResponse struct {
+	Message          string          `json:"message"`
+	DetectionID      string          `json:"detectionId"`
+	DetectionStatus  string          `json:"detectionStatus"`
+	StatusReason     string          `json:"statusReason,omitempty"`
+	DriftStatus      string          `json:"driftStatus,omitempty"` // "DRIFTED" | "IN_SYNC" | "NOT_CHECKED" | "UNKNOWN"
+	DriftedResources int32           `json:"driftedResources"`
+	StackID          string          `json:"stackId,omitempty"`
+	StackName        string          `json:"stackName,omitempty"`
+	Account          string          `json:"account,omitempty"`
+	Region           string          `json:"region,omitempty"`
+	Owner            string          `json:"owner,omitempty"`
+	CheckedAt        *time.Time      `json:"checkedAt,omitempty"`
+	Resources        []ResourceDrift `json:"resources"`
+}
+
+type DriftSweepSummary struct {
+	Owners         int `json:"owners"`
+	Accounts       int `json:"accounts"`
+	FailedAccounts int `json:"failedAccounts"`
+	Stacks         int `json:"stacks"`
+	Drifted        int `json:"drifted"`
+	FailedStacks   int `json:"failedStacks"`
+}
+
+// StackSetPreferences controla o rollout das instâncias (StackSetOperationPreferences).
+type StackSetPreferences struct {
+	MaxConcurrentCount         *int32 `json:"maxConcurrentCount,omitempty"`
+	MaxConcurrentPercentage    *int32 `json:"maxConcurrentPercentage,omitempty"`
+	FailureToleranceCount      *int32 `json:"failureToleranceCount,omitempty"`
+	FailureTolerancePercentage *int32 `json:"failureTolerancePercentage,omitempty"`
+	RegionConcurrencyType      string `json:"regionConcurrencyType,omitempty"` // "SEQUENTIAL" | "PARALLEL"
+}
+
+// StackSetRequest reaproveita o corpo de create-stack: accountName é a conta
+// administradora do stack set e stackName é ignorado.
+type StackSetRequest struct {
+	RequestBody
+	StackSetName          string              `json:"stackSetName"`
+	Description           string              `json:"description,omitempty"`
+	AccountNames          []string            `json:"accountNames"` // Contas cadastradas que recebem instâncias
+	Regions               []string            `json:"regions"`
+	AdministrationRoleARN string              `json:"administrationRoleArn,omitempty"`
+	ExecutionRoleName     string              `json:"executionRoleName,omitempty"`
+	Preferences           StackSetPreferences `json:"preferences,omitempty"`
+}
+
+type StackInstance struct {
+	AccountName    string `json:"accountName,omitempty"`
+	AccountID      string `json:"accountId"`
+	Region         string `json:"region"`
+	Status         string `json:"status"`                   // "CURRENT" | "OUTDATED" | "INOPERABLE"
+	DetailedStatus string `json:"detailedStatus,omitempty"` // "PENDING" | "RUNNING" | "SUCCEEDED" | "FAILED" | ...
+	StatusReason   string `json:"statusReason,omitempty"`
+	StackID        string `json:"stackId,omitempty"`
+	DriftStatus    string `json:"driftStatus,omitempty"`
+}
+
+type StackSetResponse struct {
+	Message         string          `json:"message"`
+	StackSetName    string          `json:"stackSetName"`
+	StackSetID      string          `json:"stackSetId,omitempty"`
+	Status          string          `json:"status,omitempty"`
+	Account         string          `json:"account,omitempty"` // Conta administradora
+	Owner           string          `json:"owner,omitempty"`
+	OperationID     string          `json:"operationId,omitempty"`
+	OperationStatus string          `json:"operationStatus,omitempty"`
+	Parameters      []Parameter     `json:"parameters,omitempty"` // NoEcho mascarado
+	Capabilities    []string        `json:"capabilities,omitempty"`
+	Instances       []StackInstance `json:"instances"`
+
+	GuardrailWarnings []GuardrailViolation `json:"guardrailWarnings,omitempty"` // Violações em modo warn, em qualquer conta x região
+	SecurityFindings  []ScanFinding        `json:"securityFindings,omitempty"`  // Scan automático em modo warn (só na criação)
+}
+
+// CatalogPublishRequest publica uma versão no catálogo de templates.
+type CatalogPublishRequest struct {
+	TemplateID   string          `json:"templateId"`
+	Version      string          `json:"version"` // Semver: MAJOR.MINOR.PATCH[-pre]
+	Description  string          `json:"description,omitempty"`
+	Template     json.RawMessage `json:"template"`
+	TemplateYAML string          `json:"templateYaml,omitempty"`
+}
+
+type DeprecateRequest struct {
+	Message string `json:"message,omitempty"`
+}
+
+type CatalogVersion struct {
+	TemplateID         string          `json:"templateId"`
+	Version            string          `json:"version"`
+	Description        string          `json:"description,omitempty"`
+	ContentHash        string          `json:"contentHash"`
+	Size               int             `json:"size"`
+	CreatedAt          time.Time       `json:"createdAt"`
+	Deprecated         bool            `json:"deprecated"`
+	DeprecationMessage string          `json:"deprecationMessage,omitempty"`
+	DeprecatedAt       *time.Time      `json:"deprecatedAt,omitempty"`
+	Template           json.RawMessage `json:"template,omitempty"` // só em GET de uma versão
+}
+
+type CatalogTemplate struct {
+	TemplateID    string           `json:"templateId"`
+	LatestVersion string           `json:"latestVersion,omitempty"` // Usada quando 'version' é omitido
+	Versions      []CatalogVersion `json:"versions"`
+}
+
+type CatalogResponse struct {
+	Owner     string            `json:"owner"`
+	Templates []CatalogTemplate `json:"templates"`
 }
@@ -0,0 +1,274 @@
+package validate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"create-stack-ms/internal/types"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+var sections = map[string]bool{
+	"AWSTemplateFormatVersion": true,
+	"Description":              true,
+	"Metadata":                 true,
+	"Parameters":               true,
+	"Rules":                    true,
+	"Mappings":                 true,
+	"Conditions":               true,
+	"Transform":                true,
+	"Resources":                true,
+	"Outputs":                  true,
+}
+
+// ${Nome} ou ${Recurso.Atributo}; ${!Literal} é escape e fica de fora.
+var subVar = regexp.MustCompile(`\$\{([^!}][^}]*)\}`)
+
+// checker acumula os achados de uma passada pelo template.
+type checker struct {
+	tpl        map[string]any
+	params     map[string]bool
+	resources  map[string]bool
+	conditions map[string]bool
+	used       map[string]bool // parâmetros referenciados
+	transform  bool            // com Transform (SAM, macros) há recursos gerados que não vemos
+	findings   []types.Finding
+}
+
+// Template roda as verificações estruturais locais, sem chamar a AWS.
+// Os achados saem ordenados pelo path (JSON pointer).
+func Template(tpl map[string]any) []types.Finding {
+	c := &checker{
+		tpl:        tpl,
+		params:     keys(tpl["Parameters"]),
+		resources:  keys(tpl["Resources"]),
+		conditions: keys(tpl["Conditions"]),
+		used:       map[string]bool{},
+		transform:  tpl["Transform"] != nil,
+	}
+	c.sections()
+	c.resourceTypes()
+	c.dependsOn()
+	for _, section := range []string{"Resources", "Outputs", "Conditions", "Rules", "Metadata"} {
+		if v, ok := tpl[section]; ok {
+			c.walk(v, "/"+section)
+		}
+	}
+	c.unusedParameters()
+
+	sort.SliceStable(c.findings, func(i, j int) bool { return c.findings[i].Path < c.findings[j].Path })
+	return c.findings
+}
+
+// HasErrors indica se algum achado tem severidade error.
+func HasErrors(findings []types.Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) add(severity, rule, path, format string, args ...any) {
+	c.findings = append(c.findings, types.Finding{
+		Severity: severity,
+		Rule:     rule,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *checker) sections() {
+	for k := range c.tpl {
+		if !sections[k] {
+			c.add(SeverityError, "unknown-section", Pointer(k), "unknown top-level section '%s'", k)
+		}
+	}
+	if v, ok := c.tpl["AWSTemplateFormatVersion"]; ok && v != "2010-09-09" {
+		c.add(SeverityError, "format-version", Pointer("AWSTemplateFormatVersion"), "AWSTemplateFormatVersion must be '2010-09-09'")
+	}
+	if _, ok := c.tpl["Resources"].(map[string]any); !ok {
+		c.add(SeverityError, "resources-missing", Pointer("Resources"), "template must declare a 'Resources' object")
+		return
+	}
+	if len(c.resources) == 0 {
+		c.add(SeverityError, "resources-missing", Pointer("Resources"), "template must declare at least one resource")
+	}
+}
+
+func (c *checker) resourceTypes() {
+	resources, _ := c.tpl["Resources"].(map[string]any)
+	for name, v := range resources {
+		res, ok := v.(map[string]any)
+		if !ok {
+			c.add(SeverityError, "resource-shape", Pointer("Resources", name), "resource '%s' must be an object", name)
+			continue
+		}
+		if t, ok := res["Type"].(string); !ok || t == "" {
+			c.add(SeverityError, "resource-type", Pointer("Resources", name), "resource '%s' has no 'Type'", name)
+		}
+		if cond, ok := res["Condition"].(string); ok && !c.conditions[cond] {
+			c.add(SeverityError, "condition-missing", Pointer("Resources", name, "Condition"), "condition '%s' is not declared", cond)
+		}
+	}
+}
+
+func (c *checker) dependsOn() {
+	resources, _ := c.tpl["Resources"].(map[string]any)
+	for name, v := range resources {
+		res, _ := v.(map[string]any)
+		var deps []string
+		switch d := res["DependsOn"].(type) {
+		case nil:
+			continue
+		case string:
+			deps = []string{d}
+		case []any:
+			for _, it := range d {
+				if s, ok := it.(string); ok {
+					deps = append(deps, s)
+				}
+			}
+		default:
+			c.add(SeverityError, "depends-on", Pointer("Resources", name, "DependsOn"), "DependsOn must be a string or a list of strings")
+			continue
+		}
+		for _, dep := range deps {
+			switch {
+			case dep == name:
+				c.add(SeverityError, "depends-on", Pointer("Resources", name, "DependsOn"), "resource '%s' depends on itself", name)
+			case !c.resources[dep]:
+				c.add(c.unresolvedSeverity(), "depends-on", Pointer("Resources", name, "DependsOn"), "DependsOn target '%s' is not a resource in this template", dep)
+			}
+		}
+	}
+}
+
+// walk percorre o valor procurando Ref, Fn::GetAtt e Fn::Sub.
+func (c *checker) walk(v any, path string) {
+	switch val := v.(type) {
+	case []any:
+		for i, it := range val {
+			c.walk(it, fmt.Sprintf("%s/%d", path, i))
+		}
+	case map[string]any:
+		if len(val) == 1 {
+			for k, arg := range val {
+				switch k {
+				case "Ref":
+					if name, ok := arg.(string); ok {
+						c.ref(name, path+"/Ref")
+					}
+				case "Fn::GetAtt":
+					c.getAtt(arg, path+"/Fn::GetAtt")
+				case "Fn::Sub":
+					c.sub(arg, path+"/Fn::Sub")
+				}
+			}
+		}
+		for k, it := range val {
+			c.walk(it, path+"/"+escape(k))
+		}
+	}
+}
+
+func (c *checker) ref(name, path string) {
+	if c.params[name] {
+		c.used[name] = true
+		return
+	}
+	if c.resources[name] || strings.HasPrefix(name, "AWS::") {
+		return
+	}
+	c.add(c.unresolvedSeverity(), "unresolved-ref", path, "Ref target '%s' is not a parameter, resource or pseudo parameter", name)
+}
+
+func (c *checker) getAtt(arg any, path string) {
+	var name string
+	switch a := arg.(type) {
+	case []any:
+		if len(a) > 0 {
+			name, _ = a[0].(string)
+		}
+	case string:
+		name, _, _ = strings.Cut(a, ".")
+	}
+	if name != "" && !c.resources[name] {
+		c.add(c.unresolvedSeverity(), "unresolved-getatt", path, "Fn::GetAtt target '%s' is not a resource in this template", name)
+	}
+}
+
+func (c *checker) sub(arg any, path string) {
+	var text string
+	local := map[string]bool{}
+	switch a := arg.(type) {
+	case string:
+		text = a
+	case []any:
+		if len(a) > 0 {
+			text, _ = a[0].(string)
+		}
+		if len(a) > 1 {
+			local = keys(a[1])
+		}
+	}
+	for _, m := range subVar.FindAllStringSubmatch(text, -1) {
+		name := strings.TrimSpace(m[1])
+		if local[name] {
+			continue
+		}
+		if res, _, isAttr := strings.Cut(name, "."); isAttr {
+			if !c.resources[res] {
+				c.add(c.unresolvedSeverity(), "unresolved-sub", path, "Fn::Sub variable '${%s}' does not match a resource", name)
+			}
+			continue
+		}
+		c.ref(name, path)
+	}
+}
+
+func (c *checker) unusedParameters() {
+	for name := range c.params {
+		if !c.used[name] {
+			c.add(SeverityWarning, "unused-parameter", Pointer("Parameters", name), "parameter '%s' is never referenced", name)
+		}
+	}
+}
+
+func (c *checker) unresolvedSeverity() string {
+	if c.transform {
+		return SeverityWarning
+	}
+	return SeverityError
+}
+
+func keys(v any) map[string]bool {
+	m, _ := v.(map[string]any)
+	out := make(map[string]bool, len(m))
+	for k := range m {
+		out[k] = true
+	}
+	return out
+}
+
+// Pointer monta um JSON pointer (RFC 6901) a partir dos segmentos.
+func Pointer(segments ...string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		b.WriteString(escape(s))
+	}
+	return b.String()
+}
+
+func escape(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
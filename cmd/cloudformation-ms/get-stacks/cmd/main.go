@@ -0,0 +1,11 @@
+package main
+
+import (
+	"get-stacks/internal/handler"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	lambda.Start(handler.Handler)
+}
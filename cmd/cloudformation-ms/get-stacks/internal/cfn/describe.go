@@ -0,0 +1,124 @@
+package cfn
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/smithy-go"
+
+	"get-stacks/internal/types"
+)
+
+var ErrStackNotFound = errors.New("stack not found")
+
+type DescribeAPI interface {
+	cf.DescribeStacksAPIClient
+	cf.ListStackResourcesAPIClient
+}
+
+// IsNotFound reconhece o ValidationError que o CloudFormation devolve para
+// stacks inexistentes (não existe um tipo de erro específico para isso).
+func IsNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == "ValidationError" && strings.Contains(apiErr.ErrorMessage(), "does not exist")
+	}
+	return false
+}
+
+// LookupStack busca uma stack por nome ou StackId.
+func LookupStack(ctx context.Context, api cf.DescribeStacksAPIClient, stackName string) (cft.Stack, error) {
+	out, err := api.DescribeStacks(ctx, &cf.DescribeStacksInput{StackName: aws.String(stackName)})
+	if err != nil {
+		if IsNotFound(err) {
+			return cft.Stack{}, ErrStackNotFound
+		}
+		return cft.Stack{}, err
+	}
+	if len(out.Stacks) == 0 {
+		return cft.Stack{}, ErrStackNotFound
+	}
+	return out.Stacks[0], nil
+}
+
+// DescribeStack junta DescribeStacks e ListStackResources numa única visão.
+func DescribeStack(ctx context.Context, api DescribeAPI, stackName string) (types.StackDetail, error) {
+	s, err := LookupStack(ctx, api, stackName)
+	if err != nil {
+		return types.StackDetail{}, err
+	}
+
+	d := types.StackDetail{
+		StackName:                   aws.ToString(s.StackName),
+		StackID:                     aws.ToString(s.StackId),
+		Status:                      string(s.StackStatus),
+		Lifecycle:                   string(LifecycleOf(s.StackStatus)),
+		StatusReason:                aws.ToString(s.StackStatusReason),
+		Description:                 aws.ToString(s.Description),
+		RoleARN:                     aws.ToString(s.RoleARN),
+		DisableRollback:             aws.ToBool(s.DisableRollback),
+		EnableTerminationProtection: aws.ToBool(s.EnableTerminationProtection),
+		ParentID:                    aws.ToString(s.ParentId),
+		RootID:                      aws.ToString(s.RootId),
+		CreatedAt:                   s.CreationTime,
+		UpdatedAt:                   s.LastUpdatedTime,
+		DeletedAt:                   s.DeletionTime,
+		Outputs:                     []types.StackOutput{},
+		Parameters:                  []types.StackParameter{},
+		Tags:                        map[string]string{},
+		Resources:                   []types.StackResource{},
+	}
+	if s.DriftInformation != nil {
+		d.DriftStatus = string(s.DriftInformation.StackDriftStatus)
+	}
+	for _, c := range s.Capabilities {
+		d.Capabilities = append(d.Capabilities, string(c))
+	}
+	for _, o := range s.Outputs {
+		d.Outputs = append(d.Outputs, types.StackOutput{
+			Key:         aws.ToString(o.OutputKey),
+			Value:       aws.ToString(o.OutputValue),
+			Description: aws.ToString(o.Description),
+			ExportName:  aws.ToString(o.ExportName),
+		})
+	}
+	// Valores NoEcho já chegam mascarados ("****") pelo próprio CloudFormation
+	for _, p := range s.Parameters {
+		d.Parameters = append(d.Parameters, types.StackParameter{
+			Key:           aws.ToString(p.ParameterKey),
+			Value:         aws.ToString(p.ParameterValue),
+			ResolvedValue: aws.ToString(p.ResolvedValue),
+		})
+	}
+	for _, t := range s.Tags {
+		d.Tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+
+	// Usa o StackId: funciona também para stacks já deletadas
+	p := cf.NewListStackResourcesPaginator(api, &cf.ListStackResourcesInput{StackName: s.StackId})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return types.StackDetail{}, err
+		}
+		for _, r := range page.StackResourceSummaries {
+			res := types.StackResource{
+				LogicalID:    aws.ToString(r.LogicalResourceId),
+				PhysicalID:   aws.ToString(r.PhysicalResourceId),
+				Type:         aws.ToString(r.ResourceType),
+				Status:       string(r.ResourceStatus),
+				StatusReason: aws.ToString(r.ResourceStatusReason),
+				UpdatedAt:    r.LastUpdatedTimestamp,
+			}
+			if r.DriftInformation != nil {
+				res.DriftStatus = string(r.DriftInformation.StackResourceDriftStatus)
+			}
+			d.Resources = append(d.Resources, res)
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,220 @@
+package cfn
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+
+	"get-stacks/internal/types"
+)
+
+const (
+	DefaultEventsLimit = 100
+	MaxEventsLimit     = 500
+
+	nestedStackType = "AWS::CloudFormation::Stack"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type EventsAPI interface {
+	cf.DescribeStackEventsAPIClient
+	cf.ListStackResourcesAPIClient
+}
+
+// eventCursor marca o último evento entregue. Vários eventos podem ter o mesmo
+// timestamp, então os IDs daquele instante vão junto para evitar duplicatas.
+type eventCursor struct {
+	Timestamp time.Time `json:"ts"`
+	IDs       []string  `json:"ids,omitempty"`
+}
+
+func decodeEventCursor(s string) (*eventCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c eventCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Timestamp.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+func (c *eventCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// after indica se o evento ainda não foi entregue ao cliente.
+func (c *eventCursor) after(ts time.Time, id string) bool {
+	if c == nil || ts.After(c.Timestamp) {
+		return true
+	}
+	if ts.Before(c.Timestamp) {
+		return false
+	}
+	for _, seen := range c.IDs {
+		if seen == id {
+			return false
+		}
+	}
+	return true
+}
+
+type EventsQuery struct {
+	Cursor        string
+	IncludeNested bool
+	Limit         int
+}
+
+// StackEvents devolve os eventos posteriores ao cursor, do mais antigo para o
+// mais novo, e o cursor para a próxima chamada. Sem cursor, devolve os
+// `Limit` eventos mais recentes. Com cursor e mais eventos novos do que o
+// limite, devolve os mais antigos primeiro; o restante vem no próximo poll.
+func StackEvents(ctx context.Context, api EventsAPI, stackID string, q EventsQuery) ([]types.StackEvent, string, error) {
+	cur, err := decodeEventCursor(q.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultEventsLimit
+	}
+
+	stackIDs := []string{stackID}
+	if q.IncludeNested {
+		nested, err := nestedStacks(ctx, api, stackID)
+		if err != nil {
+			return nil, "", err
+		}
+		stackIDs = append(stackIDs, nested...)
+	}
+
+	seen := map[string]bool{}
+	var all []types.StackEvent
+	for i, id := range stackIDs {
+		evs, err := newerEvents(ctx, api, id, cur, q.Limit)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, e := range evs {
+			if seen[e.EventID] {
+				continue
+			}
+			seen[e.EventID] = true
+			e.Nested = i > 0
+			all = append(all, e)
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Timestamp.Before(all[j].Timestamp) })
+	if len(all) > q.Limit {
+		if cur == nil {
+			all = all[len(all)-q.Limit:]
+		} else {
+			all = all[:q.Limit]
+		}
+	}
+
+	if len(all) == 0 {
+		return []types.StackEvent{}, q.Cursor, nil
+	}
+	next := &eventCursor{Timestamp: all[len(all)-1].Timestamp}
+	if cur != nil && cur.Timestamp.Equal(next.Timestamp) {
+		next.IDs = append(next.IDs, cur.IDs...)
+	}
+	for _, e := range all {
+		if e.Timestamp.Equal(next.Timestamp) {
+			next.IDs = append(next.IDs, e.EventID)
+		}
+	}
+	return all, next.encode(), nil
+}
+
+// newerEvents percorre DescribeStackEvents (mais novo primeiro) até cruzar o
+// cursor; sem cursor, para ao juntar `limit` eventos. Retorna em ordem cronológica.
+func newerEvents(ctx context.Context, api EventsAPI, stackID string, cur *eventCursor, limit int) ([]types.StackEvent, error) {
+	var out []types.StackEvent
+	p := cf.NewDescribeStackEventsPaginator(api, &cf.DescribeStackEventsInput{StackName: aws.String(stackID)})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			if IsNotFound(err) {
+				return nil, ErrStackNotFound
+			}
+			return nil, err
+		}
+		for _, e := range page.StackEvents {
+			ts := aws.ToTime(e.Timestamp)
+			if cur != nil && ts.Before(cur.Timestamp) {
+				return reverse(out), nil
+			}
+			if !cur.after(ts, aws.ToString(e.EventId)) {
+				continue
+			}
+			out = append(out, types.StackEvent{
+				EventID:            aws.ToString(e.EventId),
+				StackID:            aws.ToString(e.StackId),
+				StackName:          aws.ToString(e.StackName),
+				LogicalID:          aws.ToString(e.LogicalResourceId),
+				PhysicalID:         aws.ToString(e.PhysicalResourceId),
+				ResourceType:       aws.ToString(e.ResourceType),
+				Status:             string(e.ResourceStatus),
+				StatusReason:       aws.ToString(e.ResourceStatusReason),
+				ClientRequestToken: aws.ToString(e.ClientRequestToken),
+				Timestamp:          ts,
+			})
+			if cur == nil && len(out) >= limit {
+				return reverse(out), nil
+			}
+		}
+	}
+	return reverse(out), nil
+}
+
+// nestedStacks descobre, recursivamente, os StackIds das stacks aninhadas.
+func nestedStacks(ctx context.Context, api EventsAPI, stackID string) ([]string, error) {
+	var out []string
+	queue := []string{stackID}
+	visited := map[string]bool{stackID: true}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		p := cf.NewListStackResourcesPaginator(api, &cf.ListStackResourcesInput{StackName: aws.String(id)})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				if IsNotFound(err) {
+					return nil, ErrStackNotFound
+				}
+				return nil, err
+			}
+			for _, r := range page.StackResourceSummaries {
+				child := aws.ToString(r.PhysicalResourceId)
+				if aws.ToString(r.ResourceType) != nestedStackType || child == "" || visited[child] {
+					continue
+				}
+				visited[child] = true
+				out = append(out, child)
+				queue = append(queue, child)
+			}
+		}
+	}
+	return out, nil
+}
+
+func reverse(evs []types.StackEvent) []types.StackEvent {
+	for i, j := 0, len(evs)-1; i < j; i, j = i+1, j-1 {
+		evs[i], evs[j] = evs[j], evs[i]
+	}
+	return evs
+}
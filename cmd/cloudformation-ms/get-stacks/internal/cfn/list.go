@@ -0,0 +1,261 @@
+package cfn
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cft "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+
+	"get-stacks/internal/types"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+var ErrInvalidToken = errors.New("invalid nextToken")
+
+type ListAPI interface {
+	cf.ListStacksAPIClient
+	cf.DescribeStacksAPIClient
+}
+
+type Filter struct {
+	Statuses   []cft.StackStatus
+	NamePrefix string
+	Tags       map[string]string // valor vazio = apenas presença da chave
+	Limit      int
+}
+
+// FilterFromQuery lê os query params de GET /cf/stacks. Parâmetros repetidos
+// chegam do API Gateway separados por vírgula.
+func FilterFromQuery(q map[string]string) (Filter, error) {
+	f := Filter{
+		NamePrefix: strings.TrimSpace(q["namePrefix"]),
+		Limit:      DefaultLimit,
+	}
+
+	if raw := strings.TrimSpace(q["status"]); raw != "" {
+		valid := map[string]bool{}
+		for _, s := range cft.StackStatus("").Values() {
+			valid[string(s)] = true
+		}
+		for _, s := range strings.Split(raw, ",") {
+			s = strings.ToUpper(strings.TrimSpace(s))
+			if s == "" {
+				continue
+			}
+			if !valid[s] {
+				return Filter{}, fmt.Errorf("invalid status: %s", s)
+			}
+			f.Statuses = append(f.Statuses, cft.StackStatus(s))
+		}
+	} else {
+		// Mesmo comportamento do console: stacks deletadas ficam de fora por padrão
+		for _, s := range cft.StackStatus("").Values() {
+			if s != cft.StackStatusDeleteComplete {
+				f.Statuses = append(f.Statuses, s)
+			}
+		}
+	}
+
+	if raw := strings.TrimSpace(q["tag"]); raw != "" {
+		f.Tags = map[string]string{}
+		for _, kv := range strings.Split(raw, ",") {
+			k, v, _ := strings.Cut(kv, "=")
+			k = strings.TrimSpace(k)
+			if k == "" {
+				return Filter{}, fmt.Errorf("invalid tag filter: %q (expected key or key=value)", kv)
+			}
+			f.Tags[k] = strings.TrimSpace(v)
+		}
+	}
+
+	if raw := strings.TrimSpace(q["limit"]); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > MaxLimit {
+			return Filter{}, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		f.Limit = n
+	}
+	return f, nil
+}
+
+// cursor é a posição dentro da listagem do CloudFormation: o token da página
+// atual e quantos itens dela já foram consumidos. Como os filtros são aplicados
+// aqui, uma página do cliente raramente coincide com uma página da AWS.
+type cursor struct {
+	Token  string `json:"t,omitempty"`
+	Offset int    `json:"o,omitempty"`
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	if s == "" {
+		return c, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidToken
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.Offset < 0 {
+		return c, ErrInvalidToken
+	}
+	return c, nil
+}
+
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+type page struct {
+	items []types.StackSummary
+	next  *string
+}
+
+// ListStacks pagina as stacks da conta aplicando os filtros. Sem filtro de tag
+// usa ListStacks (que também enxerga stacks deletadas); com filtro de tag usa
+// DescribeStacks, que já devolve as tags sem uma chamada extra por stack.
+func ListStacks(ctx context.Context, api ListAPI, f Filter, nextToken string) ([]types.StackSummary, string, error) {
+	cur, err := decodeCursor(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+	if f.Limit <= 0 {
+		f.Limit = DefaultLimit
+	}
+
+	fetch := listPage
+	if len(f.Tags) > 0 {
+		fetch = describePage
+	}
+
+	items := []types.StackSummary{}
+	for {
+		p, err := fetch(ctx, api, f, cur.Token)
+		if err != nil {
+			return nil, "", err
+		}
+		for i := cur.Offset; i < len(p.items); i++ {
+			if !f.match(p.items[i]) {
+				continue
+			}
+			items = append(items, p.items[i])
+			if len(items) < f.Limit {
+				continue
+			}
+			switch {
+			case i+1 < len(p.items):
+				return items, cursor{Token: cur.Token, Offset: i + 1}.encode(), nil
+			case p.next != nil:
+				return items, cursor{Token: *p.next}.encode(), nil
+			default:
+				return items, "", nil
+			}
+		}
+		if p.next == nil {
+			return items, "", nil
+		}
+		cur = cursor{Token: *p.next}
+	}
+}
+
+func (f Filter) match(s types.StackSummary) bool {
+	if f.NamePrefix != "" && !strings.HasPrefix(s.StackName, f.NamePrefix) {
+		return false
+	}
+	if len(f.Statuses) > 0 {
+		ok := false
+		for _, st := range f.Statuses {
+			if string(st) == s.Status {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	for k, v := range f.Tags {
+		got, ok := s.Tags[k]
+		if !ok || (v != "" && got != v) {
+			return false
+		}
+	}
+	return true
+}
+
+func listPage(ctx context.Context, api ListAPI, f Filter, token string) (page, error) {
+	in := &cf.ListStacksInput{StackStatusFilter: f.Statuses}
+	if token != "" {
+		in.NextToken = aws.String(token)
+	}
+	out, err := api.ListStacks(ctx, in)
+	if err != nil {
+		return page{}, err
+	}
+	p := page{next: out.NextToken}
+	for _, s := range out.StackSummaries {
+		item := types.StackSummary{
+			StackName:    aws.ToString(s.StackName),
+			StackID:      aws.ToString(s.StackId),
+			Status:       string(s.StackStatus),
+			StatusReason: aws.ToString(s.StackStatusReason),
+			Description:  aws.ToString(s.TemplateDescription),
+			ParentID:     aws.ToString(s.ParentId),
+			RootID:       aws.ToString(s.RootId),
+			CreatedAt:    s.CreationTime,
+			UpdatedAt:    s.LastUpdatedTime,
+			DeletedAt:    s.DeletionTime,
+		}
+		if s.DriftInformation != nil {
+			item.DriftStatus = string(s.DriftInformation.StackDriftStatus)
+		}
+		p.items = append(p.items, item)
+	}
+	return p, nil
+}
+
+func describePage(ctx context.Context, api ListAPI, _ Filter, token string) (page, error) {
+	in := &cf.DescribeStacksInput{}
+	if token != "" {
+		in.NextToken = aws.String(token)
+	}
+	out, err := api.DescribeStacks(ctx, in)
+	if err != nil {
+		return page{}, err
+	}
+	p := page{next: out.NextToken}
+	for _, s := range out.Stacks {
+		item := types.StackSummary{
+			StackName:    aws.ToString(s.StackName),
+			StackID:      aws.ToString(s.StackId),
+			Status:       string(s.StackStatus),
+			StatusReason: aws.ToString(s.StackStatusReason),
+			Description:  aws.ToString(s.Description),
+			ParentID:     aws.ToString(s.ParentId),
+			RootID:       aws.ToString(s.RootId),
+			CreatedAt:    s.CreationTime,
+			UpdatedAt:    s.LastUpdatedTime,
+			DeletedAt:    s.DeletionTime,
+			Tags:         map[string]string{},
+		}
+		if s.DriftInformation != nil {
+			item.DriftStatus = string(s.DriftInformation.StackDriftStatus)
+		}
+		for _, t := range s.Tags {
+			item.Tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+		}
+		p.items = append(p.items, item)
+	}
+	return p, nil
+}
@@ -0,0 +1,61 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"get-stacks/internal/types"
+)
+
+var ErrRegionNotAllowed = errors.New("region not allowed")
+
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-\d$`)
+
+// ValidRegion confere só o formato (ex. us-east-1); a existência da região
+// fica a cargo do STS.
+func ValidRegion(region string) bool {
+	return regionPattern.MatchString(region)
+}
+
+// DefaultRegion é a região padrão da conta cadastrada, ou fallback (a região
+// da Lambda) para contas cadastradas antes das regiões por conta.
+func DefaultRegion(keys types.SecretKeys, fallback string) string {
+	if keys.DefaultRegion != "" {
+		return keys.DefaultRegion
+	}
+	return fallback
+}
+
+// AllowedRegions são as regiões em que a conta aceita operações: a lista
+// cadastrada mais a região padrão.
+func AllowedRegions(keys types.SecretKeys, fallback string) []string {
+	def := DefaultRegion(keys, fallback)
+	out := []string{def}
+	for _, r := range keys.AllowedRegions {
+		if r != def {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ResolveRegion devolve a região da requisição (ou a padrão da conta, quando
+// vazia) depois de validá-la contra a lista da conta.
+func ResolveRegion(keys types.SecretKeys, requested, fallback string) (string, error) {
+	region := strings.TrimSpace(requested)
+	if region == "" {
+		return DefaultRegion(keys, fallback), nil
+	}
+	if !ValidRegion(region) {
+		return "", fmt.Errorf("invalid region '%s'", region)
+	}
+	allowed := AllowedRegions(keys, fallback)
+	for _, r := range allowed {
+		if r == region {
+			return region, nil
+		}
+	}
+	return "", fmt.Errorf("%w: '%s' (allowed: %s)", ErrRegionNotAllowed, region, strings.Join(allowed, ", "))
+}
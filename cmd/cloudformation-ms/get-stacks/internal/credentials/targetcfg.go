@@ -0,0 +1,56 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"get-stacks/internal/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// BuildTargetConfig monta a config da conta alvo na região informada. O STS
+// (AssumeRole e a validação) usa o endpoint regional dessa mesma região.
+func BuildTargetConfig(ctx context.Context, base aws.Config, keys types.SecretKeys, region string) (aws.Config, error) {
+	target := base.Copy()
+	if region != "" {
+		target.Region = region
+	}
+
+	if keys.AccessKeyID != "" && keys.SecretAccessKey != "" {
+		target.Credentials = aws.NewCredentialsCache(
+			credentials.NewStaticCredentialsProvider(
+				keys.AccessKeyID, keys.SecretAccessKey, keys.SessionToken,
+			),
+		)
+	}
+
+	// AssumeRole (opcional)
+	if keys.RoleARN != "" {
+		stsClient := sts.NewFromConfig(target)
+		sessionName := fmt.Sprintf("cfn-%d", time.Now().Unix())
+		p := stscreds.NewAssumeRoleProvider(stsClient, keys.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = sessionName
+			if keys.ExternalID != "" {
+				o.ExternalID = &keys.ExternalID
+			}
+		})
+		target.Credentials = aws.NewCredentialsCache(p)
+		log.Printf("[INFO] Using AssumeRole: roleArn=%s sessionName=%s", keys.RoleARN, sessionName)
+	}
+
+	// Validação STS
+	idOut, err := sts.NewFromConfig(target).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return target, fmt.Errorf("STS GetCallerIdentity failed (creds inválidas/expiradas?): %w", err)
+	}
+	log.Printf("[INFO] Caller identity: Account=%s ARN=%s UserId=%s Region=%s",
+		aws.ToString(idOut.Account), aws.ToString(idOut.Arn), aws.ToString(idOut.UserId), target.Region)
+
+	return target, nil
+}
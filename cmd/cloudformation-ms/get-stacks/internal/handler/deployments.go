@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"get-stacks/internal/httpresp"
+	"get-stacks/internal/inventory"
+	"get-stacks/internal/types"
+)
+
+// DeploymentsHandler atende GET /cf/deployments: o que o owner já implantou
+// pela API, em todas as contas, lido do inventário (sem chamar as contas alvo).
+//
+// Query params:
+//   - accountName: só as stacks dessa conta
+//   - stackId: histórico de operações da stack
+//   - limit, nextToken: paginação
+func DeploymentsHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	table := os.Getenv("DEPLOYMENTS_TABLE_NAME")
+	if table == "" {
+		return httpresp.Error(500, errors.New("deployment inventory is not configured")), nil
+	}
+
+	q := req.QueryStringParameters
+	accountName := strings.TrimSpace(q["accountName"])
+	stackID := strings.TrimSpace(q["stackId"])
+	if accountName != "" && stackID != "" {
+		return httpresp.Error(400, errors.New("use either 'accountName' or 'stackId', not both")), nil
+	}
+	limit := inventory.DefaultLimit
+	if raw := strings.TrimSpace(q["limit"]); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > inventory.MaxLimit {
+			return httpresp.Error(400, fmt.Errorf("limit must be between 1 and %d", inventory.MaxLimit)), nil
+		}
+		limit = n
+	}
+
+	r := inventory.NewReader(dynamodb.NewFromConfig(s.cfg), table)
+	var (
+		items []types.Deployment
+		next  string
+		err   error
+	)
+	if stackID != "" {
+		items, next, err = r.History(ctx, s.owner, stackID, limit, q["nextToken"])
+	} else {
+		items, next, err = r.Stacks(ctx, s.owner, accountName, limit, q["nextToken"])
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, inventory.ErrInvalidToken):
+			return httpresp.Error(400, err), nil
+		case errors.Is(err, inventory.ErrStackNotFound):
+			return httpresp.Error(404, fmt.Errorf("stack '%s' not found in inventory", stackID)), nil
+		}
+		return httpresp.Error(502, fmt.Errorf("query inventory failed: %w", err)), nil
+	}
+	log.Printf("[INFO] Inventory query: accountName=%s stackId=%s count=%d hasMore=%t", accountName, stackID, len(items), next != "")
+
+	return httpresp.OK(200, types.DeploymentsResponse{
+		Owner:       s.owner,
+		Account:     accountName,
+		StackID:     stackID,
+		Deployments: items,
+		NextToken:   next,
+	}), nil
+}
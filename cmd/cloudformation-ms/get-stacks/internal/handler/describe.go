@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+
+	"get-stacks/internal/cfn"
+	"get-stacks/internal/httpresp"
+)
+
+// DescribeHandler atende GET /cf/stacks/{accountName}/{stackName}.
+func DescribeHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	accountName := strings.TrimSpace(req.PathParameters["accountName"])
+	stackName := strings.TrimSpace(req.PathParameters["stackName"])
+	if accountName == "" || stackName == "" {
+		return httpresp.Error(400, errors.New("path parameters 'accountName' and 'stackName' are required")), nil
+	}
+
+	targetCfg, errResp := s.targetConfig(ctx, accountName, queryRegion(req))
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	detail, err := cfn.DescribeStack(ctx, cf.NewFromConfig(targetCfg), stackName)
+	if err != nil {
+		if errors.Is(err, cfn.ErrStackNotFound) {
+			return httpresp.Error(404, fmt.Errorf("stack '%s' not found in account '%s'", stackName, accountName)), nil
+		}
+		return httpresp.Error(502, fmt.Errorf("describe stack failed: %w", err)), nil
+	}
+	detail.Account = accountName
+	detail.Region = targetCfg.Region
+	detail.Owner = s.owner
+	log.Printf("[INFO] Described stack: stackId=%s status=%s lifecycle=%s resources=%d",
+		detail.StackID, detail.Status, detail.Lifecycle, len(detail.Resources))
+
+	return httpresp.OK(200, detail), nil
+}
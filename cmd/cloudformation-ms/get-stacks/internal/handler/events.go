@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+
+	"get-stacks/internal/cfn"
+	"get-stacks/internal/httpresp"
+	"get-stacks/internal/types"
+)
+
+// EventsHandler atende GET /cf/stacks/{accountName}/{stackName}/events.
+func EventsHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	accountName := strings.TrimSpace(req.PathParameters["accountName"])
+	stackName := strings.TrimSpace(req.PathParameters["stackName"])
+	if accountName == "" || stackName == "" {
+		return httpresp.Error(400, errors.New("path parameters 'accountName' and 'stackName' are required")), nil
+	}
+
+	q := req.QueryStringParameters
+	query := cfn.EventsQuery{
+		Cursor:        q["cursor"],
+		IncludeNested: strings.EqualFold(q["includeNested"], "true"),
+		Limit:         cfn.DefaultEventsLimit,
+	}
+	if raw := strings.TrimSpace(q["limit"]); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > cfn.MaxEventsLimit {
+			return httpresp.Error(400, fmt.Errorf("limit must be between 1 and %d", cfn.MaxEventsLimit)), nil
+		}
+		query.Limit = n
+	}
+
+	targetCfg, errResp := s.targetConfig(ctx, accountName, queryRegion(req))
+	if errResp != nil {
+		return *errResp, nil
+	}
+	client := cf.NewFromConfig(targetCfg)
+
+	stack, err := cfn.LookupStack(ctx, client, stackName)
+	if err != nil {
+		if errors.Is(err, cfn.ErrStackNotFound) {
+			return httpresp.Error(404, fmt.Errorf("stack '%s' not found in account '%s'", stackName, accountName)), nil
+		}
+		return httpresp.Error(502, fmt.Errorf("describe stack failed: %w", err)), nil
+	}
+
+	evs, cursor, err := cfn.StackEvents(ctx, client, aws.ToString(stack.StackId), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, cfn.ErrInvalidCursor):
+			return httpresp.Error(400, err), nil
+		case errors.Is(err, cfn.ErrStackNotFound):
+			return httpresp.Error(404, fmt.Errorf("stack '%s' not found in account '%s'", stackName, accountName)), nil
+		}
+		return httpresp.Error(502, fmt.Errorf("describe stack events failed: %w", err)), nil
+	}
+	lc := cfn.LifecycleOf(stack.StackStatus)
+	log.Printf("[INFO] Stack events: stackId=%s events=%d nested=%t lifecycle=%s",
+		aws.ToString(stack.StackId), len(evs), query.IncludeNested, lc)
+
+	return httpresp.OK(200, types.EventsResponse{
+		Account:   accountName,
+		Region:    targetCfg.Region,
+		Owner:     s.owner,
+		StackName: aws.ToString(stack.StackName),
+		StackID:   aws.ToString(stack.StackId),
+		Status:    string(stack.StackStatus),
+		Lifecycle: string(lc),
+		Terminal:  lc.Terminal(),
+		Events:    evs,
+		Cursor:    cursor,
+	}), nil
+}
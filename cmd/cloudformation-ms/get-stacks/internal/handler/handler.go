@@ -2,21 +2,58 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudformation"
 
+	"get-stacks/internal/cfn"
 	"get-stacks/internal/httpresp"
+	"get-stacks/internal/types"
 )
 
+// Handler atende GET /cf/stacks.
 func Handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
-	log.Print("[INFO] Incoming request: reqId=%s method=%s path=%s",
-		req.RequestContext.RequestID, req.RequestContext.HTTP.Method, req.RawPath)
+	s, errResp := newSession(ctx, req)
+	if errResp != nil {
+		return *errResp, nil
+	}
+
+	q := req.QueryStringParameters
+	accountName := strings.TrimSpace(q["accountName"])
+	if accountName == "" {
+		return httpresp.Error(400, errors.New("query parameter 'accountName' is required")), nil
+	}
+
+	filter, err := cfn.FilterFromQuery(q)
+	if err != nil {
+		return httpresp.Error(400, err), nil
+	}
+	log.Printf("[INFO] List filters: accountName=%s statuses=%d namePrefix=%q tags=%d limit=%d continuation=%t",
+		accountName, len(filter.Statuses), filter.NamePrefix, len(filter.Tags), filter.Limit, q["nextToken"] != "")
+
+	targetCfg, errResp := s.targetConfig(ctx, accountName, queryRegion(req))
+	if errResp != nil {
+		return *errResp, nil
+	}
 
-	cfg, err := awsconfig.Base(ctx)
+	stacks, next, err := cfn.ListStacks(ctx, cf.NewFromConfig(targetCfg), filter, q["nextToken"])
 	if err != nil {
-		return httpresp.Error(500, fmt.Errorf("aws config error: %w", err)), nil
+		if errors.Is(err, cfn.ErrInvalidToken) {
+			return httpresp.Error(400, err), nil
+		}
+		return httpresp.Error(502, fmt.Errorf("list stacks failed: %w", err)), nil
 	}
+	log.Printf("[INFO] Listed stacks: count=%d hasMore=%t", len(stacks), next != "")
 
+	return httpresp.OK(200, types.ListResponse{
+		Account:   accountName,
+		Region:    targetCfg.Region,
+		Owner:     s.owner,
+		Stacks:    stacks,
+		NextToken: next,
+	}), nil
 }
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cip "github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	sm "github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"get-stacks/internal/auth"
+	"get-stacks/internal/awsconfig"
+	"get-stacks/internal/credentials"
+	"get-stacks/internal/httpresp"
+)
+
+type deps struct {
+	cip *cip.Client
+	sm  *sm.Client
+}
+
+func (d *deps) Cognito() *cip.Client { return d.cip }
+
+// session guarda o que todo endpoint precisa antes de falar com a conta alvo:
+// config base da Lambda, clientes compartilhados e o owner autenticado.
+type session struct {
+	cfg   aws.Config
+	deps  *deps
+	owner string
+}
+
+func newSession(ctx context.Context, req events.APIGatewayV2HTTPRequest) (*session, *events.APIGatewayV2HTTPResponse) {
+	log.Printf("[INFO] Incoming request: reqId=%s method=%s path=%s",
+		req.RequestContext.RequestID, req.RequestContext.HTTP.Method, req.RawPath)
+
+	// ---- SDK base ----
+	cfg, err := awsconfig.Base(ctx)
+	if err != nil {
+		resp := httpresp.Error(500, fmt.Errorf("aws config error: %w", err))
+		return nil, &resp
+	}
+
+	d := &deps{
+		cip: cip.NewFromConfig(cfg),
+		sm:  sm.NewFromConfig(cfg),
+	}
+
+	owner, err := auth.OwnerFromRequest(ctx, req, cfg, d)
+	if err != nil || owner == "" {
+		resp := httpresp.Error(401, errors.New("unauthorized"))
+		return nil, &resp
+	}
+	log.Printf("[INFO] Authenticated owner=%s", owner)
+
+	return &session{cfg: cfg, deps: d, owner: owner}, nil
+}
+
+// targetConfig resolve owner -> secret -> config da conta alvo na região
+// pedida (vazia = região padrão da conta).
+func (s *session) targetConfig(ctx context.Context, accountName, region string) (aws.Config, *events.APIGatewayV2HTTPResponse) {
+	// ---- Secrets Manager: credenciais da conta alvo ----
+	secretName := fmt.Sprintf("%s/%s/access_keys", s.owner, accountName)
+	log.Printf("[INFO] Fetching credentials from secret: %s", secretName)
+
+	keys, err := credentials.GetAccountCreds(ctx, s.deps.sm, secretName)
+	if err != nil {
+		resp := httpresp.Error(404, fmt.Errorf("failed to get credentials from secrets manager: %w", err))
+		return aws.Config{}, &resp
+	}
+
+	region, err = credentials.ResolveRegion(keys, region, s.cfg.Region)
+	if err != nil {
+		status := 400
+		if errors.Is(err, credentials.ErrRegionNotAllowed) {
+			status = 403
+		}
+		resp := httpresp.Error(status, fmt.Errorf("account '%s': %w", accountName, err))
+		return aws.Config{}, &resp
+	}
+
+	// ---- Config alvo (credenciais / assume role / sts check) ----
+	targetCfg, err := credentials.BuildTargetConfig(ctx, s.cfg, keys, region)
+	if err != nil {
+		resp := httpresp.Error(401, fmt.Errorf("invalid credentials for account '%s' in region '%s': %w", accountName, region, err))
+		return aws.Config{}, &resp
+	}
+	return targetCfg, nil
+}
+
+// queryRegion lê ?region=; vazio usa a região padrão da conta.
+func queryRegion(req events.APIGatewayV2HTTPRequest) string {
+	return strings.TrimSpace(req.QueryStringParameters["region"])
+}
@@ -0,0 +1,191 @@
+package inventory
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"get-stacks/internal/types"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+
+	// Mesmo layout gravado pela cloudformation-ms (create-stack/internal/inventory)
+	indexName = "gsi1"
+	latestKey = "LATEST"
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid nextToken")
+	ErrStackNotFound = errors.New("stack not found in inventory")
+)
+
+type API interface {
+	dynamodb.QueryAPIClient
+	GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+}
+
+type Reader struct {
+	api   API
+	table string
+}
+
+func NewReader(api API, table string) *Reader {
+	return &Reader{api: api, table: table}
+}
+
+// Stacks devolve a última operação de cada stack do owner, opcionalmente só
+// de uma conta. Nenhuma chamada vai para as contas alvo.
+func (r *Reader) Stacks(ctx context.Context, owner, account string, limit int, nextToken string) ([]types.Deployment, string, error) {
+	cond := "gsi1pk = :pk"
+	values := map[string]ddbtypes.AttributeValue{
+		":pk": &ddbtypes.AttributeValueMemberS{Value: "OWNER#" + owner},
+	}
+	if account != "" {
+		cond += " AND begins_with(gsi1sk, :account)"
+		values[":account"] = &ddbtypes.AttributeValueMemberS{Value: account + "#"}
+	}
+	return r.query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.table),
+		IndexName:                 aws.String(indexName),
+		KeyConditionExpression:    aws.String(cond),
+		ExpressionAttributeValues: values,
+	}, limit, nextToken)
+}
+
+// History devolve as operações de uma stack do owner, da mais nova para a
+// mais antiga.
+func (r *Reader) History(ctx context.Context, owner, stackID string, limit int, nextToken string) ([]types.Deployment, string, error) {
+	out, err := r.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.table),
+		Key: map[string]ddbtypes.AttributeValue{
+			"pk": &ddbtypes.AttributeValueMemberS{Value: "STACK#" + stackID},
+			"sk": &ddbtypes.AttributeValueMemberS{Value: latestKey},
+		},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	// Stacks de outro owner respondem como inexistentes
+	if len(out.Item) == 0 || str(out.Item, "owner") != owner {
+		return nil, "", ErrStackNotFound
+	}
+
+	return r.query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.table),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :op)"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":pk": &ddbtypes.AttributeValueMemberS{Value: "STACK#" + stackID},
+			":op": &ddbtypes.AttributeValueMemberS{Value: "OP#"},
+		},
+		ScanIndexForward: aws.Bool(false),
+	}, limit, nextToken)
+}
+
+func (r *Reader) query(ctx context.Context, in *dynamodb.QueryInput, limit int, nextToken string) ([]types.Deployment, string, error) {
+	start, err := decodeToken(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	in.ExclusiveStartKey = start
+	in.Limit = aws.Int32(int32(limit))
+
+	out, err := r.api.Query(ctx, in)
+	if err != nil {
+		return nil, "", err
+	}
+	items := make([]types.Deployment, 0, len(out.Items))
+	for _, it := range out.Items {
+		items = append(items, deployment(it))
+	}
+	return items, encodeToken(out.LastEvaluatedKey), nil
+}
+
+// O token é a LastEvaluatedKey do DynamoDB; todas as chaves são strings.
+func encodeToken(key map[string]ddbtypes.AttributeValue) string {
+	if len(key) == 0 {
+		return ""
+	}
+	m := map[string]string{}
+	for k := range key {
+		m[k] = str(key, k)
+	}
+	b, _ := json.Marshal(m)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeToken(s string) (map[string]ddbtypes.AttributeValue, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil || len(m) == 0 {
+		return nil, ErrInvalidToken
+	}
+	key := map[string]ddbtypes.AttributeValue{}
+	for k, v := range m {
+		key[k] = &ddbtypes.AttributeValueMemberS{Value: v}
+	}
+	return key, nil
+}
+
+func deployment(it map[string]ddbtypes.AttributeValue) types.Deployment {
+	d := types.Deployment{
+		Account:        str(it, "account"),
+		Region:         str(it, "region"),
+		StackName:      str(it, "stackName"),
+		StackID:        str(it, "stackId"),
+		Operation:      str(it, "operation"),
+		Status:         str(it, "status"),
+		Lifecycle:      str(it, "lifecycle"),
+		TemplateHash:   str(it, "templateHash"),
+		RequestID:      str(it, "requestId"),
+		StartedAt:      timestamp(it, "startedAt"),
+		FinishedAt:     timestamp(it, "finishedAt"),
+		FailureReason:  str(it, "failureReason"),
+		FailedResource: str(it, "failedResource"),
+	}
+	if v, ok := it["durationSeconds"].(*ddbtypes.AttributeValueMemberN); ok {
+		if n, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+			d.DurationSeconds = &n
+		}
+	}
+	if v, ok := it["parameters"].(*ddbtypes.AttributeValueMemberM); ok {
+		d.Parameters = map[string]string{}
+		for k := range v.Value {
+			d.Parameters[k] = str(v.Value, k)
+		}
+	}
+	return d
+}
+
+func str(it map[string]ddbtypes.AttributeValue, key string) string {
+	if v, ok := it[key].(*ddbtypes.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+func timestamp(it map[string]ddbtypes.AttributeValue, key string) *time.Time {
+	t, err := time.Parse(time.RFC3339Nano, str(it, key))
+	if err != nil {
+		return nil
+	}
+	return &t
+}
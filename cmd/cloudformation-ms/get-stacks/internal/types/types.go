@@ -1,8 +1,141 @@
 package types
 
-type RequestBody struct {
-	StackName       string `json:"stackName"`
-	AccessKeyID     string `json:"acessKey"`
+import "time"
+
+type SecretKeys struct {
+	AccessKeyID     string `json:"accessKeyId"`
 	SecretAccessKey string `json:"secretAccessKey"`
-	Region          string `json:"region"`
+	SessionToken    string `json:"sessionToken,omitempty"`
+	RoleARN         string `json:"roleArn,omitempty"`
+	ExternalID      string `json:"externalId,omitempty"`
+
+	// Regiões da conta, gravadas no cadastro (register-keys)
+	DefaultRegion  string   `json:"defaultRegion,omitempty"`
+	AllowedRegions []string `json:"allowedRegions,omitempty"`
+}
+
+type StackSummary struct {
+	StackName    string            `json:"stackName"`
+	StackID      string            `json:"stackId"`
+	Status       string            `json:"status"`
+	StatusReason string            `json:"statusReason,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	DriftStatus  string            `json:"driftStatus,omitempty"`
+	ParentID     string            `json:"parentId,omitempty"`
+	RootID       string            `json:"rootId,omitempty"`
+	CreatedAt    *time.Time        `json:"createdAt,omitempty"`
+	UpdatedAt    *time.Time        `json:"updatedAt,omitempty"`
+	DeletedAt    *time.Time        `json:"deletedAt,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+}
+
+type ListResponse struct {
+	Account   string         `json:"account"`
+	Region    string         `json:"region"`
+	Owner     string         `json:"owner"`
+	Stacks    []StackSummary `json:"stacks"`
+	NextToken string         `json:"nextToken,omitempty"`
+}
+
+type StackOutput struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	ExportName  string `json:"exportName,omitempty"`
+}
+
+type StackParameter struct {
+	Key           string `json:"key"`
+	Value         string `json:"value"`
+	ResolvedValue string `json:"resolvedValue,omitempty"` // parâmetros do tipo SSM
+}
+
+type StackResource struct {
+	LogicalID    string     `json:"logicalId"`
+	PhysicalID   string     `json:"physicalId,omitempty"`
+	Type         string     `json:"type"`
+	Status       string     `json:"status"`
+	StatusReason string     `json:"statusReason,omitempty"`
+	DriftStatus  string     `json:"driftStatus,omitempty"`
+	UpdatedAt    *time.Time `json:"updatedAt,omitempty"`
+}
+
+type StackDetail struct {
+	Account                     string            `json:"account"`
+	Region                      string            `json:"region"`
+	Owner                       string            `json:"owner"`
+	StackName                   string            `json:"stackName"`
+	StackID                     string            `json:"stackId"`
+	Status                      string            `json:"status"`
+	Lifecycle                   string            `json:"lifecycle"`
+	StatusReason                string            `json:"statusReason,omitempty"`
+	Description                 string            `json:"description,omitempty"`
+	Capabilities                []string          `json:"capabilities,omitempty"`
+	RoleARN                     string            `json:"roleArn,omitempty"`
+	DisableRollback             bool              `json:"disableRollback"`
+	EnableTerminationProtection bool              `json:"enableTerminationProtection"`
+	DriftStatus                 string            `json:"driftStatus,omitempty"`
+	ParentID                    string            `json:"parentId,omitempty"`
+	RootID                      string            `json:"rootId,omitempty"`
+	CreatedAt                   *time.Time        `json:"createdAt,omitempty"`
+	UpdatedAt                   *time.Time        `json:"updatedAt,omitempty"`
+	DeletedAt                   *time.Time        `json:"deletedAt,omitempty"`
+	Outputs                     []StackOutput     `json:"outputs"`
+	Parameters                  []StackParameter  `json:"parameters"`
+	Tags                        map[string]string `json:"tags"`
+	Resources                   []StackResource   `json:"resources"`
+}
+
+type StackEvent struct {
+	EventID            string    `json:"eventId"`
+	StackID            string    `json:"stackId"`
+	StackName          string    `json:"stackName"`
+	LogicalID          string    `json:"logicalId"`
+	PhysicalID         string    `json:"physicalId,omitempty"`
+	ResourceType       string    `json:"resourceType"`
+	Status             string    `json:"status"`
+	StatusReason       string    `json:"statusReason,omitempty"`
+	ClientRequestToken string    `json:"clientRequestToken,omitempty"`
+	Timestamp          time.Time `json:"timestamp"`
+	Nested             bool      `json:"nested,omitempty"`
+}
+
+type EventsResponse struct {
+	Account   string       `json:"account"`
+	Region    string       `json:"region"`
+	Owner     string       `json:"owner"`
+	StackName string       `json:"stackName"`
+	StackID   string       `json:"stackId"`
+	Status    string       `json:"status"`
+	Lifecycle string       `json:"lifecycle"`
+	Terminal  bool         `json:"terminal"`
+	Events    []StackEvent `json:"events"`
+	Cursor    string       `json:"cursor,omitempty"`
+}
+
+// Deployment é uma operação registrada no inventário pela cloudformation-ms.
+type Deployment struct {
+	Account         string            `json:"account"`
+	Region          string            `json:"region,omitempty"`
+	StackName       string            `json:"stackName"`
+	StackID         string            `json:"stackId"`
+	Operation       string            `json:"operation"`
+	Status          string            `json:"status"`
+	Lifecycle       string            `json:"lifecycle"`
+	TemplateHash    string            `json:"templateHash,omitempty"`
+	Parameters      map[string]string `json:"parameters,omitempty"` // NoEcho mascarado
+	RequestID       string            `json:"requestId,omitempty"`
+	StartedAt       *time.Time        `json:"startedAt,omitempty"`
+	FinishedAt      *time.Time        `json:"finishedAt,omitempty"`
+	DurationSeconds *int64            `json:"durationSeconds,omitempty"`
+	FailureReason   string            `json:"failureReason,omitempty"`
+	FailedResource  string            `json:"failedResource,omitempty"`
+}
+
+type DeploymentsResponse struct {
+	Owner       string       `json:"owner"`
+	Account     string       `json:"account,omitempty"`
+	StackID     string       `json:"stackId,omitempty"`
+	Deployments []Deployment `json:"deployments"`
+	NextToken   string       `json:"nextToken,omitempty"`
 }
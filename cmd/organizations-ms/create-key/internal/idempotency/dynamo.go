@@ -0,0 +1,138 @@
+// Cópia de cmd/cloudformation-ms/create-stack/internal/idempotency/dynamo.go;
+// altere a versão canônica primeiro.
+
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type API interface {
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// DynamoStore grava um item por chave: pk=IDEMPOTENCY#<scope>, sk=<key>.
+// O atributo expiresAt (epoch em segundos) é o TTL da tabela.
+type DynamoStore struct {
+	api   API
+	table string
+}
+
+func NewDynamoStore(api API, table string) *DynamoStore {
+	return &DynamoStore{api: api, table: table}
+}
+
+func key(scope, k string) map[string]ddbtypes.AttributeValue {
+	return map[string]ddbtypes.AttributeValue{
+		"pk": &ddbtypes.AttributeValueMemberS{Value: "IDEMPOTENCY#" + scope},
+		"sk": &ddbtypes.AttributeValueMemberS{Value: k},
+	}
+}
+
+func (s *DynamoStore) Claim(ctx context.Context, r Record, now time.Time) (Record, bool, error) {
+	epoch := strconv.FormatInt(now.Unix(), 10)
+	_, err := s.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      toItem(r),
+		// O TTL do DynamoDB pode levar horas para apagar o item: expirado conta como livre
+		ConditionExpression: aws.String("attribute_not_exists(pk) OR expiresAt < :now OR " +
+			"(#status = :inProgress AND lockedUntil < :now AND fingerprint = :fp)"),
+		ExpressionAttributeNames: map[string]string{"#status": "status"},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":now":        &ddbtypes.AttributeValueMemberN{Value: epoch},
+			":inProgress": &ddbtypes.AttributeValueMemberS{Value: StatusInProgress},
+			":fp":         &ddbtypes.AttributeValueMemberS{Value: r.Fingerprint},
+		},
+		ReturnValuesOnConditionCheckFailure: ddbtypes.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	var ccf *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return fromItem(ccf.Item), false, nil
+	}
+	if err != nil {
+		return Record{}, false, err
+	}
+	return r, true, nil
+}
+
+func (s *DynamoStore) Complete(ctx context.Context, r Record) error {
+	_, err := s.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      toItem(r),
+	})
+	return err
+}
+
+func (s *DynamoStore) Release(ctx context.Context, scope, k string) error {
+	_, err := s.api.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                aws.String(s.table),
+		Key:                      key(scope, k),
+		ConditionExpression:      aws.String("#status = :inProgress"),
+		ExpressionAttributeNames: map[string]string{"#status": "status"},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":inProgress": &ddbtypes.AttributeValueMemberS{Value: StatusInProgress},
+		},
+	})
+	var ccf *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil
+	}
+	return err
+}
+
+func toItem(r Record) map[string]ddbtypes.AttributeValue {
+	item := key(r.Scope, r.Key)
+	item["fingerprint"] = &ddbtypes.AttributeValueMemberS{Value: r.Fingerprint}
+	item["status"] = &ddbtypes.AttributeValueMemberS{Value: r.Status}
+	item["lockedUntil"] = &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(r.LockedUntil.Unix(), 10)}
+	item["expiresAt"] = &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(r.ExpiresAt.Unix(), 10)}
+	if r.Status == StatusCompleted {
+		headers, _ := json.Marshal(r.Headers)
+		item["statusCode"] = &ddbtypes.AttributeValueMemberN{Value: strconv.Itoa(r.StatusCode)}
+		item["headers"] = &ddbtypes.AttributeValueMemberS{Value: string(headers)}
+		item["body"] = &ddbtypes.AttributeValueMemberS{Value: r.Body}
+	}
+	return item
+}
+
+func fromItem(item map[string]ddbtypes.AttributeValue) Record {
+	r := Record{
+		Scope:       strings.TrimPrefix(str(item, "pk"), "IDEMPOTENCY#"),
+		Key:         str(item, "sk"),
+		Fingerprint: str(item, "fingerprint"),
+		Status:      str(item, "status"),
+		LockedUntil: time.Unix(num(item, "lockedUntil"), 0),
+		ExpiresAt:   time.Unix(num(item, "expiresAt"), 0),
+		StatusCode:  int(num(item, "statusCode")),
+		Body:        str(item, "body"),
+	}
+	if h := str(item, "headers"); h != "" {
+		_ = json.Unmarshal([]byte(h), &r.Headers)
+	}
+	return r
+}
+
+func str(item map[string]ddbtypes.AttributeValue, name string) string {
+	if v, ok := item[name].(*ddbtypes.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+func num(item map[string]ddbtypes.AttributeValue, name string) int64 {
+	if v, ok := item[name].(*ddbtypes.AttributeValueMemberN); ok {
+		n, _ := strconv.ParseInt(v.Value, 10, 64)
+		return n
+	}
+	return 0
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -15,8 +16,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	cip "github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	sm "github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+
+	"create-key/internal/idempotency"
+	"create-key/internal/tagpolicy"
 )
 
 type requestBody struct {
@@ -25,6 +30,8 @@ type requestBody struct {
 	SecretAccessKey string            `json:"secretAccessKey"`
 	Description     string            `json:"description,omitempty"`
 	Tags            map[string]string `json:"tags,omitempty"`
+	DefaultRegion   string            `json:"defaultRegion,omitempty"`  // Padrão: região da Lambda
+	AllowedRegions  []string          `json:"allowedRegions,omitempty"` // Regiões extras liberadas para deploy
 }
 
 type responseBody struct {
@@ -33,8 +40,13 @@ type responseBody struct {
 	VersionId  string `json:"versionId,omitempty"`
 	Owner      string `json:"owner,omitempty"`
 	Account    string `json:"account,omitempty"`
+
+	DefaultRegion  string   `json:"defaultRegion,omitempty"`
+	AllowedRegions []string `json:"allowedRegions,omitempty"`
 }
 
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-\d$`)
+
 func newAWS(ctx context.Context) (aws.Config, error) {
 	if region := os.Getenv("AWS_REGION"); region == "" {
 		if def := os.Getenv("AWS_DEFAULT_REGION"); def != "" {
@@ -52,10 +64,17 @@ func newCognitoClient(cfg aws.Config) *cip.Client {
 	return cip.NewFromConfig(cfg)
 }
 
-func buildSecretString(accessKeyID, secretAccessKey string) (string, error) {
-	payload := map[string]string{
-		"accessKeyId":     accessKeyID,
-		"secretAccessKey": secretAccessKey,
+func buildSecretString(body requestBody) (string, error) {
+	payload := map[string]any{
+		"accessKeyId":     body.AccessKeyID,
+		"secretAccessKey": body.SecretAccessKey,
+	}
+	// Lidos pela cloudformation-ms para escolher e validar a região de cada requisição
+	if body.DefaultRegion != "" {
+		payload["defaultRegion"] = body.DefaultRegion
+	}
+	if len(body.AllowedRegions) > 0 {
+		payload["allowedRegions"] = body.AllowedRegions
 	}
 	b, err := json.Marshal(payload)
 	if err != nil {
@@ -131,24 +150,43 @@ func handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.AP
 	if body.AccessKeyID == "" || body.SecretAccessKey == "" {
 		return apiError(400, errors.New("fields 'accessKeyId' and 'secretAccessKey' are required")), nil
 	}
+	if body.DefaultRegion == "" {
+		body.DefaultRegion = os.Getenv("AWS_REGION")
+	}
+	for _, r := range append([]string{body.DefaultRegion}, body.AllowedRegions...) {
+		if !regionPattern.MatchString(r) {
+			return apiError(400, fmt.Errorf("invalid region '%s'", r)), nil
+		}
+	}
 
 	secretName := fmt.Sprintf("%s/%s/access_keys", owner, body.AccountName)
 
-	secretString, err := buildSecretString(body.AccessKeyID, body.SecretAccessKey)
+	secretString, err := buildSecretString(body)
 	if err != nil {
 		return apiError(500, fmt.Errorf("failed to build secret payload: %w", err)), nil
 	}
 
-	smClient := newSecretsClient(cfg)
-
-	if body.Tags == nil {
-		body.Tags = map[string]string{}
+	policy, err := tagpolicy.FromEnv()
+	if err != nil {
+		log.Printf("tag policy err: %v", err)
+		return apiError(500, errors.New("internal error")), nil
+	}
+	tags, err := policy.Apply(body.Tags, map[string]string{
+		// owner e account são as tags antigas, mantidas para quem já filtra por elas
+		"owner":                owner,
+		"account":              body.AccountName,
+		tagpolicy.TagOwner:     owner,
+		tagpolicy.TagAccount:   body.AccountName,
+		tagpolicy.TagRequestID: req.RequestContext.RequestID,
+	}, tagpolicy.SecretsManagerLimits)
+	if err != nil {
+		return apiTagPolicyError(err), nil
 	}
-	body.Tags["owner"] = owner
-	body.Tags["account"] = body.AccountName
+
+	smClient := newSecretsClient(cfg)
 
 	var smTags []types.Tag
-	for k, v := range body.Tags {
+	for k, v := range tags {
 		smTags = append(smTags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
 	}
 
@@ -175,6 +213,9 @@ func handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.AP
 			VersionId:  aws.ToString(putOut.VersionId),
 			Owner:      owner,
 			Account:    body.AccountName,
+
+			DefaultRegion:  body.DefaultRegion,
+			AllowedRegions: body.AllowedRegions,
 		}
 		return apiOK(200, resp), nil
 	}
@@ -190,6 +231,9 @@ func handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.AP
 		VersionId:  aws.ToString(createOut.VersionId),
 		Owner:      owner,
 		Account:    body.AccountName,
+
+		DefaultRegion:  body.DefaultRegion,
+		AllowedRegions: body.AllowedRegions,
 	}
 	return apiOK(201, resp), nil
 }
@@ -219,6 +263,43 @@ func apiError(status int, err error) events.APIGatewayV2HTTPResponse {
 	}
 }
 
+// apiTagPolicyError responde 400 listando cada violação da tag policy.
+func apiTagPolicyError(err error) events.APIGatewayV2HTTPResponse {
+	var verr *tagpolicy.ViolationError
+	if !errors.As(err, &verr) {
+		return apiError(400, err)
+	}
+	out := map[string]any{
+		"message":    fmt.Sprintf("tag policy violated (%d violations)", len(verr.Violations)),
+		"violations": verr.Violations,
+	}
+	b, _ := json.Marshal(out)
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: 400,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(b),
+	}
+}
+
+// idempotentHandler aplica o header Idempotency-Key quando
+// IDEMPOTENCY_TABLE_NAME está definido; sem a tabela o header é ignorado.
+func idempotentHandler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	table := os.Getenv("IDEMPOTENCY_TABLE_NAME")
+	if table == "" {
+		return handler(ctx, req)
+	}
+	cfg, err := newAWS(ctx)
+	if err != nil {
+		log.Println("aws config err:", err)
+		return apiError(500, errors.New("internal error")), nil
+	}
+	store := idempotency.NewDynamoStore(dynamodb.NewFromConfig(cfg), table)
+	return idempotency.New(store).Wrap(handler)(ctx, req)
+}
+
 func main() {
-	lambda.Start(handler)
+	lambda.Start(idempotentHandler)
 }
@@ -8,6 +8,18 @@ resource "aws_cognito_user_pool" "user_pool" {
     email_sending_account = "COGNITO_DEFAULT"
   }
 
+  # Preferência do owner: "true" permite acrescentar automaticamente as
+  # capabilities detectadas no template (CAPABILITY_IAM, ...).
+  schema {
+    name                = "auto_capabilities"
+    attribute_data_type = "String"
+    mutable             = true
+    string_attribute_constraints {
+      min_length = 0
+      max_length = 5
+    }
+  }
+
 }
 
 resource "aws_cognito_user_pool_client" "client" {
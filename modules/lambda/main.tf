@@ -37,4 +37,33 @@ module "this" {
       ]
     }
   ]
+}
+
+resource "aws_cloudwatch_event_rule" "schedule" {
+  count               = var.schedule_expression == null ? 0 : 1
+  name                = "${var.name}-schedule"
+  schedule_expression = var.schedule_expression
+}
+
+resource "aws_cloudwatch_event_target" "schedule" {
+  count = var.schedule_expression == null ? 0 : 1
+  rule  = aws_cloudwatch_event_rule.schedule[0].name
+  arn   = module.this.lambda_function_arn
+}
+
+resource "aws_lambda_permission" "schedule" {
+  count         = var.schedule_expression == null ? 0 : 1
+  statement_id  = "AllowEventBridgeSchedule"
+  action        = "lambda:InvokeFunction"
+  function_name = module.this.lambda_function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.schedule[0].arn
+}
+
+resource "aws_lambda_event_source_mapping" "queue" {
+  count                   = var.queue_arn == null ? 0 : 1
+  event_source_arn        = var.queue_arn
+  function_name           = module.this.lambda_function_arn
+  batch_size              = var.queue_batch_size
+  function_response_types = ["ReportBatchItemFailures"]
 }
\ No newline at end of file
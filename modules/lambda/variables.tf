@@ -17,4 +17,15 @@ variable "timeout" {
 }
 variable "policy_json" {
   default = null
+}
+variable "schedule_expression" {
+  description = "Expressão do EventBridge (ex. rate(1 day)); null para Lambdas só de API"
+  default     = null
+}
+variable "queue_arn" {
+  description = "Fila SQS que dispara a Lambda; null para Lambdas só de API"
+  default     = null
+}
+variable "queue_batch_size" {
+  default = 10
 }
\ No newline at end of file
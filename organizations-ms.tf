@@ -14,9 +14,11 @@ module "create_secret_keys_lambda" {
   architectures                           = ["arm64"]
 
   environment_variables = {
-    USER_POOL_CLIENT_ID = aws_cognito_user_pool_client.client.id
-    USER_POOL_ID        = aws_cognito_user_pool.user_pool.id
-    REGION              = var.region
+    USER_POOL_CLIENT_ID    = aws_cognito_user_pool_client.client.id
+    USER_POOL_ID           = aws_cognito_user_pool.user_pool.id
+    REGION                 = var.region
+    IDEMPOTENCY_TABLE_NAME = module.idempotency_dynamodb.dynamodb_table_id
+    TAG_POLICY             = jsonencode(var.tag_policy)
   }
 
   allowed_triggers = {
@@ -39,6 +41,11 @@ module "create_secret_keys_lambda" {
         ]
         Resource = "*"
       },
+      {
+        Effect   = "Allow"
+        Action   = ["dynamodb:PutItem", "dynamodb:DeleteItem"]
+        Resource = module.idempotency_dynamodb.dynamodb_table_arn
+      },
     ]
   })
 
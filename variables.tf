@@ -15,4 +15,46 @@ variable "project" {
 
 variable "region" {
   default = "us-east-1"
+}
+
+# Tag policy das stacks e dos secrets: chaves obrigatórias e padrões de valor.
+# Ex.: { rules = [{ key = "cost-center", required = true, pattern = "^CC-\\d{4}$" }] }
+variable "tag_policy" {
+  type = object({
+    rules = list(object({
+      key      = string
+      required = optional(bool, false)
+      pattern  = optional(string, "")
+    }))
+  })
+  default = {
+    rules = []
+  }
+}
+
+# Scan de segurança automático no create-stack, nos change sets de criação e
+# nos stack sets novos: "off", "warn" (findings na resposta) ou "enforce"
+# (findings de severidade high bloqueiam a criação).
+variable "security_scan" {
+  type    = string
+  default = "off"
+
+  validation {
+    condition     = contains(["off", "warn", "enforce"], var.security_scan)
+    error_message = "security_scan must be off, warn or enforce."
+  }
+}
+
+# Lint do template contra a especificação de recursos do CloudFormation no
+# create-stack: "warn" só registra os erros no log; "enforce" rejeita templates
+# com erros; "off" desliga. A especificação embutida é parcial, por isso o
+# padrão não bloqueia.
+variable "template_lint" {
+  type    = string
+  default = "warn"
+
+  validation {
+    condition     = contains(["off", "warn", "enforce"], var.template_lint)
+    error_message = "template_lint must be off, warn or enforce."
+  }
 }
\ No newline at end of file